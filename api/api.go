@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/ONSdigital/dp-dimension-importer/report"
+	"github.com/ONSdigital/dp-dimension-importer/store"
+	"github.com/ONSdigital/dp-net/v2/request"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+var packageName = "api.API"
+
+// API provides the HTTP endpoints exposed by the dimension importer, other than the health check
+type API struct {
+	Router    *mux.Router
+	Reports   *report.Store
+	Reorderer Reorderer      // the reorder endpoints are not registered if nil
+	CodeLists CodeListReader // the code list reorder endpoint is not registered if nil
+	Importer  Importer       // the import endpoint is not registered if nil
+
+	// ServiceAuthToken is the "Bearer {token}" authorization header required by the admin endpoints, which reorder and import instances.
+	// Every admin request is forbidden if it is empty.
+	ServiceAuthToken string
+
+	jobs sync.WaitGroup // reorder and import jobs in progress
+}
+
+// Setup creates the API and registers its endpoints in the provided router.
+// The reorderer, code list reader and importer are optional, the endpoints that need them are only registered if they are provided.
+// The admin endpoints require the provided service auth token.
+func Setup(ctx context.Context, router *mux.Router, reports *report.Store, reorderer Reorderer, codeLists CodeListReader, importer Importer, serviceAuthToken string) *API {
+	api := &API{
+		Router:           router,
+		Reports:          reports,
+		Reorderer:        reorderer,
+		CodeLists:        codeLists,
+		Importer:         importer,
+		ServiceAuthToken: serviceAuthToken,
+	}
+
+	router.HandleFunc("/reports/{instance_id}", api.getReport).Methods(http.MethodGet)
+	if reorderer != nil {
+		router.HandleFunc("/instances/{instance_id}/reorder", api.serviceAuth(api.reorderInstance)).Methods(http.MethodPost)
+		if codeLists != nil {
+			router.HandleFunc("/code-lists/{code_list_id}/reorder", api.serviceAuth(api.reorderCodeList)).Methods(http.MethodPost)
+		}
+	}
+
+	if importer != nil {
+		router.HandleFunc("/instances/{instance_id}/import", api.serviceAuth(api.importInstance)).Methods(http.MethodPost)
+	}
+
+	log.Info(ctx, "api endpoints registered", log.Data{"package": packageName})
+	return api
+}
+
+// getReport writes the data-quality report of the requested instance as a JSON response body
+func (api *API) getReport(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	instanceID := mux.Vars(req)["instance_id"]
+	logData := log.Data{"instance_id": instanceID, "package": packageName}
+
+	rep, found := api.Reports.Get(instanceID)
+	if !found {
+		log.Info(ctx, "import report not found", logData)
+		http.Error(w, "import report not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(ctx, w, rep.Summary(), logData)
+}
+
+// Close waits for the reorder and import jobs in progress to finish, until the provided context is done
+func (api *API) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		api.jobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// serviceAuth returns a handler that only calls the provided admin handler if the request is authorised with the service auth token.
+// It responds with 401 Unauthorized if the request has no authorization header, and 403 Forbidden if it has another token.
+func (api *API) serviceAuth(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		logData := log.Data{"path": req.URL.Path, "package": packageName}
+
+		token := req.Header.Get(request.AuthHeaderKey)
+		if token == "" {
+			log.Info(ctx, "admin request without authorization header", logData)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if api.ServiceAuthToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(api.ServiceAuthToken)) != 1 {
+			log.Info(ctx, "admin request with an invalid service auth token", logData)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		handler(w, req)
+	}
+}
+
+// validIDs returns true if the provided IDs of the request path are valid graph database IDs,
+// otherwise it responds with 400 Bad Request, so that they are never written into a graph query
+func validIDs(ctx context.Context, w http.ResponseWriter, logData log.Data, ids ...string) bool {
+	if err := store.ValidateID(ids...); err != nil {
+		log.Info(ctx, "invalid id in request path", log.Data{"package": packageName, "error": err.Error(), "request": logData})
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// writeJSON marshals the provided value and writes it to the response with a 200 OK status
+func writeJSON(ctx context.Context, w http.ResponseWriter, v interface{}, logData log.Data) {
+	writeJSONStatus(ctx, w, http.StatusOK, v, logData)
+}
+
+// writeJSONStatus marshals the provided value and writes it to the response with the provided status
+func writeJSONStatus(ctx context.Context, w http.ResponseWriter, status int, v interface{}, logData log.Data) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.Error(ctx, "failed to marshal response body", err, logData)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(b); err != nil {
+		log.Error(ctx, "failed to write response body", err, logData)
+	}
+}
@@ -0,0 +1,62 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-dimension-importer/api"
+	"github.com/ONSdigital/dp-dimension-importer/report"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var ctx = context.Background()
+
+// serviceAuthToken is the service auth token required by the admin endpoints
+const serviceAuthToken = "Bearer test-token"
+
+// adminRequest returns a new request authorised with the service auth token
+func adminRequest(method, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	req.Header.Set("Authorization", serviceAuthToken)
+	return req
+}
+
+func TestGetReport(t *testing.T) {
+	Convey("Given an API with a store that contains a report", t, func() {
+		reports := report.NewStore(10)
+		r := report.New("instance1")
+		r.AddOption("geography", "England")
+		r.Complete()
+		reports.Put(r)
+
+		router := mux.NewRouter()
+		api.Setup(ctx, router, reports, nil, nil, nil, serviceAuthToken)
+
+		Convey("When the report is requested", func() {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports/instance1", http.NoBody))
+
+			Convey("Then the report summary is returned as JSON with status 200", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Header().Get("Content-Type"), ShouldEqual, "application/json")
+				var s report.Summary
+				So(json.Unmarshal(w.Body.Bytes(), &s), ShouldBeNil)
+				So(s, ShouldResemble, r.Summary())
+			})
+		})
+
+		Convey("When a report that does not exist is requested", func() {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports/unknown", http.NoBody))
+
+			Convey("Then status 404 is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+			})
+		})
+	})
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ONSdigital/dp-dimension-importer/event"
+	"github.com/ONSdigital/dp-net/v2/request"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+//go:generate moq -out mock/import.go -pkg mock . Importer
+
+// Importer imports the dimensions of an instance, as if the provided NewInstance event had been consumed
+type Importer interface {
+	Handle(ctx context.Context, newInstance event.NewInstance) error
+}
+
+// ImportRequest is the request body of an import request. The file URL is required, as it is sent in the completion event of the import
+type ImportRequest struct {
+	FileURL     string `json:"file_url"`
+	Force       bool   `json:"force"`       // import the instance again, deleting its existing nodes, if it has already been imported
+	Incremental bool   `json:"incremental"` // import only the dimension options missing from the graph database, if the instance has already been imported
+}
+
+// ImportJob is the response body of an import request, describing the import that will be performed
+type ImportJob struct {
+	InstanceID  string `json:"instance_id"`
+	Force       bool   `json:"force"`
+	Incremental bool   `json:"incremental"`
+}
+
+// importInstance starts a job that imports the requested instance, and responds with 202 Accepted.
+// The trace ID of the import is the request ID header, if any.
+func (api *API) importInstance(w http.ResponseWriter, req *http.Request) {
+	ctx := context.WithoutCancel(req.Context())
+	instanceID := mux.Vars(req)["instance_id"]
+	logData := log.Data{"instance_id": instanceID, "package": packageName}
+	if !validIDs(ctx, w, logData, instanceID) {
+		return
+	}
+
+	var body ImportRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		log.Info(ctx, "invalid import request body", log.Data{"instance_id": instanceID, "package": packageName, "error": err.Error()})
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if body.FileURL == "" {
+		log.Info(ctx, "import request without file url", logData)
+		http.Error(w, "file_url is required", http.StatusBadRequest)
+		return
+	}
+
+	newInstance := event.NewInstance{
+		FileURL:     body.FileURL,
+		InstanceID:  instanceID,
+		TraceID:     req.Header.Get(request.RequestHeaderKey),
+		Force:       body.Force,
+		Incremental: body.Incremental,
+	}
+	logData["force"] = body.Force
+	logData["incremental"] = body.Incremental
+	log.Info(ctx, "import job started", logData)
+
+	api.jobs.Add(1)
+	go func() {
+		defer api.jobs.Done()
+		if err := api.Importer.Handle(ctx, newInstance); err != nil {
+			log.Error(ctx, "failed to import instance", err, logData)
+			return
+		}
+		log.Info(ctx, "import job finished", logData)
+	}()
+
+	writeJSONStatus(ctx, w, http.StatusAccepted, ImportJob{InstanceID: instanceID, Force: body.Force, Incremental: body.Incremental}, logData)
+}
@@ -0,0 +1,121 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-dimension-importer/api"
+	"github.com/ONSdigital/dp-dimension-importer/api/mock"
+	"github.com/ONSdigital/dp-dimension-importer/event"
+	"github.com/ONSdigital/dp-dimension-importer/report"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestImport(t *testing.T) {
+	Convey("Given an API with an importer", t, func() {
+		importer := &mock.ImporterMock{
+			HandleFunc: func(ctx context.Context, newInstance event.NewInstance) error {
+				return nil
+			},
+		}
+		router := mux.NewRouter()
+		a := api.Setup(ctx, router, report.NewStore(10), nil, nil, importer, serviceAuthToken)
+
+		Convey("When a forced import of an instance is requested", func() {
+			req := adminRequest(http.MethodPost, "/instances/instance1/import", strings.NewReader(`{"file_url":"s3://bucket/file.csv","force":true}`))
+			req.Header.Set("X-Request-Id", "trace1")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			So(a.Close(ctx), ShouldBeNil)
+
+			Convey("Then status 202 is returned with the import to perform", func() {
+				So(w.Code, ShouldEqual, http.StatusAccepted)
+				var job api.ImportJob
+				So(json.Unmarshal(w.Body.Bytes(), &job), ShouldBeNil)
+				So(job, ShouldResemble, api.ImportJob{InstanceID: "instance1", Force: true})
+			})
+
+			Convey("Then the instance is imported with a forced event, traced by the request ID", func() {
+				So(importer.HandleCalls(), ShouldHaveLength, 1)
+				So(importer.HandleCalls()[0].NewInstance, ShouldResemble, event.NewInstance{
+					FileURL:    "s3://bucket/file.csv",
+					InstanceID: "instance1",
+					TraceID:    "trace1",
+					Force:      true,
+				})
+			})
+		})
+
+		Convey("When a forced import is requested without authorization header", func() {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/instances/instance1/import", strings.NewReader(`{"file_url":"s3://bucket/file.csv","force":true}`)))
+
+			Convey("Then status 401 is returned and nothing is imported", func() {
+				So(w.Code, ShouldEqual, http.StatusUnauthorized)
+				So(importer.HandleCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a forced import is requested with another service auth token", func() {
+			req := httptest.NewRequest(http.MethodPost, "/instances/instance1/import", strings.NewReader(`{"file_url":"s3://bucket/file.csv","force":true}`))
+			req.Header.Set("Authorization", "Bearer another-token")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			Convey("Then status 403 is returned and nothing is imported", func() {
+				So(w.Code, ShouldEqual, http.StatusForbidden)
+				So(importer.HandleCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When an import is requested for an instance id containing quotes", func() {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, adminRequest(http.MethodPost, "/instances/"+url.PathEscape("1')")+"/import", strings.NewReader(`{"file_url":"s3://bucket/file.csv","force":true}`)))
+
+			Convey("Then status 400 is returned and nothing is imported", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(importer.HandleCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When an import is requested without file url", func() {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, adminRequest(http.MethodPost, "/instances/instance1/import", strings.NewReader(`{"force":true}`)))
+
+			Convey("Then status 400 is returned and nothing is imported", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(importer.HandleCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When an import is requested with an invalid body", func() {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, adminRequest(http.MethodPost, "/instances/instance1/import", strings.NewReader(`{`)))
+
+			Convey("Then status 400 is returned and nothing is imported", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(importer.HandleCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given an API without an importer", t, func() {
+		router := mux.NewRouter()
+		api.Setup(ctx, router, report.NewStore(10), nil, nil, nil, serviceAuthToken)
+
+		Convey("When an import is requested", func() {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, adminRequest(http.MethodPost, "/instances/instance1/import", strings.NewReader(`{"file_url":"s3://bucket/file.csv"}`)))
+
+			Convey("Then status 404 is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+			})
+		})
+	})
+}
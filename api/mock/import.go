@@ -0,0 +1,83 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"context"
+	"github.com/ONSdigital/dp-dimension-importer/api"
+	"github.com/ONSdigital/dp-dimension-importer/event"
+	"sync"
+)
+
+// Ensure, that ImporterMock does implement api.Importer.
+// If this is not the case, regenerate this file with moq.
+var _ api.Importer = &ImporterMock{}
+
+// ImporterMock is a mock implementation of api.Importer.
+//
+//	func TestSomethingThatUsesImporter(t *testing.T) {
+//
+//		// make and configure a mocked api.Importer
+//		mockedImporter := &ImporterMock{
+//			HandleFunc: func(ctx context.Context, newInstance event.NewInstance) error {
+//				panic("mock out the Handle method")
+//			},
+//		}
+//
+//		// use mockedImporter in code that requires api.Importer
+//		// and then make assertions.
+//
+//	}
+type ImporterMock struct {
+	// HandleFunc mocks the Handle method.
+	HandleFunc func(ctx context.Context, newInstance event.NewInstance) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Handle holds details about calls to the Handle method.
+		Handle []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// NewInstance is the newInstance argument value.
+			NewInstance event.NewInstance
+		}
+	}
+	lockHandle sync.RWMutex
+}
+
+// Handle calls HandleFunc.
+func (mock *ImporterMock) Handle(ctx context.Context, newInstance event.NewInstance) error {
+	if mock.HandleFunc == nil {
+		panic("ImporterMock.HandleFunc: method is nil but Importer.Handle was just called")
+	}
+	callInfo := struct {
+		Ctx         context.Context
+		NewInstance event.NewInstance
+	}{
+		Ctx:         ctx,
+		NewInstance: newInstance,
+	}
+	mock.lockHandle.Lock()
+	mock.calls.Handle = append(mock.calls.Handle, callInfo)
+	mock.lockHandle.Unlock()
+	return mock.HandleFunc(ctx, newInstance)
+}
+
+// HandleCalls gets all the calls that were made to Handle.
+// Check the length with:
+//
+//	len(mockedImporter.HandleCalls())
+func (mock *ImporterMock) HandleCalls() []struct {
+	Ctx         context.Context
+	NewInstance event.NewInstance
+} {
+	var calls []struct {
+		Ctx         context.Context
+		NewInstance event.NewInstance
+	}
+	mock.lockHandle.RLock()
+	calls = mock.calls.Handle
+	mock.lockHandle.RUnlock()
+	return calls
+}
@@ -0,0 +1,154 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"context"
+	"github.com/ONSdigital/dp-dimension-importer/api"
+	"sync"
+)
+
+// Ensure, that ReordererMock does implement api.Reorderer.
+// If this is not the case, regenerate this file with moq.
+var _ api.Reorderer = &ReordererMock{}
+
+// ReordererMock is a mock implementation of api.Reorderer.
+//
+//	func TestSomethingThatUsesReorderer(t *testing.T) {
+//
+//		// make and configure a mocked api.Reorderer
+//		mockedReorderer := &ReordererMock{
+//			ReorderFunc: func(ctx context.Context, instanceID string) error {
+//				panic("mock out the Reorder method")
+//			},
+//		}
+//
+//		// use mockedReorderer in code that requires api.Reorderer
+//		// and then make assertions.
+//
+//	}
+type ReordererMock struct {
+	// ReorderFunc mocks the Reorder method.
+	ReorderFunc func(ctx context.Context, instanceID string) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Reorder holds details about calls to the Reorder method.
+		Reorder []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+		}
+	}
+	lockReorder sync.RWMutex
+}
+
+// Reorder calls ReorderFunc.
+func (mock *ReordererMock) Reorder(ctx context.Context, instanceID string) error {
+	if mock.ReorderFunc == nil {
+		panic("ReordererMock.ReorderFunc: method is nil but Reorderer.Reorder was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		InstanceID string
+	}{
+		Ctx:        ctx,
+		InstanceID: instanceID,
+	}
+	mock.lockReorder.Lock()
+	mock.calls.Reorder = append(mock.calls.Reorder, callInfo)
+	mock.lockReorder.Unlock()
+	return mock.ReorderFunc(ctx, instanceID)
+}
+
+// ReorderCalls gets all the calls that were made to Reorder.
+// Check the length with:
+//
+//	len(mockedReorderer.ReorderCalls())
+func (mock *ReordererMock) ReorderCalls() []struct {
+	Ctx        context.Context
+	InstanceID string
+} {
+	var calls []struct {
+		Ctx        context.Context
+		InstanceID string
+	}
+	mock.lockReorder.RLock()
+	calls = mock.calls.Reorder
+	mock.lockReorder.RUnlock()
+	return calls
+}
+
+// Ensure, that CodeListReaderMock does implement api.CodeListReader.
+// If this is not the case, regenerate this file with moq.
+var _ api.CodeListReader = &CodeListReaderMock{}
+
+// CodeListReaderMock is a mock implementation of api.CodeListReader.
+//
+//	func TestSomethingThatUsesCodeListReader(t *testing.T) {
+//
+//		// make and configure a mocked api.CodeListReader
+//		mockedCodeListReader := &CodeListReaderMock{
+//			GetCodeListInstancesFunc: func(ctx context.Context, codeListID string) ([]string, error) {
+//				panic("mock out the GetCodeListInstances method")
+//			},
+//		}
+//
+//		// use mockedCodeListReader in code that requires api.CodeListReader
+//		// and then make assertions.
+//
+//	}
+type CodeListReaderMock struct {
+	// GetCodeListInstancesFunc mocks the GetCodeListInstances method.
+	GetCodeListInstancesFunc func(ctx context.Context, codeListID string) ([]string, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// GetCodeListInstances holds details about calls to the GetCodeListInstances method.
+		GetCodeListInstances []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// CodeListID is the codeListID argument value.
+			CodeListID string
+		}
+	}
+	lockGetCodeListInstances sync.RWMutex
+}
+
+// GetCodeListInstances calls GetCodeListInstancesFunc.
+func (mock *CodeListReaderMock) GetCodeListInstances(ctx context.Context, codeListID string) ([]string, error) {
+	if mock.GetCodeListInstancesFunc == nil {
+		panic("CodeListReaderMock.GetCodeListInstancesFunc: method is nil but CodeListReader.GetCodeListInstances was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		CodeListID string
+	}{
+		Ctx:        ctx,
+		CodeListID: codeListID,
+	}
+	mock.lockGetCodeListInstances.Lock()
+	mock.calls.GetCodeListInstances = append(mock.calls.GetCodeListInstances, callInfo)
+	mock.lockGetCodeListInstances.Unlock()
+	return mock.GetCodeListInstancesFunc(ctx, codeListID)
+}
+
+// GetCodeListInstancesCalls gets all the calls that were made to GetCodeListInstances.
+// Check the length with:
+//
+//	len(mockedCodeListReader.GetCodeListInstancesCalls())
+func (mock *CodeListReaderMock) GetCodeListInstancesCalls() []struct {
+	Ctx        context.Context
+	CodeListID string
+} {
+	var calls []struct {
+		Ctx        context.Context
+		CodeListID string
+	}
+	mock.lockGetCodeListInstances.RLock()
+	calls = mock.calls.GetCodeListInstances
+	mock.lockGetCodeListInstances.RUnlock()
+	return calls
+}
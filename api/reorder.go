@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+//go:generate moq -out mock/reorder.go -pkg mock . Reorderer CodeListReader
+
+// Reorderer recomputes the order of the dimension options of an imported instance and patches it in dataset API
+type Reorderer interface {
+	Reorder(ctx context.Context, instanceID string) error
+}
+
+// CodeListReader finds the imported instances that use a code list
+type CodeListReader interface {
+	GetCodeListInstances(ctx context.Context, codeListID string) ([]string, error)
+}
+
+// ReorderJob is the response body of a reorder request, listing the instances that will be reordered
+type ReorderJob struct {
+	InstanceIDs []string `json:"instance_ids"`
+}
+
+// reorderInstance starts a job that reorders the requested instance, and responds with 202 Accepted
+func (api *API) reorderInstance(w http.ResponseWriter, req *http.Request) {
+	instanceID := mux.Vars(req)["instance_id"]
+	logData := log.Data{"instance_id": instanceID, "package": packageName}
+	if !validIDs(req.Context(), w, logData, instanceID) {
+		return
+	}
+	api.startReorder(w, req, []string{instanceID}, logData)
+}
+
+// reorderCodeList starts a job that reorders every instance that uses the requested code list, and responds with 202 Accepted
+func (api *API) reorderCodeList(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	codeListID := mux.Vars(req)["code_list_id"]
+	logData := log.Data{"code_list_id": codeListID, "package": packageName}
+	if !validIDs(ctx, w, logData, codeListID) {
+		return
+	}
+
+	instanceIDs, err := api.CodeLists.GetCodeListInstances(ctx, codeListID)
+	if err != nil {
+		log.Error(ctx, "failed to get the instances of the code list", err, logData)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	api.startReorder(w, req, instanceIDs, logData)
+}
+
+// startReorder reorders the provided instances one at a time in a new go-routine, which outlives the request,
+// and writes the list of instances to the response with a 202 Accepted status
+func (api *API) startReorder(w http.ResponseWriter, req *http.Request, instanceIDs []string, logData log.Data) {
+	ctx := context.WithoutCancel(req.Context())
+	logData["instance_ids"] = instanceIDs
+	log.Info(ctx, "reorder job started", logData)
+
+	api.jobs.Add(1)
+	go func() {
+		defer api.jobs.Done()
+		failed := 0
+		for _, instanceID := range instanceIDs {
+			if err := api.Reorderer.Reorder(ctx, instanceID); err != nil {
+				log.Error(ctx, "failed to reorder instance", err, log.Data{"instance_id": instanceID, "package": packageName})
+				failed++
+			}
+		}
+		logData["failed"] = failed
+		log.Info(ctx, "reorder job finished", logData)
+	}()
+
+	writeJSONStatus(ctx, w, http.StatusAccepted, ReorderJob{InstanceIDs: instanceIDs}, logData)
+}
@@ -0,0 +1,183 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/ONSdigital/dp-dimension-importer/api"
+	"github.com/ONSdigital/dp-dimension-importer/api/mock"
+	"github.com/ONSdigital/dp-dimension-importer/report"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// reordererMock returns a reorderer mock that reorders any instance successfully
+func reordererMock() *mock.ReordererMock {
+	return &mock.ReordererMock{
+		ReorderFunc: func(ctx context.Context, instanceID string) error {
+			return nil
+		},
+	}
+}
+
+func TestReorder(t *testing.T) {
+	Convey("Given an API with a reorderer and a code list reader", t, func() {
+		reorderer := reordererMock()
+		codeLists := &mock.CodeListReaderMock{
+			GetCodeListInstancesFunc: func(ctx context.Context, codeListID string) ([]string, error) {
+				return []string{"instance1", "instance2"}, nil
+			},
+		}
+		router := mux.NewRouter()
+		a := api.Setup(ctx, router, report.NewStore(10), reorderer, codeLists, nil, serviceAuthToken)
+
+		Convey("When an instance reorder is requested", func() {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, adminRequest(http.MethodPost, "/instances/instance1/reorder", http.NoBody))
+			So(a.Close(ctx), ShouldBeNil)
+
+			Convey("Then status 202 is returned with the instance to reorder", func() {
+				So(w.Code, ShouldEqual, http.StatusAccepted)
+				var job api.ReorderJob
+				So(json.Unmarshal(w.Body.Bytes(), &job), ShouldBeNil)
+				So(job.InstanceIDs, ShouldResemble, []string{"instance1"})
+			})
+
+			Convey("Then the instance is reordered", func() {
+				So(reorderer.ReorderCalls(), ShouldHaveLength, 1)
+				So(reorderer.ReorderCalls()[0].InstanceID, ShouldEqual, "instance1")
+			})
+		})
+
+		Convey("When a code list reorder is requested and the first instance fails to be reordered", func() {
+			reorderer.ReorderFunc = func(ctx context.Context, instanceID string) error {
+				if instanceID == "instance1" {
+					return context.DeadlineExceeded
+				}
+				return nil
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, adminRequest(http.MethodPost, "/code-lists/geography/reorder", http.NoBody))
+			So(a.Close(ctx), ShouldBeNil)
+
+			Convey("Then status 202 is returned with the instances that use the code list", func() {
+				So(w.Code, ShouldEqual, http.StatusAccepted)
+				So(codeLists.GetCodeListInstancesCalls()[0].CodeListID, ShouldEqual, "geography")
+				var job api.ReorderJob
+				So(json.Unmarshal(w.Body.Bytes(), &job), ShouldBeNil)
+				So(job.InstanceIDs, ShouldResemble, []string{"instance1", "instance2"})
+			})
+
+			Convey("Then every instance is reordered, one at a time", func() {
+				So(reorderer.ReorderCalls(), ShouldHaveLength, 2)
+				So(reorderer.ReorderCalls()[0].InstanceID, ShouldEqual, "instance1")
+				So(reorderer.ReorderCalls()[1].InstanceID, ShouldEqual, "instance2")
+			})
+		})
+
+		Convey("When an instance reorder is requested without authorization header", func() {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/instances/instance1/reorder", http.NoBody))
+
+			Convey("Then status 401 is returned and nothing is reordered", func() {
+				So(w.Code, ShouldEqual, http.StatusUnauthorized)
+				So(reorderer.ReorderCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a code list reorder is requested with another service auth token", func() {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/code-lists/geography/reorder", http.NoBody)
+			req.Header.Set("Authorization", "Bearer another-token")
+			router.ServeHTTP(w, req)
+
+			Convey("Then status 403 is returned and the graph database is not queried", func() {
+				So(w.Code, ShouldEqual, http.StatusForbidden)
+				So(codeLists.GetCodeListInstancesCalls(), ShouldBeEmpty)
+				So(reorderer.ReorderCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a code list reorder is requested with an id containing quotes", func() {
+			w := httptest.NewRecorder()
+			path := "/code-lists/" + url.PathEscape("x').drop().iterate();g.V().has('listID','") + "/reorder"
+			router.ServeHTTP(w, adminRequest(http.MethodPost, path, http.NoBody))
+
+			Convey("Then status 400 is returned and the graph database is not queried", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(codeLists.GetCodeListInstancesCalls(), ShouldBeEmpty)
+				So(reorderer.ReorderCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When an instance reorder is requested with an id containing quotes", func() {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, adminRequest(http.MethodPost, "/instances/"+url.PathEscape("1')")+"/reorder", http.NoBody))
+
+			Convey("Then status 400 is returned and nothing is reordered", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(reorderer.ReorderCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a code list reorder is requested and the instances of the code list cannot be obtained", func() {
+			codeLists.GetCodeListInstancesFunc = func(ctx context.Context, codeListID string) ([]string, error) {
+				return nil, context.DeadlineExceeded
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, adminRequest(http.MethodPost, "/code-lists/geography/reorder", http.NoBody))
+
+			Convey("Then status 500 is returned and nothing is reordered", func() {
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+				So(reorderer.ReorderCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given an API with a reorderer whose reorder is in progress", t, func() {
+		release := make(chan struct{})
+		var started sync.WaitGroup
+		started.Add(1)
+		reorderer := &mock.ReordererMock{
+			ReorderFunc: func(ctx context.Context, instanceID string) error {
+				started.Done()
+				<-release
+				return nil
+			},
+		}
+		router := mux.NewRouter()
+		a := api.Setup(ctx, router, report.NewStore(10), reorderer, nil, nil, serviceAuthToken)
+		router.ServeHTTP(httptest.NewRecorder(), adminRequest(http.MethodPost, "/instances/instance1/reorder", http.NoBody))
+		started.Wait()
+
+		Convey("When the API is closed with a context that is done", func() {
+			cancelledCtx, cancel := context.WithCancel(ctx)
+			cancel()
+			err := a.Close(cancelledCtx)
+			close(release)
+
+			Convey("Then the context error is returned", func() {
+				So(err, ShouldEqual, context.Canceled)
+			})
+		})
+	})
+
+	Convey("Given an API without a code list reader", t, func() {
+		router := mux.NewRouter()
+		api.Setup(ctx, router, report.NewStore(10), reordererMock(), nil, nil, serviceAuthToken)
+
+		Convey("When a code list reorder is requested", func() {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, adminRequest(http.MethodPost, "/code-lists/geography/reorder", http.NoBody))
+
+			Convey("Then status 404 is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+			})
+		})
+	})
+}
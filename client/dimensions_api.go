@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 
@@ -39,17 +40,40 @@ var ErrDimensionIDEmpty = errors.New("dimension.id is required but is empty")
 type IClient interface {
 	PatchInstanceDimensions(ctx context.Context, serviceAuthToken, instanceID string, upserts []*dataset.OptionPost, updates []*dataset.OptionUpdate, ifMatch string) (eTag string, err error)
 	GetInstanceDimensionsInBatches(ctx context.Context, serviceAuthToken, instanceID string, batchSize, maxWorkers int) (dimensions dataset.Dimensions, eTag string, err error)
-	GetInstance(ctx context.Context, userAuthToken, serviceAuthToken, collectionID, instanceID, ifMatch string) (m dataset.Instance, eTag string, err error)
+	GetInstanceDimensionsBytes(ctx context.Context, serviceAuthToken, instanceID string, q *dataset.QueryParams, ifMatch string) (b []byte, eTag string, err error)
+	GetInstanceBytes(ctx context.Context, userAuthToken, serviceAuthToken, collectionID, instanceID, ifMatch string) (b []byte, eTag string, err error)
 	Checker(ctx context.Context, state *healthcheck.CheckState) error
 }
 
+// instanceResponse is the dataset API representation of an instance, including the instance type which is not part of dataset.Instance
+type instanceResponse struct {
+	dataset.Instance
+	Type string `json:"type"`
+}
+
+// dimensionsResponse is the dataset API representation of a page of instance dimension options,
+// including the order of each option which is not part of dataset.Dimension
+type dimensionsResponse struct {
+	Items []struct {
+		dataset.Dimension
+		Order *int `json:"order"`
+	} `json:"items"`
+	TotalCount int `json:"total_count"`
+}
+
 // DatasetAPI provides methods for getting dimensions for a given instanceID and updating the node_id of a specific dimension.
+// Each call is subject to the configured Timeouts, idempotent calls are retried according to Retry,
+// no calls are made while the Breaker is open, and dimension option patches are split according to Patch.
 type DatasetAPI struct {
 	AuthToken      string
 	DatasetAPIHost string
 	Client         IClient
 	MaxWorkers     int
 	BatchSize      int
+	Timeouts       Timeouts
+	Retry          RetryPolicy
+	Breaker        *CircuitBreaker
+	Patch          PatchLimits
 }
 
 // NewDatasetAPIClient validates the parameters and creates a new dataset API client from dp-api-clients-go library.
@@ -63,43 +87,145 @@ func NewDatasetAPIClient(cfg *config.Config) (*DatasetAPI, error) {
 		Client:         dataset.NewAPIClient(cfg.DatasetAPIAddr),
 		MaxWorkers:     cfg.DatasetAPIMaxWorkers,
 		BatchSize:      cfg.DatasetAPIBatchSize,
+		Timeouts: Timeouts{
+			GetInstance:     cfg.DatasetAPIGetInstanceTimeout,
+			GetDimensions:   cfg.DatasetAPIGetDimensionsTimeout,
+			PatchDimensions: cfg.DatasetAPIPatchDimensionsTimeout,
+		},
+		Retry: RetryPolicy{
+			MaxRetries:     cfg.DatasetAPIMaxRetries,
+			InitialBackoff: cfg.DatasetAPIRetryBackoff,
+			MaxBackoff:     cfg.DatasetAPIRetryMaxBackoff,
+		},
+		Breaker: NewCircuitBreaker(cfg.DatasetAPIBreakerThreshold, cfg.DatasetAPIBreakerCooldown),
+		Patch: PatchLimits{
+			MaxOptions:  cfg.DatasetAPIPatchMaxOptions,
+			MaxBytes:    cfg.DatasetAPIPatchMaxBytes,
+			Parallelism: cfg.DatasetAPIPatchParallelism,
+		},
 	}, nil
 }
 
-// GetInstance retrieve the specified instance from the Dataset API.
-func (api DatasetAPI) GetInstance(ctx context.Context, instanceID string) (*model.Instance, error) {
+// GetInstance retrieve the specified instance from the Dataset API, along with its current eTag.
+func (api DatasetAPI) GetInstance(ctx context.Context, instanceID string) (*model.Instance, string, error) {
 	if instanceID == "" {
-		return &model.Instance{}, fmt.Errorf("error getting instance: %w", ErrInstanceIDEmpty)
+		return &model.Instance{}, "", fmt.Errorf("error getting instance: %w", ErrInstanceIDEmpty)
 	}
-	datasetInstance, _, err := api.Client.GetInstance(ctx, "", api.AuthToken, "", instanceID, headers.IfMatchAnyETag)
+	var b []byte
+	var eTag string
+	err := api.call(ctx, "GetInstance", api.Timeouts.GetInstance, true, func(ctx context.Context) (err error) {
+		b, eTag, err = api.Client.GetInstanceBytes(ctx, "", api.AuthToken, "", instanceID, headers.IfMatchAnyETag)
+		return err
+	})
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	return model.NewInstance(&datasetInstance), err
+
+	var resp instanceResponse
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return nil, "", fmt.Errorf("error unmarshalling instance: %w", err)
+	}
+
+	instance := model.NewInstance(&resp.Instance)
+	instance.SetType(resp.Type)
+	return instance, eTag, nil
 }
 
-// GetDimensions retrieve the dimensions of the specified instance from the Dataset API
-func (api DatasetAPI) GetDimensions(ctx context.Context, instanceID, ifMatch string) ([]*model.Dimension, error) {
+// GetDimensions retrieve the dimensions of the specified instance from the Dataset API, along with the eTag of the instance.
+func (api DatasetAPI) GetDimensions(ctx context.Context, instanceID, ifMatch string) ([]*model.Dimension, string, error) {
 	if instanceID == "" {
-		return nil, fmt.Errorf("error getting dimensions: %w", ErrInstanceIDEmpty)
+		return nil, "", fmt.Errorf("error getting dimensions: %w", ErrInstanceIDEmpty)
 	}
 
-	dimensions, _, err := api.Client.GetInstanceDimensionsInBatches(ctx, api.AuthToken, instanceID, api.BatchSize, api.MaxWorkers)
+	var dimensions dataset.Dimensions
+	var eTag string
+	err := api.call(ctx, "GetDimensions", api.Timeouts.GetDimensions, true, func(ctx context.Context) (err error) {
+		dimensions, eTag, err = api.Client.GetInstanceDimensionsInBatches(ctx, api.AuthToken, instanceID, api.BatchSize, api.MaxWorkers)
+		return err
+	})
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	ret := []*model.Dimension{}
 	for i := range dimensions.Items {
 		ret = append(ret, model.NewDimension(&dimensions.Items[i]))
 	}
-	return ret, nil
+	return ret, eTag, nil
+}
+
+// StreamDimensions retrieves the dimensions of the specified instance from the Dataset API one page of BatchSize options at a time,
+// calling process with each page as soon as it is received. Unlike GetDimensions, the Order of each dimension is populated. The next page is only requested once process returns,
+// so that no more than one page of dimension options is held in memory. If process returns an error, no more pages are requested
+// and the error is returned. Each page request is subject to the GetDimensions timeout.
+func (api DatasetAPI) StreamDimensions(ctx context.Context, instanceID string, process func(dimensions []*model.Dimension) error) error {
+	if instanceID == "" {
+		return fmt.Errorf("error getting dimensions: %w", ErrInstanceIDEmpty)
+	}
+
+	for offset := 0; ; {
+		var b []byte
+		err := api.call(ctx, "GetDimensionsPage", api.Timeouts.GetDimensions, true, func(ctx context.Context) (err error) {
+			q := &dataset.QueryParams{Offset: offset, Limit: api.BatchSize}
+			b, _, err = api.Client.GetInstanceDimensionsBytes(ctx, api.AuthToken, instanceID, q, headers.IfMatchAnyETag)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		var page dimensionsResponse
+		if err := json.Unmarshal(b, &page); err != nil {
+			return fmt.Errorf("error unmarshalling dimensions: %w", err)
+		}
+		if len(page.Items) == 0 {
+			return nil
+		}
+
+		dimensions := make([]*model.Dimension, len(page.Items))
+		for i := range page.Items {
+			dimensions[i] = model.NewDimension(&page.Items[i].Dimension)
+			dimensions[i].Order = page.Items[i].Order
+		}
+		if err := process(dimensions); err != nil {
+			return err
+		}
+
+		offset += len(page.Items)
+		if offset >= page.TotalCount {
+			return nil
+		}
+	}
 }
 
-// PatchDimensionOption makes an HTTP patch request to update the node_id and/or order for multiple dimension options
-func (api DatasetAPI) PatchDimensionOption(ctx context.Context, instanceID string, updates []*dataset.OptionUpdate) (string, error) {
+// PatchDimensionOption makes HTTP patch requests to update the node_id and/or order for multiple dimension options,
+// only if the instance eTag matches the provided ifMatch value. The new eTag of the instance is returned.
+// The updates are split in as many requests as required by the configured PatchLimits. If only some of them are patched, a *PatchError is returned.
+// Setting the node_id and order values is idempotent, so each request is retried on failure.
+func (api DatasetAPI) PatchDimensionOption(ctx context.Context, instanceID, ifMatch string, updates []*dataset.OptionUpdate) (string, error) {
 	if instanceID == "" {
 		return "", fmt.Errorf("error patching dimensions: %w", ErrInstanceIDEmpty)
 	}
-	return api.Client.PatchInstanceDimensions(ctx, api.AuthToken, instanceID, nil, updates, headers.IfMatchAnyETag)
+	chunks := api.Patch.splitUpdates(updates)
+	if len(chunks) == 0 {
+		chunks = [][]*dataset.OptionUpdate{updates}
+	}
+	return api.patchChunks(ctx, instanceID, ifMatch, chunks)
+}
+
+// Checker checks the health of dataset API, taking into account the state of the circuit breaker:
+// the check is critical while the circuit is open, and it is at most a warning while it is half-open.
+func (api DatasetAPI) Checker(ctx context.Context, state *healthcheck.CheckState) error {
+	if err := api.Client.Checker(ctx, state); err != nil {
+		return err
+	}
+	switch api.Breaker.State() {
+	case CircuitOpen:
+		return state.Update(healthcheck.StatusCritical, ErrCircuitOpen.Error(), 0)
+	case CircuitHalfOpen:
+		if state.Status() == healthcheck.StatusOK {
+			return state.Update(healthcheck.StatusWarning, "dataset api circuit breaker is half-open", 0)
+		}
+	}
+	return nil
 }
@@ -3,6 +3,7 @@ package client_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
@@ -22,6 +23,8 @@ const (
 	instanceID = "1234567890"
 	authToken  = "pa55w0rd"
 	ifMatch    = "*"
+	testETag   = "testETag"
+	newETag    = "newETag"
 )
 
 var errMock = errors.New("broken")
@@ -39,10 +42,26 @@ var dimensionTwo = model.NewDimension(&datasetDimensionTwo)
 var expectedDimensions = []*model.Dimension{dimensionOne, dimensionTwo}
 
 // Instance returned by dataset API mock
-var datasetInstance = dataset.Instance{Version: dataset.Version{ID: instanceID, CSVHeader: []string{"the", "csv", "header"}}}
+var datasetInstance = dataset.Instance{Version: dataset.Version{
+	ID:        instanceID,
+	CSVHeader: []string{"the", "csv", "header"},
+	Edition:   "2021",
+	Version:   1,
+	Links:     dataset.Links{Dataset: dataset.Link{ID: "cpih01"}},
+}}
+
+// Instance returned by dataset API mock as bytes, including the instance type
+var datasetInstanceBytes, _ = json.Marshal(struct {
+	dataset.Instance
+	Type string `json:"type"`
+}{datasetInstance, "v4"})
 
 // Instance in dp-dimension-importer
-var expectedInstance = model.NewInstance(&datasetInstance)
+var expectedInstance = func() *model.Instance {
+	i := model.NewInstance(&datasetInstance)
+	i.SetType("v4")
+	return i
+}()
 
 var ctx = context.Background()
 
@@ -63,8 +82,8 @@ func TestNewClient(t *testing.T) {
 func TestGetInstance(t *testing.T) {
 	Convey("Given valid client configuration", t, func() {
 		clientMock := &mocks.IClientMock{
-			GetInstanceFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) (dataset.Instance, string, error) {
-				return datasetInstance, "", nil
+			GetInstanceBytesFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) ([]byte, string, error) {
+				return datasetInstanceBytes, testETag, nil
 			},
 		}
 
@@ -75,18 +94,19 @@ func TestGetInstance(t *testing.T) {
 		}
 
 		Convey("When the GetInstance method is called", func() {
-			instance, err := datasetAPI.GetInstance(ctx, instanceID)
+			instance, eTag, err := datasetAPI.GetInstance(ctx, instanceID)
 
-			Convey("Then the expected response is returned with no error", func() {
+			Convey("Then the expected response is returned with the instance eTag and no error", func() {
 				So(instance, ShouldResemble, expectedInstance)
+				So(eTag, ShouldEqual, testETag)
 				So(err, ShouldEqual, nil)
 			})
 
-			Convey("Then dataset.GetInstance is called exactly once with the right parameters", func() {
-				So(len(clientMock.GetInstanceCalls()), ShouldEqual, 1)
-				So(clientMock.GetInstanceCalls()[0].InstanceID, ShouldEqual, instanceID)
-				So(clientMock.GetInstanceCalls()[0].ServiceAuthToken, ShouldEqual, authToken)
-				So(clientMock.GetInstanceCalls()[0].UserAuthToken, ShouldEqual, "")
+			Convey("Then dataset.GetInstanceBytes is called exactly once with the right parameters", func() {
+				So(len(clientMock.GetInstanceBytesCalls()), ShouldEqual, 1)
+				So(clientMock.GetInstanceBytesCalls()[0].InstanceID, ShouldEqual, instanceID)
+				So(clientMock.GetInstanceBytesCalls()[0].ServiceAuthToken, ShouldEqual, authToken)
+				So(clientMock.GetInstanceBytesCalls()[0].UserAuthToken, ShouldEqual, "")
 			})
 		})
 	})
@@ -102,7 +122,7 @@ func TestGetInstance(t *testing.T) {
 		}
 
 		Convey("When GetInstance method is called", func() {
-			instance, err := datasetAPI.GetInstance(ctx, instanceID)
+			instance, _, err := datasetAPI.GetInstance(ctx, instanceID)
 
 			Convey("Then the expected error is returned", func() {
 				So(instance, ShouldResemble, &model.Instance{})
@@ -113,8 +133,8 @@ func TestGetInstance(t *testing.T) {
 
 	Convey("Given dataset.GetInstance will return an error", t, func() {
 		clientMock := &mocks.IClientMock{
-			GetInstanceFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) (dataset.Instance, string, error) {
-				return dataset.Instance{}, "", errMock
+			GetInstanceBytesFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) ([]byte, string, error) {
+				return nil, "", errMock
 			},
 		}
 
@@ -125,18 +145,41 @@ func TestGetInstance(t *testing.T) {
 		}
 
 		Convey("When GetInstance is invoked", func() {
-			instance, err := datasetAPI.GetInstance(ctx, instanceID)
+			instance, _, err := datasetAPI.GetInstance(ctx, instanceID)
 
 			Convey("Then the expected error response is returned", func() {
 				So(instance, ShouldBeNil)
 				So(err, ShouldResemble, errMock)
 			})
 
-			Convey("Then dataset.GetInstance is called exactly once with the right parameters", func() {
-				So(len(clientMock.GetInstanceCalls()), ShouldEqual, 1)
-				So(clientMock.GetInstanceCalls()[0].InstanceID, ShouldEqual, instanceID)
-				So(clientMock.GetInstanceCalls()[0].ServiceAuthToken, ShouldEqual, authToken)
-				So(clientMock.GetInstanceCalls()[0].UserAuthToken, ShouldEqual, "")
+			Convey("Then dataset.GetInstanceBytes is called exactly once with the right parameters", func() {
+				So(len(clientMock.GetInstanceBytesCalls()), ShouldEqual, 1)
+				So(clientMock.GetInstanceBytesCalls()[0].InstanceID, ShouldEqual, instanceID)
+				So(clientMock.GetInstanceBytesCalls()[0].ServiceAuthToken, ShouldEqual, authToken)
+				So(clientMock.GetInstanceBytesCalls()[0].UserAuthToken, ShouldEqual, "")
+			})
+		})
+	})
+
+	Convey("Given dataset.GetInstanceBytes will return an invalid instance", t, func() {
+		clientMock := &mocks.IClientMock{
+			GetInstanceBytesFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) ([]byte, string, error) {
+				return []byte("not json"), "", nil
+			},
+		}
+
+		datasetAPI := client.DatasetAPI{
+			AuthToken:      authToken,
+			DatasetAPIHost: host,
+			Client:         clientMock,
+		}
+
+		Convey("When GetInstance is invoked", func() {
+			instance, _, err := datasetAPI.GetInstance(ctx, instanceID)
+
+			Convey("Then the expected error response is returned", func() {
+				So(instance, ShouldBeNil)
+				So(err.Error(), ShouldStartWith, "error unmarshalling instance: ")
 			})
 		})
 	})
@@ -146,7 +189,7 @@ func TestGetDimensions(t *testing.T) {
 	Convey("Given a valid client configuration", t, func() {
 		clientMock := &mocks.IClientMock{
 			GetInstanceDimensionsInBatchesFunc: func(ctx context.Context, serviceAuthToken string, instanceID string, bacthSize, maxWorkers int) (dataset.Dimensions, string, error) {
-				return datasetDimensions, "", nil
+				return datasetDimensions, testETag, nil
 			},
 		}
 
@@ -157,10 +200,11 @@ func TestGetDimensions(t *testing.T) {
 		}
 
 		Convey("When the client is called with a valid instanceID", func() {
-			dims, err := datasetAPI.GetDimensions(ctx, instanceID, ifMatch)
+			dims, eTag, err := datasetAPI.GetDimensions(ctx, instanceID, ifMatch)
 
-			Convey("Then the expected response is returned with no error", func() {
+			Convey("Then the expected response is returned with the instance eTag and no error", func() {
 				So(dims, ShouldResemble, expectedDimensions)
+				So(eTag, ShouldEqual, testETag)
 				So(err, ShouldEqual, nil)
 			})
 
@@ -182,7 +226,7 @@ func TestGetDimensions(t *testing.T) {
 		}
 
 		Convey("When GetDimensions is invoked", func() {
-			dims, err := datasetAPI.GetDimensions(ctx, "", ifMatch)
+			dims, _, err := datasetAPI.GetDimensions(ctx, "", ifMatch)
 
 			Convey("Then the expected error is returned", func() {
 				So(dims, ShouldBeNil)
@@ -205,7 +249,7 @@ func TestGetDimensions(t *testing.T) {
 		}
 
 		Convey("When GetDimensions is invoked", func() {
-			dims, err := datasetAPI.GetDimensions(ctx, instanceID, ifMatch)
+			dims, _, err := datasetAPI.GetDimensions(ctx, instanceID, ifMatch)
 
 			Convey("Then the expected error response is returned", func() {
 				So(dims, ShouldBeNil)
@@ -244,7 +288,7 @@ func TestDatasetAPI_PatchDimensionOption(t *testing.T) {
 		}
 
 		Convey("When PatchDimensionOption is called with an update", func() {
-			_, err := datasetAPI.PatchDimensionOption(ctx, instanceID, updates)
+			_, err := datasetAPI.PatchDimensionOption(ctx, instanceID, ifMatch, updates)
 
 			Convey("Then the expected error is returned", func() {
 				So(err, ShouldResemble, errMock)
@@ -264,7 +308,7 @@ func TestDatasetAPI_PatchDimensionOption(t *testing.T) {
 	Convey("Given dataset.PatchInstanceDimensionOption succeeds", t, func() {
 		clientMock := &mocks.IClientMock{
 			PatchInstanceDimensionsFunc: func(ctx context.Context, serviceAuthToken string, instanceID string, upserts []*dataset.OptionPost, updates []*dataset.OptionUpdate, ifMatch string) (string, error) {
-				return "", nil
+				return newETag, nil
 			},
 		}
 
@@ -274,10 +318,11 @@ func TestDatasetAPI_PatchDimensionOption(t *testing.T) {
 			Client:         clientMock,
 		}
 
-		Convey("When PatchDimensionOption is called", func() {
-			_, err := datasetAPI.PatchDimensionOption(ctx, instanceID, updates)
+		Convey("When PatchDimensionOption is called with an eTag", func() {
+			eTag, err := datasetAPI.PatchDimensionOption(ctx, instanceID, testETag, updates)
 
-			Convey("Then no error is returned", func() {
+			Convey("Then the new instance eTag is returned with no error", func() {
+				So(eTag, ShouldEqual, newETag)
 				So(err, ShouldEqual, nil)
 			})
 
@@ -287,7 +332,7 @@ func TestDatasetAPI_PatchDimensionOption(t *testing.T) {
 				So(clientMock.PatchInstanceDimensionsCalls()[0].InstanceID, ShouldEqual, instanceID)
 				So(clientMock.PatchInstanceDimensionsCalls()[0].Upserts, ShouldBeNil)
 				So(clientMock.PatchInstanceDimensionsCalls()[0].Updates, ShouldResemble, updates)
-				So(clientMock.PatchInstanceDimensionsCalls()[0].IfMatch, ShouldEqual, headers.IfMatchAnyETag)
+				So(clientMock.PatchInstanceDimensionsCalls()[0].IfMatch, ShouldEqual, testETag)
 			})
 		})
 	})
@@ -302,3 +347,122 @@ func Response(body []byte, statusCode int, err error) (*http.Response, error) {
 		Body:       readCloser,
 	}, err
 }
+
+func TestStreamDimensions(t *testing.T) {
+	// dataset API returns one option per page
+	pagedClientMock := func() *mocks.IClientMock {
+		return &mocks.IClientMock{
+			GetInstanceDimensionsBytesFunc: func(ctx context.Context, serviceAuthToken string, instanceID string, q *dataset.QueryParams, ifMatch string) ([]byte, string, error) {
+				page := dataset.Dimensions{Offset: q.Offset, Limit: q.Limit, TotalCount: len(datasetDimensions.Items)}
+				if q.Offset < len(datasetDimensions.Items) {
+					page.Items = datasetDimensions.Items[q.Offset : q.Offset+1]
+					page.Count = 1
+				}
+				b, err := json.Marshal(page)
+				return b, "", err
+			},
+		}
+	}
+
+	Convey("Given a valid client configuration with a batch size of 1", t, func() {
+		clientMock := pagedClientMock()
+		datasetAPI := client.DatasetAPI{
+			AuthToken:      authToken,
+			DatasetAPIHost: host,
+			Client:         clientMock,
+			BatchSize:      1,
+		}
+
+		Convey("When StreamDimensions is called with a valid instanceID", func() {
+			pages := [][]*model.Dimension{}
+			err := datasetAPI.StreamDimensions(ctx, instanceID, func(dimensions []*model.Dimension) error {
+				pages = append(pages, dimensions)
+				return nil
+			})
+
+			Convey("Then each page of dimensions is processed in order and no error is returned", func() {
+				So(err, ShouldBeNil)
+				So(pages, ShouldResemble, [][]*model.Dimension{{dimensionOne}, {dimensionTwo}})
+			})
+
+			Convey("Then dataset.GetInstanceDimensionsBytes is called once for each page with the right parameters", func() {
+				calls := clientMock.GetInstanceDimensionsBytesCalls()
+				So(calls, ShouldHaveLength, 2)
+				for i, call := range calls {
+					So(call.InstanceID, ShouldEqual, instanceID)
+					So(call.ServiceAuthToken, ShouldEqual, authToken)
+					So(call.Q, ShouldResemble, &dataset.QueryParams{Offset: i, Limit: 1})
+				}
+			})
+		})
+
+		Convey("When StreamDimensions is called and processing the first page fails", func() {
+			err := datasetAPI.StreamDimensions(ctx, instanceID, func(dimensions []*model.Dimension) error {
+				return errMock
+			})
+
+			Convey("Then the processing error is returned and no more pages are requested", func() {
+				So(err, ShouldResemble, errMock)
+				So(clientMock.GetInstanceDimensionsBytesCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+
+	Convey("Given dataset API returns the order of each dimension option", t, func() {
+		clientMock := &mocks.IClientMock{
+			GetInstanceDimensionsBytesFunc: func(ctx context.Context, serviceAuthToken string, instanceID string, q *dataset.QueryParams, ifMatch string) ([]byte, string, error) {
+				return []byte(`{"items":[{"dimension":"666_SEX_MALE","option":"Male","node_id":"1111","order":3}],"total_count":1}`), "", nil
+			},
+		}
+		datasetAPI := client.DatasetAPI{AuthToken: authToken, DatasetAPIHost: host, Client: clientMock, BatchSize: 1}
+
+		Convey("When StreamDimensions is called", func() {
+			var dims []*model.Dimension
+			err := datasetAPI.StreamDimensions(ctx, instanceID, func(dimensions []*model.Dimension) error {
+				dims = dimensions
+				return nil
+			})
+
+			Convey("Then the order of each dimension is populated", func() {
+				So(err, ShouldBeNil)
+				So(dims, ShouldHaveLength, 1)
+				So(dims[0].DBModel(), ShouldResemble, dimensionOne.DBModel())
+				So(*dims[0].Order, ShouldEqual, 3)
+			})
+		})
+	})
+
+	Convey("Given dataset.GetInstanceDimensionsBytes will return an error", t, func() {
+		clientMock := &mocks.IClientMock{
+			GetInstanceDimensionsBytesFunc: func(ctx context.Context, serviceAuthToken string, instanceID string, q *dataset.QueryParams, ifMatch string) ([]byte, string, error) {
+				return nil, "", errMock
+			},
+		}
+		datasetAPI := client.DatasetAPI{AuthToken: authToken, DatasetAPIHost: host, Client: clientMock, BatchSize: 1}
+
+		Convey("When StreamDimensions is called", func() {
+			processed := false
+			err := datasetAPI.StreamDimensions(ctx, instanceID, func(dimensions []*model.Dimension) error {
+				processed = true
+				return nil
+			})
+
+			Convey("Then the expected error is returned and nothing is processed", func() {
+				So(err, ShouldResemble, errMock)
+				So(processed, ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("Given an empty instanceID is provided", t, func() {
+		datasetAPI := client.DatasetAPI{AuthToken: authToken, DatasetAPIHost: host, Client: &mocks.IClientMock{}}
+
+		Convey("When StreamDimensions is invoked", func() {
+			err := datasetAPI.StreamDimensions(ctx, "", func(dimensions []*model.Dimension) error { return nil })
+
+			Convey("Then the expected error is returned", func() {
+				So(err.Error(), ShouldEqual, "error getting dimensions: instance id is required but is empty")
+			})
+		})
+	})
+}
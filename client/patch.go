@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
+	"github.com/ONSdigital/dp-api-clients-go/v2/headers"
+	dprequest "github.com/ONSdigital/dp-net/v2/request"
+)
+
+// PatchLimits defines how the dimension option updates of a single PatchDimensionOption call are split into requests to dataset API.
+// A zero MaxOptions or MaxBytes means no limit. Chunks are sent sequentially unless Parallelism is greater than 1 and the eTag of the instance is not checked.
+type PatchLimits struct {
+	MaxOptions  int // maximum number of dimension options updated by a single request
+	MaxBytes    int // maximum size in bytes of the body of a single request
+	Parallelism int // maximum number of concurrent requests
+}
+
+// PatchError is returned when some of the dimension option updates provided to PatchDimensionOption could not be patched.
+// It lists the updates that were patched and the ones that were not, and wraps the error of the first failed request.
+type PatchError struct {
+	Patched    []*dataset.OptionUpdate
+	NotPatched []*dataset.OptionUpdate
+	Err        error
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("failed to patch %d of %d dimension options: [%s]: %v",
+		len(e.NotPatched), len(e.Patched)+len(e.NotPatched), strings.Join(OptionKeys(e.NotPatched), ", "), e.Err)
+}
+
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+// OptionKeys returns a 'dimension:option' key for each one of the provided updates
+func OptionKeys(updates []*dataset.OptionUpdate) []string {
+	keys := make([]string, len(updates))
+	for i, u := range updates {
+		keys[i] = u.Name + ":" + u.Option
+	}
+	return keys
+}
+
+// splitUpdates splits the provided updates in chunks that satisfy the limits. An update that is bigger than MaxBytes on its own is sent alone.
+func (l PatchLimits) splitUpdates(updates []*dataset.OptionUpdate) [][]*dataset.OptionUpdate {
+	chunks := [][]*dataset.OptionUpdate{}
+	chunk := []*dataset.OptionUpdate{}
+	chunkSize := 2 // json array brackets
+	for _, u := range updates {
+		size := patchSize(u)
+		exceedsOptions := l.MaxOptions > 0 && len(chunk) >= l.MaxOptions
+		exceedsBytes := l.MaxBytes > 0 && chunkSize+size > l.MaxBytes
+		if len(chunk) > 0 && (exceedsOptions || exceedsBytes) {
+			chunks = append(chunks, chunk)
+			chunk, chunkSize = []*dataset.OptionUpdate{}, 2
+		}
+		chunk = append(chunk, u)
+		chunkSize += size
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// patchSize returns the number of bytes that the provided update adds to the body of a patch request,
+// which contains one patch operation for its node_id and one for its order, separated by commas.
+func patchSize(u *dataset.OptionUpdate) int {
+	ops := []dprequest.Patch{}
+	if u.NodeID != "" {
+		ops = append(ops, dprequest.Patch{Op: dprequest.OpAdd.String(), Path: fmt.Sprintf("/%s/options/%s/node_id", u.Name, u.Option), Value: u.NodeID})
+	}
+	if u.Order != nil {
+		ops = append(ops, dprequest.Patch{Op: dprequest.OpAdd.String(), Path: fmt.Sprintf("/%s/options/%s/order", u.Name, u.Option), Value: u.Order})
+	}
+	size := 0
+	for _, op := range ops {
+		b, _ := json.Marshal(op)
+		size += len(b) + 1
+	}
+	return size
+}
+
+// patchChunks sends each chunk of updates in a separate request.
+// Requests are chained whenever the provided ifMatch value is a known eTag: each one is sent sequentially with the eTag returned by the previous one
+// as If-Match, and the last eTag is returned, so that the instance is never patched without checking that it has not been modified.
+// Requests are only sent concurrently, up to the patch Parallelism, if the caller has opted out of the eTag check with an empty or wildcard ifMatch value,
+// in which case they are all sent with the wildcard eTag and the provided ifMatch value is returned, because the order in which they were applied is not known.
+func (api DatasetAPI) patchChunks(ctx context.Context, instanceID, ifMatch string, chunks [][]*dataset.OptionUpdate) (string, error) {
+	patched := make([]bool, len(chunks))
+	patch := func(i int, ifMatch string) (eTag string, err error) {
+		err = api.call(ctx, "PatchDimensionOption", api.Timeouts.PatchDimensions, true, func(ctx context.Context) (err error) {
+			eTag, err = api.Client.PatchInstanceDimensions(ctx, api.AuthToken, instanceID, nil, chunks[i], ifMatch)
+			return err
+		})
+		return eTag, err
+	}
+
+	checkETag := ifMatch != "" && ifMatch != headers.IfMatchAnyETag
+	if api.Patch.Parallelism <= 1 || checkETag {
+		eTag := ifMatch
+		for i := range chunks {
+			newETag, err := patch(i, eTag)
+			if err != nil {
+				return "", newPatchError(chunks, patched, err)
+			}
+			patched[i] = true
+			eTag = newETag
+		}
+		return eTag, nil
+	}
+
+	var firstErr error
+	mutex := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	semaphore := make(chan struct{}, api.Patch.Parallelism)
+	for i := range chunks {
+		semaphore <- struct{}{}
+		mutex.Lock()
+		failed := firstErr != nil
+		mutex.Unlock()
+		if failed {
+			break // no more requests are sent after a failure
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			_, err := patch(i, headers.IfMatchAnyETag)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			patched[i] = true
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return "", newPatchError(chunks, patched, firstErr)
+	}
+	return ifMatch, nil
+}
+
+// newPatchError creates a PatchError for the provided chunks, according to which ones were patched.
+// If there is only one chunk, the provided error is returned, because no updates were patched.
+func newPatchError(chunks [][]*dataset.OptionUpdate, patched []bool, err error) error {
+	if len(chunks) == 1 {
+		return err
+	}
+	pErr := &PatchError{Patched: []*dataset.OptionUpdate{}, NotPatched: []*dataset.OptionUpdate{}, Err: err}
+	for i, chunk := range chunks {
+		if patched[i] {
+			pErr.Patched = append(pErr.Patched, chunk...)
+			continue
+		}
+		pErr.NotPatched = append(pErr.NotPatched, chunk...)
+	}
+	return pErr
+}
@@ -0,0 +1,178 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
+	"github.com/ONSdigital/dp-api-clients-go/v2/headers"
+	"github.com/ONSdigital/dp-dimension-importer/client"
+	"github.com/ONSdigital/dp-dimension-importer/mocks"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// testUpdates returns n option updates for the same dimension, with node IDs
+func testUpdates(n int) []*dataset.OptionUpdate {
+	updates := make([]*dataset.OptionUpdate, n)
+	for i := range updates {
+		updates[i] = &dataset.OptionUpdate{Name: "geography", Option: fmt.Sprintf("option%d", i), NodeID: fmt.Sprintf("node%d", i)}
+	}
+	return updates
+}
+
+// patchClientMock returns a dataset client mock that returns an incremental eTag for each patch request,
+// failing with the provided error for the requests that contain any of the options in failOptions
+func patchClientMock(err error, failOptions ...string) *mocks.IClientMock {
+	mutex := &sync.Mutex{}
+	count := 0
+	return &mocks.IClientMock{
+		PatchInstanceDimensionsFunc: func(ctx context.Context, serviceAuthToken string, instanceID string, upserts []*dataset.OptionPost, updates []*dataset.OptionUpdate, ifMatch string) (string, error) {
+			for _, u := range updates {
+				for _, o := range failOptions {
+					if u.Option == o {
+						return "", err
+					}
+				}
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			count++
+			return fmt.Sprintf("etag-%d", count), nil
+		},
+	}
+}
+
+func TestDatasetAPI_PatchDimensionOption_Split(t *testing.T) {
+	Convey("Given a dataset api client with a limit of 2 options per patch", t, func() {
+		clientMock := patchClientMock(errMock)
+		datasetAPI := client.DatasetAPI{AuthToken: authToken, Client: clientMock, Patch: client.PatchLimits{MaxOptions: 2}}
+
+		Convey("When 5 updates are patched", func() {
+			updates := testUpdates(5)
+			eTag, err := datasetAPI.PatchDimensionOption(ctx, instanceID, testETag, updates)
+
+			Convey("Then the updates are sent sequentially in 3 requests, each one with the eTag returned by the previous one", func() {
+				So(err, ShouldBeNil)
+				calls := clientMock.PatchInstanceDimensionsCalls()
+				So(calls, ShouldHaveLength, 3)
+				So(calls[0].Updates, ShouldResemble, updates[0:2])
+				So(calls[0].IfMatch, ShouldEqual, testETag)
+				So(calls[1].Updates, ShouldResemble, updates[2:4])
+				So(calls[1].IfMatch, ShouldEqual, "etag-1")
+				So(calls[2].Updates, ShouldResemble, updates[4:])
+				So(calls[2].IfMatch, ShouldEqual, "etag-2")
+			})
+
+			Convey("Then the eTag returned by the last request is returned", func() {
+				So(eTag, ShouldEqual, "etag-3")
+			})
+		})
+
+		Convey("When 2 updates are patched", func() {
+			_, err := datasetAPI.PatchDimensionOption(ctx, instanceID, testETag, testUpdates(2))
+
+			Convey("Then they are sent in a single request", func() {
+				So(err, ShouldBeNil)
+				So(clientMock.PatchInstanceDimensionsCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+
+	Convey("Given a dataset api client with a byte size limit that only fits one update per patch", t, func() {
+		clientMock := patchClientMock(errMock)
+		datasetAPI := client.DatasetAPI{AuthToken: authToken, Client: clientMock, Patch: client.PatchLimits{MaxBytes: 100}}
+
+		Convey("When 3 updates are patched", func() {
+			_, err := datasetAPI.PatchDimensionOption(ctx, instanceID, testETag, testUpdates(3))
+
+			Convey("Then each update is sent in a separate request", func() {
+				So(err, ShouldBeNil)
+				calls := clientMock.PatchInstanceDimensionsCalls()
+				So(calls, ShouldHaveLength, 3)
+				for _, call := range calls {
+					So(call.Updates, ShouldHaveLength, 1)
+				}
+			})
+		})
+	})
+
+	Convey("Given a dataset api client with a limit of 2 options per patch and a request that fails", t, func() {
+		clientMock := patchClientMock(errMock, "option2")
+		datasetAPI := client.DatasetAPI{AuthToken: authToken, Client: clientMock, Patch: client.PatchLimits{MaxOptions: 2}}
+
+		Convey("When 5 updates are patched", func() {
+			updates := testUpdates(5)
+			_, err := datasetAPI.PatchDimensionOption(ctx, instanceID, testETag, updates)
+
+			Convey("Then a PatchError listing the patched and not patched options is returned", func() {
+				var patchErr *client.PatchError
+				So(errors.As(err, &patchErr), ShouldBeTrue)
+				So(patchErr.Patched, ShouldResemble, updates[0:2])
+				So(patchErr.NotPatched, ShouldResemble, updates[2:])
+				So(errors.Is(err, errMock), ShouldBeTrue)
+				So(err.Error(), ShouldEqual, "failed to patch 3 of 5 dimension options: [geography:option2, geography:option3, geography:option4]: broken")
+			})
+
+			Convey("Then no more requests are sent after the failed one", func() {
+				So(clientMock.PatchInstanceDimensionsCalls(), ShouldHaveLength, 2)
+			})
+		})
+	})
+
+	Convey("Given a dataset api client with a limit of 1 option per patch and a parallelism of 2", t, func() {
+		clientMock := patchClientMock(errMock)
+		datasetAPI := client.DatasetAPI{AuthToken: authToken, Client: clientMock, Patch: client.PatchLimits{MaxOptions: 1, Parallelism: 2}}
+
+		Convey("When 4 updates are patched with a known eTag", func() {
+			eTag, err := datasetAPI.PatchDimensionOption(ctx, instanceID, testETag, testUpdates(4))
+
+			Convey("Then the requests are chained sequentially, so that every one of them checks the eTag", func() {
+				So(err, ShouldBeNil)
+				calls := clientMock.PatchInstanceDimensionsCalls()
+				So(calls, ShouldHaveLength, 4)
+				So(calls[0].IfMatch, ShouldEqual, testETag)
+				for i, call := range calls[1:] {
+					So(call.IfMatch, ShouldEqual, fmt.Sprintf("etag-%d", i+1))
+				}
+			})
+
+			Convey("Then the eTag of the last response is returned, to be used by the next patch instead of the wildcard eTag", func() {
+				So(eTag, ShouldEqual, "etag-4")
+				So(eTag, ShouldNotEqual, headers.IfMatchAnyETag)
+			})
+		})
+
+		Convey("When 4 updates are patched with the wildcard eTag", func() {
+			eTag, err := datasetAPI.PatchDimensionOption(ctx, instanceID, headers.IfMatchAnyETag, testUpdates(4))
+
+			Convey("Then the requests are sent concurrently with the wildcard eTag", func() {
+				So(err, ShouldBeNil)
+				calls := clientMock.PatchInstanceDimensionsCalls()
+				So(calls, ShouldHaveLength, 4)
+				for _, call := range calls {
+					So(call.IfMatch, ShouldEqual, headers.IfMatchAnyETag)
+				}
+			})
+
+			Convey("Then the wildcard eTag is returned", func() {
+				So(eTag, ShouldEqual, headers.IfMatchAnyETag)
+			})
+		})
+
+		Convey("When 4 updates are patched with the wildcard eTag and the request for one of them fails", func() {
+			clientMock.PatchInstanceDimensionsFunc = patchClientMock(errMock, "option3").PatchInstanceDimensionsFunc
+			updates := testUpdates(4)
+			_, err := datasetAPI.PatchDimensionOption(ctx, instanceID, headers.IfMatchAnyETag, updates)
+
+			Convey("Then a PatchError listing the failed option as not patched is returned", func() {
+				var patchErr *client.PatchError
+				So(errors.As(err, &patchErr), ShouldBeTrue)
+				So(patchErr.NotPatched, ShouldResemble, updates[3:])
+				So(patchErr.Patched, ShouldNotContain, updates[3])
+			})
+		})
+	})
+}
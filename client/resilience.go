@@ -0,0 +1,223 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// ErrCircuitOpen is returned when a call to dataset API is not attempted because the circuit breaker is open
+var ErrCircuitOpen = errors.New("dataset api circuit breaker is open")
+
+// Possible states of a CircuitBreaker
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// Timeouts defines the maximum time that each dataset API operation can take. A zero value means no timeout.
+type Timeouts struct {
+	GetInstance     time.Duration
+	GetDimensions   time.Duration
+	PatchDimensions time.Duration
+}
+
+// RetryPolicy defines how failed idempotent calls to dataset API are retried.
+// The backoff between attempts starts at InitialBackoff and doubles after each attempt, up to MaxBackoff.
+type RetryPolicy struct {
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// CircuitBreaker stops calls to dataset API after a threshold of consecutive failures, for the duration of a cooldown period.
+// Once the cooldown has elapsed, a single trial call is allowed (half-open state), while the other calls are still rejected:
+// its success closes the circuit, and its failure opens it again. A nil CircuitBreaker always allows calls.
+type CircuitBreaker struct {
+	mutex     sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	state     string
+	trial     bool // whether the trial call of the half-open circuit is in flight
+}
+
+// NewCircuitBreaker creates a new closed CircuitBreaker. A threshold lower than 1 disables the circuit breaker.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     CircuitClosed,
+	}
+}
+
+// Allow returns ErrCircuitOpen if calls are not currently allowed. Once the cooldown has elapsed, only the first caller is allowed,
+// until the result of its trial call is recorded with Success, Failure or Abandon.
+func (cb *CircuitBreaker) Allow() error {
+	if cb == nil {
+		return nil
+	}
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	if cb.state == CircuitOpen {
+		if time.Since(cb.openedAt) < cb.cooldown {
+			return ErrCircuitOpen
+		}
+		cb.state = CircuitHalfOpen
+	}
+	if cb.state == CircuitHalfOpen {
+		if cb.trial {
+			return ErrCircuitOpen
+		}
+		cb.trial = true
+	}
+	return nil
+}
+
+// Success records a successful call, closing the circuit
+func (cb *CircuitBreaker) Success() {
+	if cb == nil {
+		return
+	}
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.failures = 0
+	cb.state = CircuitClosed
+	cb.trial = false
+}
+
+// Abandon records a call whose result says nothing about dataset API, e.g. because it was cancelled by the caller,
+// so that another call can be the trial call of a half-open circuit
+func (cb *CircuitBreaker) Abandon() {
+	if cb == nil {
+		return
+	}
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.trial = false
+}
+
+// Failure records a failed call, opening the circuit if the threshold has been reached or if it was half-open
+func (cb *CircuitBreaker) Failure() {
+	if cb == nil || cb.threshold < 1 {
+		return
+	}
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.failures++
+	if cb.state == CircuitHalfOpen || cb.failures >= cb.threshold {
+		cb.state = CircuitOpen
+		cb.openedAt = time.Now()
+	}
+	cb.trial = false
+}
+
+// State returns the current state of the circuit breaker
+func (cb *CircuitBreaker) State() string {
+	if cb == nil {
+		return CircuitClosed
+	}
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	if cb.state == CircuitOpen && time.Since(cb.openedAt) >= cb.cooldown {
+		return CircuitHalfOpen
+	}
+	return cb.state
+}
+
+// IsServerFailure returns true if the provided error means that dataset API could not serve the request
+// (network errors, 429 and 5xx responses), as opposed to rejecting it. Errors caused by a cancelled or expired context
+// are not server failures, as they do not come from dataset API: the timeouts of the calls to dataset API are recorded as failures by the caller.
+func IsServerFailure(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr interface{ Code() int }
+	if errors.As(err, &apiErr) {
+		return apiErr.Code() == http.StatusTooManyRequests || apiErr.Code() >= http.StatusInternalServerError
+	}
+	return true
+}
+
+// IsConflict returns true if the provided error means that dataset API rejected a request
+// because the instance has been modified since its eTag was obtained (409 and 412 responses)
+func IsConflict(err error) bool {
+	var apiErr interface{ Code() int }
+	if errors.As(err, &apiErr) {
+		return apiErr.Code() == http.StatusConflict || apiErr.Code() == http.StatusPreconditionFailed
+	}
+	return false
+}
+
+// call performs the provided dataset API operation with the configured timeout, retrying it with backoff
+// if it is idempotent and it fails with a server failure or times out. Results are recorded in the circuit breaker,
+// except for calls cancelled by the caller, which say nothing about dataset API.
+func (api DatasetAPI) call(ctx context.Context, operation string, timeout time.Duration, idempotent bool, fn func(ctx context.Context) error) error {
+	attempts := 1
+	if idempotent {
+		attempts += api.Retry.MaxRetries
+	}
+	backoff := api.Retry.InitialBackoff
+
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		if err := api.Breaker.Allow(); err != nil {
+			if lastErr != nil {
+				return lastErr // the circuit has been opened by a previous attempt of this call
+			}
+			return err
+		}
+
+		err := callWithTimeout(ctx, timeout, fn)
+		if err == nil {
+			api.Breaker.Success()
+			return nil
+		}
+		if ctx.Err() != nil {
+			api.Breaker.Abandon()
+			return err
+		}
+		if !IsServerFailure(err) && !errors.Is(err, context.DeadlineExceeded) {
+			api.Breaker.Success() // dataset API is responsive, it rejected the request
+			return err
+		}
+		api.Breaker.Failure()
+		lastErr = err
+
+		if attempt >= attempts || ctx.Err() != nil {
+			return err
+		}
+		log.Warn(ctx, "dataset api call failed, retrying", log.FormatErrors([]error{err}), log.Data{
+			"operation": operation,
+			"attempt":   attempt,
+			"backoff":   backoff.String(),
+		})
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+		if api.Retry.MaxBackoff > 0 && backoff > api.Retry.MaxBackoff {
+			backoff = api.Retry.MaxBackoff
+		}
+	}
+}
+
+// callWithTimeout calls fn with a context that is cancelled after the provided timeout, if it is greater than zero
+func callWithTimeout(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return fn(ctx)
+}
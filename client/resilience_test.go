@@ -0,0 +1,301 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
+	"github.com/ONSdigital/dp-dimension-importer/client"
+	"github.com/ONSdigital/dp-dimension-importer/mocks"
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var testRetryPolicy = client.RetryPolicy{
+	MaxRetries:     2,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     2 * time.Millisecond,
+}
+
+// newAPIError returns the error returned by the dataset API client for a response with the provided status code
+func newAPIError(statusCode int) error {
+	return dataset.NewDatasetAPIResponse(&http.Response{StatusCode: statusCode, Body: io.NopCloser(strings.NewReader(""))}, "/instances")
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	Convey("Given a circuit breaker with a threshold of 2 consecutive failures", t, func() {
+		cb := client.NewCircuitBreaker(2, 20*time.Millisecond)
+
+		Convey("Then it is closed and allows calls", func() {
+			So(cb.State(), ShouldEqual, client.CircuitClosed)
+			So(cb.Allow(), ShouldBeNil)
+		})
+
+		Convey("When a failure is followed by a success", func() {
+			cb.Failure()
+			cb.Success()
+			cb.Failure()
+
+			Convey("Then it stays closed", func() {
+				So(cb.State(), ShouldEqual, client.CircuitClosed)
+				So(cb.Allow(), ShouldBeNil)
+			})
+		})
+
+		Convey("When 2 consecutive failures happen", func() {
+			cb.Failure()
+			cb.Failure()
+
+			Convey("Then it is open and rejects calls", func() {
+				So(cb.State(), ShouldEqual, client.CircuitOpen)
+				So(cb.Allow(), ShouldEqual, client.ErrCircuitOpen)
+			})
+
+			Convey("Then, once the cooldown has elapsed, it is half-open and allows a single trial call", func() {
+				time.Sleep(30 * time.Millisecond)
+				So(cb.State(), ShouldEqual, client.CircuitHalfOpen)
+				So(cb.Allow(), ShouldBeNil)
+				So(cb.Allow(), ShouldEqual, client.ErrCircuitOpen)
+
+				Convey("And an abandoned trial call lets another call through", func() {
+					cb.Abandon()
+					So(cb.Allow(), ShouldBeNil)
+					So(cb.Allow(), ShouldEqual, client.ErrCircuitOpen)
+				})
+
+				Convey("And a success closes it", func() {
+					cb.Success()
+					So(cb.State(), ShouldEqual, client.CircuitClosed)
+					So(cb.Allow(), ShouldBeNil)
+					So(cb.Allow(), ShouldBeNil)
+				})
+
+				Convey("And a failure opens it again", func() {
+					cb.Failure()
+					So(cb.State(), ShouldEqual, client.CircuitOpen)
+					So(cb.Allow(), ShouldEqual, client.ErrCircuitOpen)
+				})
+			})
+		})
+	})
+
+	Convey("Given a circuit breaker with a threshold of 0", t, func() {
+		cb := client.NewCircuitBreaker(0, time.Minute)
+
+		Convey("Then failures never open it", func() {
+			for i := 0; i < 10; i++ {
+				cb.Failure()
+			}
+			So(cb.State(), ShouldEqual, client.CircuitClosed)
+			So(cb.Allow(), ShouldBeNil)
+		})
+	})
+
+	Convey("Given a nil circuit breaker", t, func() {
+		var cb *client.CircuitBreaker
+
+		Convey("Then it is always closed", func() {
+			cb.Failure()
+			So(cb.State(), ShouldEqual, client.CircuitClosed)
+			So(cb.Allow(), ShouldBeNil)
+		})
+	})
+}
+
+func TestDatasetAPI_Retries(t *testing.T) {
+	Convey("Given a dataset API client that fails once with a server error before succeeding", t, func() {
+		failures := 1
+		clientMock := &mocks.IClientMock{
+			GetInstanceBytesFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) ([]byte, string, error) {
+				if failures > 0 {
+					failures--
+					return nil, "", newAPIError(http.StatusInternalServerError)
+				}
+				return datasetInstanceBytes, "", nil
+			},
+		}
+		datasetAPI := client.DatasetAPI{AuthToken: authToken, Client: clientMock, Retry: testRetryPolicy}
+
+		Convey("When GetInstance is called", func() {
+			instance, _, err := datasetAPI.GetInstance(ctx, instanceID)
+
+			Convey("Then the call is retried and the instance is returned", func() {
+				So(err, ShouldBeNil)
+				So(instance, ShouldResemble, expectedInstance)
+				So(clientMock.GetInstanceBytesCalls(), ShouldHaveLength, 2)
+			})
+		})
+	})
+
+	Convey("Given a dataset API client that always fails with a server error", t, func() {
+		clientMock := &mocks.IClientMock{
+			PatchInstanceDimensionsFunc: func(ctx context.Context, serviceAuthToken string, instanceID string, upserts []*dataset.OptionPost, updates []*dataset.OptionUpdate, ifMatch string) (string, error) {
+				return "", newAPIError(http.StatusServiceUnavailable)
+			},
+		}
+		datasetAPI := client.DatasetAPI{AuthToken: authToken, Client: clientMock, Retry: testRetryPolicy}
+
+		Convey("When PatchDimensionOption is called", func() {
+			_, err := datasetAPI.PatchDimensionOption(ctx, instanceID, ifMatch, []*dataset.OptionUpdate{})
+
+			Convey("Then the call is attempted MaxRetries+1 times and the last error is returned", func() {
+				So(clientMock.PatchInstanceDimensionsCalls(), ShouldHaveLength, 3)
+				var apiErr *dataset.ErrInvalidDatasetAPIResponse
+				So(errors.As(err, &apiErr), ShouldBeTrue)
+				So(apiErr.Code(), ShouldEqual, http.StatusServiceUnavailable)
+			})
+		})
+	})
+
+	Convey("Given a dataset API client that rejects the request", t, func() {
+		clientMock := &mocks.IClientMock{
+			GetInstanceDimensionsInBatchesFunc: func(ctx context.Context, serviceAuthToken string, instanceID string, batchSize, maxWorkers int) (dataset.Dimensions, string, error) {
+				return dataset.Dimensions{}, "", newAPIError(http.StatusNotFound)
+			},
+		}
+		breaker := client.NewCircuitBreaker(1, time.Minute)
+		datasetAPI := client.DatasetAPI{AuthToken: authToken, Client: clientMock, Retry: testRetryPolicy, Breaker: breaker}
+
+		Convey("When GetDimensions is called", func() {
+			_, _, err := datasetAPI.GetDimensions(ctx, instanceID, ifMatch)
+
+			Convey("Then the call is not retried and the circuit breaker stays closed", func() {
+				So(err, ShouldNotBeNil)
+				So(clientMock.GetInstanceDimensionsInBatchesCalls(), ShouldHaveLength, 1)
+				So(breaker.State(), ShouldEqual, client.CircuitClosed)
+			})
+		})
+	})
+
+	Convey("Given a dataset API client with a timeout for get instance calls", t, func() {
+		var deadline time.Time
+		var hasDeadline bool
+		clientMock := &mocks.IClientMock{
+			GetInstanceBytesFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) ([]byte, string, error) {
+				deadline, hasDeadline = ctx.Deadline()
+				return datasetInstanceBytes, "", nil
+			},
+		}
+		datasetAPI := client.DatasetAPI{AuthToken: authToken, Client: clientMock, Timeouts: client.Timeouts{GetInstance: time.Minute}}
+
+		Convey("When GetInstance is called", func() {
+			_, _, err := datasetAPI.GetInstance(ctx, instanceID)
+
+			Convey("Then the dataset API client is called with a context that has the expected deadline", func() {
+				So(err, ShouldBeNil)
+				So(hasDeadline, ShouldBeTrue)
+				So(deadline, ShouldHappenWithin, time.Minute, time.Now())
+			})
+		})
+	})
+}
+
+func TestDatasetAPI_CircuitBreaker(t *testing.T) {
+	Convey("Given a dataset API client that always fails and a circuit breaker with a threshold of 2", t, func() {
+		clientMock := &mocks.IClientMock{
+			GetInstanceBytesFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) ([]byte, string, error) {
+				return nil, "", errMock
+			},
+			CheckerFunc: func(ctx context.Context, state *healthcheck.CheckState) error {
+				return state.Update(healthcheck.StatusOK, "dataset api is ok", 200)
+			},
+		}
+		datasetAPI := client.DatasetAPI{
+			AuthToken: authToken,
+			Client:    clientMock,
+			Retry:     testRetryPolicy,
+			Breaker:   client.NewCircuitBreaker(2, time.Minute),
+		}
+
+		Convey("When GetInstance is called twice", func() {
+			_, _, err1 := datasetAPI.GetInstance(ctx, instanceID)
+			_, _, err2 := datasetAPI.GetInstance(ctx, instanceID)
+
+			Convey("Then the circuit opens after 2 attempts and no more calls are made", func() {
+				So(err1, ShouldEqual, errMock)
+				So(err2, ShouldEqual, client.ErrCircuitOpen)
+				So(clientMock.GetInstanceBytesCalls(), ShouldHaveLength, 2)
+			})
+
+			Convey("Then the dataset health check is critical", func() {
+				state := healthcheck.NewCheckState("Dataset")
+				err := datasetAPI.Checker(ctx, state)
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, healthcheck.StatusCritical)
+				So(state.Message(), ShouldEqual, client.ErrCircuitOpen.Error())
+			})
+		})
+
+		Convey("When no calls have failed", func() {
+			state := healthcheck.NewCheckState("Dataset")
+			err := datasetAPI.Checker(ctx, state)
+
+			Convey("Then the dataset health check reflects the dataset API client check", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, healthcheck.StatusOK)
+				So(clientMock.CheckerCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+}
+
+func TestDatasetAPI_CircuitBreaker_Context(t *testing.T) {
+	Convey("Given a dataset API client whose calls block until their context is done, and a circuit breaker with a threshold of 1", t, func() {
+		clientMock := &mocks.IClientMock{
+			GetInstanceBytesFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) ([]byte, string, error) {
+				<-ctx.Done()
+				return nil, "", ctx.Err()
+			},
+		}
+		breaker := client.NewCircuitBreaker(1, time.Minute)
+		datasetAPI := client.DatasetAPI{AuthToken: authToken, Client: clientMock, Breaker: breaker}
+
+		Convey("When the call is cancelled by the caller", func() {
+			cancelled, cancel := context.WithCancel(ctx)
+			cancel()
+			_, _, err := datasetAPI.GetInstance(cancelled, instanceID)
+
+			Convey("Then the error is returned, and the circuit breaker stays closed", func() {
+				So(errors.Is(err, context.Canceled), ShouldBeTrue)
+				So(breaker.State(), ShouldEqual, client.CircuitClosed)
+			})
+		})
+
+		Convey("When the call times out", func() {
+			datasetAPI.Timeouts = client.Timeouts{GetInstance: time.Millisecond}
+			_, _, err := datasetAPI.GetInstance(ctx, instanceID)
+
+			Convey("Then the timeout is recorded as a failure, and the circuit breaker opens", func() {
+				So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+				So(breaker.State(), ShouldEqual, client.CircuitOpen)
+			})
+		})
+	})
+}
+
+func TestIsServerFailure(t *testing.T) {
+	Convey("IsServerFailure returns true for network errors, 429 and 5xx responses, and false for context errors and other responses", t, func() {
+		So(client.IsServerFailure(errMock), ShouldBeTrue)
+		So(client.IsServerFailure(newAPIError(http.StatusTooManyRequests)), ShouldBeTrue)
+		So(client.IsServerFailure(newAPIError(http.StatusBadGateway)), ShouldBeTrue)
+		So(client.IsServerFailure(newAPIError(http.StatusNotFound)), ShouldBeFalse)
+		So(client.IsServerFailure(context.Canceled), ShouldBeFalse)
+		So(client.IsServerFailure(fmt.Errorf("get instance: %w", context.DeadlineExceeded)), ShouldBeFalse)
+	})
+}
+
+func TestIsConflict(t *testing.T) {
+	Convey("IsConflict returns true only for 409 and 412 dataset API responses", t, func() {
+		So(client.IsConflict(newAPIError(http.StatusConflict)), ShouldBeTrue)
+		So(client.IsConflict(newAPIError(http.StatusPreconditionFailed)), ShouldBeTrue)
+		So(client.IsConflict(fmt.Errorf("wrapped: %w", newAPIError(http.StatusPreconditionFailed))), ShouldBeTrue)
+		So(client.IsConflict(newAPIError(http.StatusInternalServerError)), ShouldBeFalse)
+		So(client.IsConflict(errMock), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ONSdigital/dp-dimension-importer/client"
+	"github.com/ONSdigital/dp-dimension-importer/config"
+	"github.com/ONSdigital/dp-dimension-importer/handler"
+	"github.com/ONSdigital/dp-dimension-importer/initialise"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// result is the outcome of backfilling the node IDs of one instance, as printed to stdout
+type result struct {
+	*handler.BackfillResult
+	InstanceID string `json:"instance_id"`
+	Error      string `json:"error,omitempty"`
+}
+
+// backfill-node-ids patches into dataset API the node IDs already stored in the graph database for each instance ID provided as an argument,
+// without inserting any dimensions, and prints the results as JSON to stdout. It exits with status 1 if the node IDs of any instance could not be backfilled.
+func main() {
+	log.Namespace = "dimension-importer-backfill-node-ids"
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s instance_id [instance_id ...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	os.Exit(run(context.Background(), flag.Args()))
+}
+
+// run backfills the node IDs of the provided instances and returns the exit status
+func run(ctx context.Context, instanceIDs []string) int {
+	cfg, err := config.Get(ctx)
+	if err != nil {
+		log.Fatal(ctx, "config load returned an error", err)
+		return 1
+	}
+
+	serviceList := initialise.ExternalServiceList{}
+	graphDB, err := serviceList.GetGraphDB(ctx)
+	if err != nil {
+		log.Fatal(ctx, "failed to get graphDB", err)
+		return 1
+	}
+	defer func() {
+		if err := graphDB.Close(ctx); err != nil {
+			log.Error(ctx, "error closing graph db", err)
+		}
+	}()
+
+	reader, err := serviceList.GetGraphReader(graphDB)
+	if err != nil {
+		log.Fatal(ctx, "graph database cannot be read back", err)
+		return 1
+	}
+
+	datasetAPICli, err := client.NewDatasetAPIClient(cfg)
+	if err != nil {
+		log.Fatal(ctx, "failed to create datasetAPI client", err)
+		return 1
+	}
+
+	// the graph database is only read, dimensions are never inserted by a backfill
+	h := &handler.InstanceEventHandler{
+		Store:              graphDB,
+		DatasetAPICli:      datasetAPICli,
+		BatchSize:          cfg.KafkaConfig.BatchSize,
+		MaxConflictRetries: cfg.DatasetAPIConflictRetries,
+	}
+
+	results := make([]*result, 0, len(instanceIDs))
+	status := 0
+	for _, instanceID := range instanceIDs {
+		backfillResult, err := h.BackfillNodeIDs(ctx, instanceID, reader)
+		r := &result{BackfillResult: backfillResult, InstanceID: instanceID}
+		if err != nil {
+			log.Error(ctx, "failed to backfill node ids", err, log.Data{"instance_id": instanceID})
+			r.Error = err.Error()
+			status = 1
+		}
+		results = append(results, r)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(results); err != nil {
+		log.Error(ctx, "failed to write the backfill results", err)
+		return 1
+	}
+	return status
+}
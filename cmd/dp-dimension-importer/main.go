@@ -7,11 +7,17 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/ONSdigital/dp-dimension-importer/api"
 	"github.com/ONSdigital/dp-dimension-importer/client"
 	"github.com/ONSdigital/dp-dimension-importer/config"
+	"github.com/ONSdigital/dp-dimension-importer/event"
 	"github.com/ONSdigital/dp-dimension-importer/handler"
+	"github.com/ONSdigital/dp-dimension-importer/idempotency"
 	"github.com/ONSdigital/dp-dimension-importer/initialise"
 	"github.com/ONSdigital/dp-dimension-importer/message"
+	"github.com/ONSdigital/dp-dimension-importer/order"
+	"github.com/ONSdigital/dp-dimension-importer/reconcile"
+	"github.com/ONSdigital/dp-dimension-importer/report"
 	"github.com/ONSdigital/dp-dimension-importer/schema"
 	"github.com/ONSdigital/dp-dimension-importer/store"
 	"github.com/ONSdigital/dp-graph/v2/graph"
@@ -76,6 +82,24 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Outgoing topic for the data-quality report of each processed instance
+	importReportProducer, err := serviceList.GetProducer(ctx, cfg.KafkaConfig.ImportReportTopic, initialise.ImportReport, cfg.KafkaConfig)
+	if err != nil {
+		log.Fatal(ctx, "failed to get kafka producer", err, log.Data{
+			"kafka_producer_topic": cfg.KafkaConfig.ImportReportTopic,
+		})
+		os.Exit(1)
+	}
+
+	// Outgoing topic for the failed imports
+	importFailedProducer, err := serviceList.GetProducer(ctx, cfg.KafkaConfig.ImportFailedTopic, initialise.ImportFailed, cfg.KafkaConfig)
+	if err != nil {
+		log.Fatal(ctx, "failed to get kafka producer", err, log.Data{
+			"kafka_producer_topic": cfg.KafkaConfig.ImportFailedTopic,
+		})
+		os.Exit(1)
+	}
+
 	// Connection to graph DB
 	graphDB, err := serviceList.GetGraphDB(ctx)
 	if err != nil {
@@ -88,12 +112,46 @@ func main() {
 		graphErrorConsumer = graph.NewLoggingErrorConsumer(ctx, graphDB.ErrorChan())
 	}
 
+	// Codecs of the kafka messages, which use the compiled-in schemas unless a schema registry is configured
+	var newInstanceUnmarshaller message.Unmarshaller = schema.NewInstanceSchema
+	var instanceCompletedMarshaller, importReportMarshaller, importFailedMarshaller message.Marshaller = schema.InstanceCompletedSchema, schema.ImportReportSchema, schema.ImportFailedSchema
+	registry := serviceList.GetSchemaRegistry(cfg)
+	if registry != nil {
+		newInstanceUnmarshaller = schema.NewRegistryCodec(schema.NewInstanceSchema, registry, schema.Subject(cfg.KafkaConfig.IncomingInstancesTopic))
+		instanceCompletedMarshaller = schema.NewRegistryCodec(schema.InstanceCompletedSchema, registry, schema.Subject(cfg.KafkaConfig.OutgoingInstancesTopic))
+		importReportMarshaller = schema.NewRegistryCodec(schema.ImportReportSchema, registry, schema.Subject(cfg.KafkaConfig.ImportReportTopic))
+		importFailedMarshaller = schema.NewRegistryCodec(schema.ImportFailedSchema, registry, schema.Subject(cfg.KafkaConfig.ImportFailedTopic))
+		log.Info(ctx, "kafka messages are encoded with the schemas of the schema registry")
+	}
+
+	// Results of the processed events, so that the events delivered more than once are skipped
+	processed, err := serviceList.GetIdempotencyStore(cfg)
+	if err != nil {
+		log.Fatal(ctx, "failed to get idempotency store", err)
+		os.Exit(1)
+	}
+
 	// MessageProducer for instanceComplete events.
 	instanceCompletedProducer := message.InstanceCompletedProducer{
 		Producer:   instanceCompleteProducer,
-		Marshaller: schema.InstanceCompletedSchema,
+		Marshaller: instanceCompletedMarshaller,
+	}
+
+	// MessageProducer for importReport events.
+	reportProducer := message.ImportReportProducer{
+		Producer:   importReportProducer,
+		Marshaller: importReportMarshaller,
 	}
 
+	// MessageProducer for importFailed events.
+	failureProducer := message.ImportFailedProducer{
+		Producer:   importFailedProducer,
+		Marshaller: importFailedMarshaller,
+	}
+
+	// In-memory store of the most recent import reports, exposed by the API.
+	reports := report.NewStore(cfg.ImportReportStoreSize)
+
 	// Dataset Client wrapper.
 	datasetAPICli, err := client.NewDatasetAPIClient(cfg)
 	if err != nil {
@@ -101,13 +159,75 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Constraints that every dimension option must satisfy
+	optionPolicy, err := handler.NewOptionPolicy(cfg.OptionMaxLength, cfg.OptionAllowedPattern)
+	if err != nil {
+		log.Fatal(ctx, "failed to create dimension option policy", err)
+		os.Exit(1)
+	}
+
+	// Pipeline profile for each instance type
+	instanceTypeProfiles := make(map[string]handler.Profile, len(cfg.InstanceTypeProfiles))
+	for instanceType, profile := range cfg.InstanceTypeProfiles {
+		instanceTypeProfiles[instanceType] = handler.Profile(profile)
+	}
+
+	// Local source of the codes order for the order-only profile, the graph database code lists are used if not provided
+	var orderSource order.Source
+	if cfg.LocalOrderFile != "" {
+		localOrderSource, err := order.LoadLocal(cfg.LocalOrderFile)
+		if err != nil {
+			log.Fatal(ctx, "failed to load local order file", err, log.Data{"local_order_file": cfg.LocalOrderFile})
+			os.Exit(1)
+		}
+		orderSource = localOrderSource
+	}
+
+	// Orderers for the dimension options without a code list order
+	orderFallbacks, err := order.NewFallbacks(cfg.OrderFallbacks, cfg.OrderFallbackDir)
+	if err != nil {
+		log.Fatal(ctx, "failed to create fallback orderers", err, log.Data{"order_fallbacks": cfg.OrderFallbacks})
+		os.Exit(1)
+	}
+
+	// Existing instances can only be imported again if their nodes can be deleted from the graph database
+	var deleter store.InstanceDeleter
+	if graphDeleter, err := serviceList.GetInstanceDeleter(graphDB); err != nil {
+		log.Warn(ctx, "graph database nodes cannot be deleted, forced imports of existing instances will fail", log.Data{"error": err.Error()})
+	} else {
+		deleter = graphDeleter
+	}
+
+	// Existing instances can only be imported incrementally, and the instances that use a code list can only be found, if the graph database can be read back
+	var graphReader store.GraphReader
+	if reader, err := serviceList.GetGraphReader(graphDB); err != nil {
+		log.Warn(ctx, "graph database cannot be read back, incremental imports of existing instances will fail and code lists cannot be reordered", log.Data{"error": err.Error()})
+	} else {
+		graphReader = reader
+	}
+
 	// Receiver for NewInstance events.
 	instanceEventHandler := &handler.InstanceEventHandler{
-		Store:             graphDB,
-		DatasetAPICli:     datasetAPICli,
-		Producer:          instanceCompletedProducer,
-		BatchSize:         cfg.KafkaConfig.BatchSize,
-		EnablePatchNodeID: cfg.EnablePatchNodeID,
+		Store:              graphDB,
+		Deleter:            deleter,
+		Reader:             graphReader,
+		DatasetAPICli:      datasetAPICli,
+		Producer:           instanceCompletedProducer,
+		ReportProducer:     reportProducer,
+		FailureProducer:    failureProducer,
+		Reports:            reports,
+		OptionPolicy:       optionPolicy,
+		OrderSource:        orderSource,
+		OrderFallbacks:     orderFallbacks,
+		BatchSize:          cfg.KafkaConfig.BatchSize,
+		EnablePatchNodeID:  cfg.EnablePatchNodeID,
+		MaxConflictRetries: cfg.DatasetAPIConflictRetries,
+		StreamDimensions:   cfg.StreamDimensions,
+		PatchVerification:  handler.VerificationMode(cfg.PatchVerification),
+		ImporterVersion:    Version,
+
+		InstanceTypeProfiles: instanceTypeProfiles,
+		DefaultProfile:       handler.Profile(cfg.DefaultProfile),
 	}
 
 	// Errors handler
@@ -124,16 +244,49 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := registerCheckers(hc, instanceConsumer, instanceCompleteProducer, errorReporterProducer, datasetAPICli.Client, graphDB); err != nil {
+	if err := registerCheckers(hc, instanceConsumer, instanceCompleteProducer, errorReporterProducer, importReportProducer, importFailedProducer, datasetAPICli, graphDB); err != nil {
 		log.Fatal(ctx, "failed to register health checker", err)
 		os.Exit(1)
 	}
 
-	httpServer := startHealthCheck(ctx, hc, cfg.BindAddr)
+	// Removal of the graph data of deleted instances, whose events are also accepted from the incoming instances topic
+	routes := map[string]message.Route{}
+	var instanceDeletedConsumer *kafka.ConsumerGroup
+	var dimensionsDeletedProducer *kafka.Producer
+	if cfg.InstanceDeletionEnabled {
+		var deletedRoute message.Route
+		deletedRoute, instanceDeletedConsumer, dimensionsDeletedProducer, err = startInstanceDeletion(ctx, cfg, &serviceList, hc, graphDB, deleter, registry, processed, errorReporter)
+		if err != nil {
+			log.Fatal(ctx, "failed to start instance deletion", err)
+			os.Exit(1)
+		}
+		routes[event.TypeInstanceDeleted] = deletedRoute
+	}
+
+	// The code list reorder endpoint is only registered if the graph database can be read back
+	var codeLists api.CodeListReader
+	if graphReader != nil {
+		codeLists = graphReader
+	}
+
+	httpServer, adminAPI := startHTTPServer(ctx, hc, reports, instanceEventHandler, codeLists, instanceEventHandler, cfg.ServiceAuthToken, cfg.BindAddr)
+
+	// Periodic check of the recently imported instances against the graph database
+	var backgroundReconciler *reconcile.Background
+	if cfg.ReconcileEnabled {
+		backgroundReconciler, err = startBackgroundReconciler(ctx, cfg, &serviceList, graphDB, datasetAPICli, instanceEventHandler, reports, errorReporter)
+		if err != nil {
+			log.Fatal(ctx, "failed to start background reconciliation", err)
+			os.Exit(1)
+		}
+	}
 
 	messageReceiver := message.KafkaMessageReceiver{
 		InstanceHandler: instanceEventHandler,
 		ErrorReporter:   errorReporter,
+		Unmarshaller:    newInstanceUnmarshaller,
+		Routes:          routes,
+		Processed:       processed,
 	}
 
 	// Start consuming messages from Kafka instanceConsumer
@@ -142,6 +295,8 @@ func main() {
 	instanceConsumer.Channels().LogErrors(ctx, "incoming instance kafka consumer received an error")
 	instanceCompleteProducer.Channels().LogErrors(ctx, "completed instance kafka producer received an error")
 	errorReporterProducer.Channels().LogErrors(ctx, "error reporter kafka producer received an error")
+	importReportProducer.Channels().LogErrors(ctx, "import report kafka producer received an error")
+	importFailedProducer.Channels().LogErrors(ctx, "import failed kafka producer received an error")
 
 	// If we receive a signal (SIGINT or SIGTERM), start graceful shutdown
 	s := <-signals
@@ -158,11 +313,25 @@ func main() {
 			hc.Stop()
 		}
 
+		if backgroundReconciler != nil {
+			log.Info(shutdownCtx, "stopping background reconciliation")
+			if err := backgroundReconciler.Close(shutdownCtx); err != nil {
+				log.Error(ctx, "error stopping background reconciliation", err)
+				hasShutdownError = true
+			}
+		}
+
 		if err := httpServer.Shutdown(shutdownCtx); err != nil {
 			log.Error(ctx, "error shutting down http server", err)
 			hasShutdownError = true
 		}
 
+		log.Info(shutdownCtx, "waiting for reorder and import jobs")
+		if err := adminAPI.Close(shutdownCtx); err != nil {
+			log.Error(ctx, "error waiting for reorder and import jobs", err)
+			hasShutdownError = true
+		}
+
 		if serviceList.InstanceConsumer {
 			log.Info(shutdownCtx, "stop listening to instance kafka consumer")
 			if err := instanceConsumer.StopListeningToConsumer(shutdownCtx); err != nil {
@@ -171,6 +340,20 @@ func main() {
 			}
 		}
 
+		if serviceList.InstanceDeletedConsumer {
+			log.Info(shutdownCtx, "stop listening to instance deleted kafka consumer")
+			if err := instanceDeletedConsumer.StopListeningToConsumer(shutdownCtx); err != nil {
+				log.Error(ctx, "error on stop listening to instance deleted kafka consumer", err)
+				hasShutdownError = true
+			}
+
+			log.Info(shutdownCtx, "closing instance deleted kafka consumer")
+			if err := instanceDeletedConsumer.Close(shutdownCtx); err != nil {
+				log.Error(ctx, "error closing instance deleted kafka consumer", err)
+				hasShutdownError = true
+			}
+		}
+
 		if serviceList.InstanceConsumer {
 			log.Info(shutdownCtx, "closing instance kafka consumer")
 			if err := instanceConsumer.Close(shutdownCtx); err != nil {
@@ -208,6 +391,30 @@ func main() {
 				hasShutdownError = true
 			}
 		}
+
+		if serviceList.ImportReportProducer {
+			log.Info(shutdownCtx, "closing import report kafka producer")
+			if err := importReportProducer.Close(shutdownCtx); err != nil {
+				log.Error(ctx, "error closing import report kafka producer", err)
+				hasShutdownError = true
+			}
+		}
+
+		if serviceList.ImportFailedProducer {
+			log.Info(shutdownCtx, "closing import failed kafka producer")
+			if err := importFailedProducer.Close(shutdownCtx); err != nil {
+				log.Error(ctx, "error closing import failed kafka producer", err)
+				hasShutdownError = true
+			}
+		}
+
+		if serviceList.DimensionsDeletedProducer {
+			log.Info(shutdownCtx, "closing dimensions deleted kafka producer")
+			if err := dimensionsDeletedProducer.Close(shutdownCtx); err != nil {
+				log.Error(ctx, "error closing dimensions deleted kafka producer", err)
+				hasShutdownError = true
+			}
+		}
 	}()
 
 	// wait for timeout or success (cancel)
@@ -223,10 +430,11 @@ func main() {
 	os.Exit(0)
 }
 
-// StartHealthCheck sets up the Handler, starts the healthcheck and the http server that serves health endpoint
-func startHealthCheck(ctx context.Context, hc *healthcheck.HealthCheck, bindAddr string) *dphttp.Server {
+// startHTTPServer sets up the Handler, starts the healthcheck and the http server that serves the health and API endpoints
+func startHTTPServer(ctx context.Context, hc *healthcheck.HealthCheck, reports *report.Store, reorderer api.Reorderer, codeLists api.CodeListReader, importer api.Importer, serviceAuthToken, bindAddr string) (*dphttp.Server, *api.API) {
 	router := mux.NewRouter()
 	router.Path("/health").HandlerFunc(hc.Handler)
+	a := api.Setup(ctx, router, reports, reorderer, codeLists, importer, serviceAuthToken)
 	hc.Start(ctx)
 
 	httpServer := dphttp.NewServer(bindAddr, router)
@@ -238,7 +446,87 @@ func startHealthCheck(ctx context.Context, hc *healthcheck.HealthCheck, bindAddr
 			hc.Stop()
 		}
 	}()
-	return httpServer
+	return httpServer, a
+}
+
+// startBackgroundReconciler starts the periodic check of the recently imported instances, sharing the graph database
+// and dataset API client of the instance event handler, and reporting any drift to the error reporter
+func startBackgroundReconciler(ctx context.Context, cfg *config.Config, serviceList *initialise.ExternalServiceList, graphDB store.Storer, datasetAPICli *client.DatasetAPI,
+	instanceEventHandler *handler.InstanceEventHandler, reports *report.Store, errorReporter reporter.ErrorReporter) (*reconcile.Background, error) {
+	reader, err := serviceList.GetGraphReader(graphDB)
+	if err != nil {
+		return nil, err
+	}
+
+	background := &reconcile.Background{
+		Reconciler: &reconcile.Reconciler{
+			DatasetAPICli: datasetAPICli,
+			Store:         graphDB,
+			Reader:        reader,
+			Handler:       instanceEventHandler,
+			CheckNodeIDs:  cfg.EnablePatchNodeID,
+		},
+		Reports:       reports,
+		ErrorReporter: errorReporter,
+		Window:        cfg.ReconcileWindow,
+		Interval:      cfg.ReconcileInterval,
+		CheckDelay:    cfg.ReconcileCheckDelay,
+	}
+	background.Start(ctx)
+	return background, nil
+}
+
+// startInstanceDeletion starts consuming the instance deleted events, removing the graph data of each deleted instance with the provided deleter
+// and confirming it with a dimensions deleted event. The consumer and producer are added to the healthcheck, and they must be closed on shutdown.
+// The returned route handles the instance deleted events in the same way, so that they can be received from other topics.
+func startInstanceDeletion(ctx context.Context, cfg *config.Config, serviceList *initialise.ExternalServiceList, hc *healthcheck.HealthCheck, graphDB store.Storer,
+	deleter store.InstanceDeleter, registry schema.Registry, processed idempotency.Store, errorReporter reporter.ErrorReporter) (message.Route, *kafka.ConsumerGroup, *kafka.Producer, error) {
+	if deleter == nil {
+		return message.Route{}, nil, nil, handler.ErrDeleteNotSupported
+	}
+
+	consumer, err := serviceList.GetInstanceDeletedConsumer(ctx, cfg.KafkaConfig)
+	if err != nil {
+		return message.Route{}, nil, nil, err
+	}
+	producer, err := serviceList.GetProducer(ctx, cfg.KafkaConfig.DimensionsDeletedTopic, initialise.DimensionsDeleted, cfg.KafkaConfig)
+	if err != nil {
+		return message.Route{}, consumer, nil, err
+	}
+	if err := hc.AddCheck("Kafka InstanceDeleted Consumer", consumer.Checker); err != nil {
+		return message.Route{}, consumer, producer, err
+	}
+	if err := hc.AddCheck("Kafka DimensionsDeleted Producer", producer.Checker); err != nil {
+		return message.Route{}, consumer, producer, err
+	}
+
+	var unmarshaller message.Unmarshaller = schema.InstanceDeletedSchema
+	var marshaller message.Marshaller = schema.DimensionsDeletedSchema
+	if registry != nil {
+		unmarshaller = schema.NewRegistryCodec(schema.InstanceDeletedSchema, registry, schema.Subject(cfg.KafkaConfig.InstanceDeletedTopic))
+		marshaller = schema.NewRegistryCodec(schema.DimensionsDeletedSchema, registry, schema.Subject(cfg.KafkaConfig.DimensionsDeletedTopic))
+	}
+
+	deletedHandler := &handler.InstanceDeletedEventHandler{
+		Store:     graphDB,
+		Deleter:   deleter,
+		Producer:  message.DimensionsDeletedProducer{Producer: producer, Marshaller: marshaller},
+		Processed: processed,
+	}
+	route := message.NewRoute("InstanceDeletedHandler", unmarshaller, deletedHandler, func(e event.InstanceDeleted) string {
+		return e.InstanceID
+	})
+	receiver := message.KafkaMessageReceiver{
+		ErrorReporter:    errorReporter,
+		Routes:           map[string]message.Route{event.TypeInstanceDeleted: route},
+		DefaultEventType: event.TypeInstanceDeleted,
+		Processed:        processed,
+	}
+	message.Consume(ctx, consumer, receiver, cfg.KafkaConfig.NumWorkers)
+
+	consumer.Channels().LogErrors(ctx, "instance deleted kafka consumer received an error")
+	producer.Channels().LogErrors(ctx, "dimensions deleted kafka producer received an error")
+	return route, consumer, producer, nil
 }
 
 // RegisterCheckers adds the checkers for the provided clients to the healthcheck object.
@@ -246,7 +534,9 @@ func registerCheckers(hc *healthcheck.HealthCheck,
 	instanceConsumer *kafka.ConsumerGroup,
 	instanceCompleteProducer *kafka.Producer,
 	errorReporterProducer *kafka.Producer,
-	datasetClient client.IClient,
+	importReportProducer *kafka.Producer,
+	importFailedProducer *kafka.Producer,
+	datasetAPI *client.DatasetAPI,
 	db store.Storer) (err error) {
 	hasErrors := false
 
@@ -265,7 +555,17 @@ func registerCheckers(hc *healthcheck.HealthCheck,
 		log.Error(context.Background(), "error adding check for kafka error reporter checker", err)
 	}
 
-	if err = hc.AddCheck("Dataset", datasetClient.Checker); err != nil {
+	if err = hc.AddCheck("Kafka ImportReport Producer", importReportProducer.Checker); err != nil {
+		hasErrors = true
+		log.Error(context.Background(), "error adding check for kafka import report producer checker", err)
+	}
+
+	if err = hc.AddCheck("Kafka ImportFailed Producer", importFailedProducer.Checker); err != nil {
+		hasErrors = true
+		log.Error(context.Background(), "error adding check for kafka import failed producer checker", err)
+	}
+
+	if err = hc.AddCheck("Dataset", datasetAPI.Checker); err != nil {
 		hasErrors = true
 		log.Error(context.Background(), "error adding check for dataset checker", err)
 	}
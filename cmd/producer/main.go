@@ -14,9 +14,12 @@ import (
 
 var instanceID = flag.String("instance", "5156253b-e21e-4a73-a783-fb53fabc1211", "")
 var file = flag.String("file", "s3://dp-frontend-florence-file-uploads/159-coicopcomb-inc-geo_cutcsv", "")
+var force = flag.Bool("force", false, "import the instance again if it has already been imported")
+var incremental = flag.Bool("incremental", false, "import only the missing dimension options if the instance has already been imported")
 
 var topic = flag.String("topic", "dimensions-extracted", "")
 var kafkaHost = flag.String("kafka", "localhost:9092", "")
+var registryFile = flag.String("schema-registry-file", "", "local schema registry file, the message is not framed with a schema ID if empty")
 var maxBytes = int(2000000)
 
 func main() {
@@ -37,11 +40,20 @@ func main() {
 	}
 
 	dimensionsInsertedEvent := event.NewInstance{
-		InstanceID: *instanceID,
-		FileURL:    *file,
+		InstanceID:  *instanceID,
+		FileURL:     *file,
+		Force:       *force,
+		Incremental: *incremental,
 	}
 
-	bytes, err := schema.NewInstanceSchema.Marshal(dimensionsInsertedEvent)
+	var marshaller interface {
+		Marshal(s interface{}) ([]byte, error)
+	} = schema.NewInstanceSchema
+	if *registryFile != "" {
+		marshaller = schema.NewRegistryCodec(schema.NewInstanceSchema, schema.NewFileRegistry(*registryFile), schema.Subject(*topic))
+	}
+
+	bytes, err := marshaller.Marshal(dimensionsInsertedEvent)
 	if err != nil {
 		log.Fatal(ctx, "error marshalling dimensions inserted event", err)
 		os.Exit(1)
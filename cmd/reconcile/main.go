@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ONSdigital/dp-dimension-importer/client"
+	"github.com/ONSdigital/dp-dimension-importer/config"
+	"github.com/ONSdigital/dp-dimension-importer/handler"
+	"github.com/ONSdigital/dp-dimension-importer/initialise"
+	"github.com/ONSdigital/dp-dimension-importer/reconcile"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+var fix = flag.Bool("fix", false, "import again the parts of each instance that differ from dataset API")
+
+// reconcile checks that the graph database holds what dataset API expects for each instance ID provided as an argument,
+// printing the discrepancies found as JSON to stdout. It exits with status 1 if any instance could not be checked or is not consistent.
+func main() {
+	log.Namespace = "dimension-importer-reconcile"
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-fix] instance_id [instance_id ...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	os.Exit(run(context.Background(), flag.Args()))
+}
+
+// run reconciles the provided instances and returns the exit status
+func run(ctx context.Context, instanceIDs []string) int {
+	cfg, err := config.Get(ctx)
+	if err != nil {
+		log.Fatal(ctx, "config load returned an error", err)
+		return 1
+	}
+
+	serviceList := initialise.ExternalServiceList{}
+	graphDB, err := serviceList.GetGraphDB(ctx)
+	if err != nil {
+		log.Fatal(ctx, "failed to get graphDB", err)
+		return 1
+	}
+	defer func() {
+		if err := graphDB.Close(ctx); err != nil {
+			log.Error(ctx, "error closing graph db", err)
+		}
+	}()
+
+	reader, err := serviceList.GetGraphReader(graphDB)
+	if err != nil {
+		log.Fatal(ctx, "graph database cannot be read back", err)
+		return 1
+	}
+
+	datasetAPICli, err := client.NewDatasetAPIClient(cfg)
+	if err != nil {
+		log.Fatal(ctx, "failed to create datasetAPI client", err)
+		return 1
+	}
+
+	// instance types that are not imported to the graph database are skipped
+	instanceTypeProfiles := make(map[string]handler.Profile, len(cfg.InstanceTypeProfiles))
+	for instanceType, profile := range cfg.InstanceTypeProfiles {
+		instanceTypeProfiles[instanceType] = handler.Profile(profile)
+	}
+
+	reconciler := &reconcile.Reconciler{
+		DatasetAPICli: datasetAPICli,
+		Store:         graphDB,
+		Reader:        reader,
+		CheckNodeIDs:  cfg.EnablePatchNodeID,
+		Handler: &handler.InstanceEventHandler{
+			Store:              graphDB,
+			DatasetAPICli:      datasetAPICli,
+			BatchSize:          cfg.KafkaConfig.BatchSize,
+			EnablePatchNodeID:  cfg.EnablePatchNodeID,
+			MaxConflictRetries: cfg.DatasetAPIConflictRetries,
+
+			InstanceTypeProfiles: instanceTypeProfiles,
+			DefaultProfile:       handler.Profile(cfg.DefaultProfile),
+		},
+	}
+
+	results := make([]*reconcile.Result, 0, len(instanceIDs))
+	consistent := true
+	for _, instanceID := range instanceIDs {
+		var result *reconcile.Result
+		if *fix {
+			result = reconciler.Fix(ctx, instanceID)
+		} else {
+			result = reconciler.Check(ctx, instanceID)
+		}
+		consistent = consistent && result.Consistent()
+		results = append(results, result)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(results); err != nil {
+		log.Error(ctx, "failed to write the reconciliation results", err)
+		return 1
+	}
+
+	if !consistent {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ONSdigital/dp-dimension-importer/schema"
+)
+
+var dir = flag.String("dir", "schema/avro", "directory of the schema definition files")
+var check = flag.Bool("check", false, "only check that the latest version of each schema matches its event struct, without writing any files")
+
+// schemagen generates the avro schemas of the kafka messages from the avro tags of their event structs.
+// When an event struct has new fields, a new version of its schema is written to '<dir>/<schema name>.v<version>.avsc',
+// the new fields having the zero value of their type as default. It exits with status 1 if an event struct cannot be described by a new version,
+// or, in check mode, if any schema does not match its event struct.
+func main() {
+	flag.Parse()
+
+	status := 0
+	for _, v := range schema.All {
+		if err := generate(v); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+		}
+	}
+	os.Exit(status)
+}
+
+// generate checks the schema against its event struct, writing its next version if it has changed and check mode is off
+func generate(v *schema.Versioned) error {
+	if *check {
+		return v.Check()
+	}
+
+	next, err := v.Next()
+	if err != nil || next == "" {
+		return err
+	}
+	path := filepath.Join(*dir, fmt.Sprintf("%s.v%d.avsc", v.Name, len(v.Versions)+1))
+	if err := os.WriteFile(path, []byte(next), 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("generated %s\n", path)
+	return nil
+}
@@ -16,16 +16,52 @@ const KafkaTLSProtocolFlag = "TLS"
 
 // Config struct to hold application configuration.
 type Config struct {
-	BindAddr                   string        `envconfig:"BIND_ADDR"`
-	ServiceAuthToken           string        `envconfig:"SERVICE_AUTH_TOKEN"            json:"-"`
-	DatasetAPIAddr             string        `envconfig:"DATASET_API_ADDR"`
-	DatasetAPIMaxWorkers       int           `envconfig:"DATASET_API_MAX_WORKERS"` // maximum number of concurrent go-routines requesting items to datast api at the same time
-	DatasetAPIBatchSize        int           `envconfig:"DATASET_API_BATCH_SIZE"`  // maximum size of a response by dataset api when requesting items in batches
-	GracefulShutdownTimeout    time.Duration `envconfig:"GRACEFUL_SHUTDOWN_TIMEOUT"`
-	HealthCheckInterval        time.Duration `envconfig:"HEALTHCHECK_INTERVAL"`
-	HealthCheckCriticalTimeout time.Duration `envconfig:"HEALTHCHECK_CRITICAL_TIMEOUT"`
-	EnablePatchNodeID          bool          `envconfig:"ENABLE_PATCH_NODE_ID"`
-	KafkaConfig                KafkaConfig
+	BindAddr                         string            `envconfig:"BIND_ADDR"`
+	ServiceAuthToken                 string            `envconfig:"SERVICE_AUTH_TOKEN"            json:"-"`
+	DatasetAPIAddr                   string            `envconfig:"DATASET_API_ADDR"`
+	DatasetAPIMaxWorkers             int               `envconfig:"DATASET_API_MAX_WORKERS"`              // maximum number of concurrent go-routines requesting items to datast api at the same time
+	DatasetAPIBatchSize              int               `envconfig:"DATASET_API_BATCH_SIZE"`               // maximum size of a response by dataset api when requesting items in batches
+	DatasetAPIGetInstanceTimeout     time.Duration     `envconfig:"DATASET_API_GET_INSTANCE_TIMEOUT"`     // maximum time for a get instance call, 0 means no timeout
+	DatasetAPIGetDimensionsTimeout   time.Duration     `envconfig:"DATASET_API_GET_DIMENSIONS_TIMEOUT"`   // maximum time for getting all the dimension options of an instance, or each page of them if streamed, 0 means no timeout
+	DatasetAPIPatchDimensionsTimeout time.Duration     `envconfig:"DATASET_API_PATCH_DIMENSIONS_TIMEOUT"` // maximum time for a dimension options patch call, 0 means no timeout
+	DatasetAPIMaxRetries             int               `envconfig:"DATASET_API_MAX_RETRIES"`              // number of times that a failed idempotent call is retried
+	DatasetAPIRetryBackoff           time.Duration     `envconfig:"DATASET_API_RETRY_BACKOFF"`            // time to wait before the first retry, doubled after each retry
+	DatasetAPIRetryMaxBackoff        time.Duration     `envconfig:"DATASET_API_RETRY_MAX_BACKOFF"`        // maximum time to wait between retries
+	DatasetAPIBreakerThreshold       int               `envconfig:"DATASET_API_BREAKER_THRESHOLD"`        // consecutive failures that open the circuit breaker, 0 disables it
+	DatasetAPIBreakerCooldown        time.Duration     `envconfig:"DATASET_API_BREAKER_COOLDOWN"`         // time that the circuit breaker stays open before allowing calls again
+	DatasetAPIConflictRetries        int               `envconfig:"DATASET_API_CONFLICT_RETRIES"`         // number of times that a patch rejected because the instance has been modified is retried
+	DatasetAPIPatchMaxOptions        int               `envconfig:"DATASET_API_PATCH_MAX_OPTIONS"`        // maximum number of dimension options patched by a single request, 0 means no limit
+	DatasetAPIPatchMaxBytes          int               `envconfig:"DATASET_API_PATCH_MAX_BYTES"`          // maximum size in bytes of a dimension options patch request body, 0 means no limit
+	DatasetAPIPatchParallelism       int               `envconfig:"DATASET_API_PATCH_PARALLELISM"`        // maximum number of concurrent patch requests when a patch is split and the instance eTag is not known, 1 means sequential
+	GracefulShutdownTimeout          time.Duration     `envconfig:"GRACEFUL_SHUTDOWN_TIMEOUT"`
+	HealthCheckInterval              time.Duration     `envconfig:"HEALTHCHECK_INTERVAL"`
+	HealthCheckCriticalTimeout       time.Duration     `envconfig:"HEALTHCHECK_CRITICAL_TIMEOUT"`
+	EnablePatchNodeID                bool              `envconfig:"ENABLE_PATCH_NODE_ID"`
+	StreamDimensions                 bool              `envconfig:"STREAM_DIMENSIONS"`           // retrieve and process the dimension options in pages of DATASET_API_BATCH_SIZE, instead of loading all of them in memory
+	PatchVerification                string            `envconfig:"PATCH_VERIFICATION"`          // read back the patched dimension options: 'off', 'repair' or 'fail'
+	ImportReportStoreSize            int               `envconfig:"IMPORT_REPORT_STORE_SIZE"`    // maximum number of import reports kept in memory
+	OptionMaxLength                  int               `envconfig:"OPTION_MAX_LENGTH"`           // maximum number of characters of a dimension option, 0 means no limit
+	OptionAllowedPattern             string            `envconfig:"OPTION_ALLOWED_PATTERN"`      // regular expression that dimension options must fully match, empty means any
+	InstanceTypeProfiles             map[string]string `envconfig:"INSTANCE_TYPE_PROFILES"`      // pipeline profile for each instance type, e.g. 'cantabular_table:noop'
+	DefaultProfile                   string            `envconfig:"DEFAULT_PIPELINE_PROFILE"`    // pipeline profile for instance types without a profile
+	LocalOrderFile                   string            `envconfig:"LOCAL_ORDER_FILE"`            // JSON file with the ordered codes of each code list, used by the order_only profile
+	OrderFallbacks                   []string          `envconfig:"ORDER_FALLBACKS"`             // fallback orderers for the options without a code list order, tried in order: 'explicit', 'chronological' or 'natural'
+	OrderFallbackDir                 string            `envconfig:"ORDER_FALLBACK_DIR"`          // directory with the ordering file of each code list, used by the explicit fallback orderer
+	ReconcileEnabled                 bool              `envconfig:"RECONCILE_ENABLED"`           // periodically check the recently imported instances against the graph database
+	ReconcileWindow                  time.Duration     `envconfig:"RECONCILE_WINDOW"`            // instances imported within this time are checked
+	ReconcileInterval                time.Duration     `envconfig:"RECONCILE_INTERVAL"`          // time between background reconciliation passes
+	ReconcileCheckDelay              time.Duration     `envconfig:"RECONCILE_CHECK_DELAY"`       // minimum time between two instance checks
+	SchemaRegistryURL                string            `envconfig:"SCHEMA_REGISTRY_URL"`         // URL of the schema registry of the kafka messages, empty means the compiled-in schemas are used
+	SchemaRegistryFile               string            `envconfig:"SCHEMA_REGISTRY_FILE"`        // local JSON file standing in for a schema registry, for development and tests
+	SchemaRegistryTimeout            time.Duration     `envconfig:"SCHEMA_REGISTRY_TIMEOUT"`     // timeout of each schema registry request
+	SchemaRegistryBackoff            time.Duration     `envconfig:"SCHEMA_REGISTRY_BACKOFF"`     // time during which a failed schema registry request is not repeated, doubled after each consecutive failure
+	SchemaRegistryMaxBackoff         time.Duration     `envconfig:"SCHEMA_REGISTRY_MAX_BACKOFF"` // maximum time during which a failed schema registry request is not repeated
+	InstanceDeletionEnabled          bool              `envconfig:"INSTANCE_DELETION_ENABLED"`   // consume instance deleted events to remove the graph data of deleted instances
+	IdempotencyEnabled               bool              `envconfig:"IDEMPOTENCY_ENABLED"`         // skip the consumed events that have already been processed successfully
+	IdempotencyStoreSize             int               `envconfig:"IDEMPOTENCY_STORE_SIZE"`      // maximum number of processed events remembered
+	IdempotencyWindow                time.Duration     `envconfig:"IDEMPOTENCY_WINDOW"`          // processed events are remembered for this time
+	IdempotencyFile                  string            `envconfig:"IDEMPOTENCY_FILE"`            // JSON file where the processed events are kept across restarts, empty means they are only kept in memory
+	KafkaConfig                      KafkaConfig
 }
 
 // KafkaConfig contains the config required to connect to Kafka
@@ -44,6 +80,11 @@ type KafkaConfig struct {
 	IncomingInstancesConsumerGroup string   `envconfig:"DIMENSIONS_EXTRACTED_CONSUMER_GROUP"`
 	OutgoingInstancesTopic         string   `envconfig:"DIMENSIONS_INSERTED_TOPIC"`
 	EventReporterTopic             string   `envconfig:"EVENT_REPORTER_TOPIC"`
+	ImportReportTopic              string   `envconfig:"DIMENSIONS_IMPORT_REPORT_TOPIC"`
+	ImportFailedTopic              string   `envconfig:"DIMENSIONS_IMPORT_FAILED_TOPIC"`
+	InstanceDeletedTopic           string   `envconfig:"INSTANCE_DELETED_TOPIC"`
+	InstanceDeletedConsumerGroup   string   `envconfig:"INSTANCE_DELETED_CONSUMER_GROUP"`
+	DimensionsDeletedTopic         string   `envconfig:"DIMENSIONS_DELETED_TOPIC"`
 }
 
 var cfg *Config
@@ -67,14 +108,48 @@ func getDefaultConfig() *Config {
 			IncomingInstancesConsumerGroup: "dp-dimension-importer",
 			OutgoingInstancesTopic:         "dimensions-inserted",
 			EventReporterTopic:             "report-events",
+			ImportReportTopic:              "dimensions-import-report",
+			ImportFailedTopic:              "dimensions-import-failed",
+			InstanceDeletedTopic:           "instance-deleted",
+			InstanceDeletedConsumerGroup:   "dp-dimension-importer-instance-deleted",
+			DimensionsDeletedTopic:         "dimensions-deleted",
 		},
-		DatasetAPIAddr:             "http://localhost:22000",
-		DatasetAPIMaxWorkers:       100,
-		DatasetAPIBatchSize:        1000,
-		GracefulShutdownTimeout:    time.Second * 5,
-		HealthCheckInterval:        30 * time.Second,
-		HealthCheckCriticalTimeout: 90 * time.Second,
-		EnablePatchNodeID:          true,
+		DatasetAPIAddr:                   "http://localhost:22000",
+		DatasetAPIMaxWorkers:             100,
+		DatasetAPIBatchSize:              1000,
+		DatasetAPIGetInstanceTimeout:     30 * time.Second,
+		DatasetAPIGetDimensionsTimeout:   0,
+		DatasetAPIPatchDimensionsTimeout: 30 * time.Second,
+		DatasetAPIMaxRetries:             3,
+		DatasetAPIRetryBackoff:           500 * time.Millisecond,
+		DatasetAPIRetryMaxBackoff:        10 * time.Second,
+		DatasetAPIBreakerThreshold:       10,
+		DatasetAPIBreakerCooldown:        30 * time.Second,
+		DatasetAPIConflictRetries:        3,
+		DatasetAPIPatchMaxOptions:        1000,
+		DatasetAPIPatchMaxBytes:          1024 * 1024,
+		DatasetAPIPatchParallelism:       1,
+		GracefulShutdownTimeout:          time.Second * 5,
+		HealthCheckInterval:              30 * time.Second,
+		HealthCheckCriticalTimeout:       90 * time.Second,
+		EnablePatchNodeID:                true,
+		StreamDimensions:                 false,
+		PatchVerification:                "off",
+		ImportReportStoreSize:            100,
+		InstanceTypeProfiles:             map[string]string{},
+		DefaultProfile:                   "graph",
+		OrderFallbacks:                   []string{},
+		ReconcileEnabled:                 false,
+		ReconcileWindow:                  24 * time.Hour,
+		ReconcileInterval:                time.Hour,
+		ReconcileCheckDelay:              10 * time.Second,
+		SchemaRegistryTimeout:            10 * time.Second,
+		SchemaRegistryBackoff:            time.Second,
+		SchemaRegistryMaxBackoff:         time.Minute,
+		InstanceDeletionEnabled:          false,
+		IdempotencyEnabled:               false,
+		IdempotencyStoreSize:             10000,
+		IdempotencyWindow:                24 * time.Hour,
 	}
 }
 
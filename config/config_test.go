@@ -37,13 +37,54 @@ func TestGet(t *testing.T) {
 					So(cfg.KafkaConfig.IncomingInstancesConsumerGroup, ShouldEqual, "dp-dimension-importer")
 					So(cfg.KafkaConfig.OutgoingInstancesTopic, ShouldEqual, "dimensions-inserted")
 					So(cfg.KafkaConfig.EventReporterTopic, ShouldEqual, "report-events")
+					So(cfg.KafkaConfig.ImportReportTopic, ShouldEqual, "dimensions-import-report")
+					So(cfg.KafkaConfig.ImportFailedTopic, ShouldEqual, "dimensions-import-failed")
+					So(cfg.KafkaConfig.InstanceDeletedTopic, ShouldEqual, "instance-deleted")
+					So(cfg.KafkaConfig.InstanceDeletedConsumerGroup, ShouldEqual, "dp-dimension-importer-instance-deleted")
+					So(cfg.KafkaConfig.DimensionsDeletedTopic, ShouldEqual, "dimensions-deleted")
 					So(cfg.DatasetAPIAddr, ShouldEqual, "http://localhost:22000")
 					So(cfg.DatasetAPIMaxWorkers, ShouldEqual, 100)
 					So(cfg.DatasetAPIBatchSize, ShouldEqual, 1000)
+					So(cfg.DatasetAPIGetInstanceTimeout, ShouldEqual, 30*time.Second)
+					So(cfg.DatasetAPIGetDimensionsTimeout, ShouldEqual, time.Duration(0))
+					So(cfg.DatasetAPIPatchDimensionsTimeout, ShouldEqual, 30*time.Second)
+					So(cfg.DatasetAPIMaxRetries, ShouldEqual, 3)
+					So(cfg.DatasetAPIRetryBackoff, ShouldEqual, 500*time.Millisecond)
+					So(cfg.DatasetAPIRetryMaxBackoff, ShouldEqual, 10*time.Second)
+					So(cfg.DatasetAPIBreakerThreshold, ShouldEqual, 10)
+					So(cfg.DatasetAPIBreakerCooldown, ShouldEqual, 30*time.Second)
+					So(cfg.DatasetAPIConflictRetries, ShouldEqual, 3)
+					So(cfg.DatasetAPIPatchMaxOptions, ShouldEqual, 1000)
+					So(cfg.DatasetAPIPatchMaxBytes, ShouldEqual, 1024*1024)
+					So(cfg.DatasetAPIPatchParallelism, ShouldEqual, 1)
 					So(cfg.GracefulShutdownTimeout, ShouldEqual, 5*time.Second)
 					So(cfg.HealthCheckInterval, ShouldEqual, 30*time.Second)
 					So(cfg.HealthCheckCriticalTimeout, ShouldEqual, 90*time.Second)
 					So(cfg.EnablePatchNodeID, ShouldEqual, true)
+					So(cfg.StreamDimensions, ShouldEqual, false)
+					So(cfg.PatchVerification, ShouldEqual, "off")
+					So(cfg.ImportReportStoreSize, ShouldEqual, 100)
+					So(cfg.OptionMaxLength, ShouldEqual, 0)
+					So(cfg.OptionAllowedPattern, ShouldEqual, "")
+					So(cfg.InstanceTypeProfiles, ShouldBeEmpty)
+					So(cfg.DefaultProfile, ShouldEqual, "graph")
+					So(cfg.LocalOrderFile, ShouldEqual, "")
+					So(cfg.OrderFallbacks, ShouldBeEmpty)
+					So(cfg.OrderFallbackDir, ShouldEqual, "")
+					So(cfg.ReconcileEnabled, ShouldBeFalse)
+					So(cfg.ReconcileWindow, ShouldEqual, 24*time.Hour)
+					So(cfg.ReconcileInterval, ShouldEqual, time.Hour)
+					So(cfg.ReconcileCheckDelay, ShouldEqual, 10*time.Second)
+					So(cfg.SchemaRegistryURL, ShouldEqual, "")
+					So(cfg.SchemaRegistryFile, ShouldEqual, "")
+					So(cfg.SchemaRegistryTimeout, ShouldEqual, 10*time.Second)
+					So(cfg.SchemaRegistryBackoff, ShouldEqual, time.Second)
+					So(cfg.SchemaRegistryMaxBackoff, ShouldEqual, time.Minute)
+					So(cfg.InstanceDeletionEnabled, ShouldBeFalse)
+					So(cfg.IdempotencyEnabled, ShouldBeFalse)
+					So(cfg.IdempotencyStoreSize, ShouldEqual, 10000)
+					So(cfg.IdempotencyWindow, ShouldEqual, 24*time.Hour)
+					So(cfg.IdempotencyFile, ShouldEqual, "")
 				})
 			})
 		})
@@ -112,6 +153,9 @@ func TestString(t *testing.T) {
 					So(cfgStr, ShouldContainSubstring, "HealthCheckInterval")
 					So(cfgStr, ShouldContainSubstring, "HealthCheckCriticalTimeout")
 					So(cfgStr, ShouldContainSubstring, "EnablePatchNodeID")
+					So(cfgStr, ShouldContainSubstring, "ImportReportStoreSize")
+					So(cfgStr, ShouldContainSubstring, "OptionMaxLength")
+					So(cfgStr, ShouldContainSubstring, "OptionAllowedPattern")
 
 					So(cfgStr, ShouldContainSubstring, "KafkaConfig")
 					So(cfgStr, ShouldContainSubstring, "BatchSize")
@@ -126,6 +170,11 @@ func TestString(t *testing.T) {
 					So(cfgStr, ShouldContainSubstring, "IncomingInstancesConsumerGroup")
 					So(cfgStr, ShouldContainSubstring, "OutgoingInstancesTopic")
 					So(cfgStr, ShouldContainSubstring, "EventReporterTopic")
+					So(cfgStr, ShouldContainSubstring, "ImportReportTopic")
+					So(cfgStr, ShouldContainSubstring, "ImportFailedTopic")
+					So(cfgStr, ShouldContainSubstring, "InstanceDeletedTopic")
+					So(cfgStr, ShouldContainSubstring, "InstanceDeletedConsumerGroup")
+					So(cfgStr, ShouldContainSubstring, "DimensionsDeletedTopic")
 				})
 			})
 		})
@@ -2,6 +2,8 @@ package config
 
 import (
 	"context"
+	"fmt"
+	"regexp"
 
 	"github.com/ONSdigital/log.go/v2/log"
 )
@@ -13,6 +15,102 @@ func validateConfig(ctx context.Context, cfg *Config) []string {
 		errs = append(errs, "no SERVICE_AUTH_TOKEN given")
 	}
 
+	if cfg.DatasetAPIGetInstanceTimeout < 0 || cfg.DatasetAPIGetDimensionsTimeout < 0 || cfg.DatasetAPIPatchDimensionsTimeout < 0 {
+		errs = append(errs, "DATASET_API timeouts cannot be negative")
+	}
+
+	if cfg.DatasetAPIMaxRetries < 0 {
+		errs = append(errs, "DATASET_API_MAX_RETRIES is less than 0")
+	}
+
+	if cfg.DatasetAPIBreakerThreshold < 0 {
+		errs = append(errs, "DATASET_API_BREAKER_THRESHOLD is less than 0")
+	}
+
+	if cfg.DatasetAPIConflictRetries < 0 {
+		errs = append(errs, "DATASET_API_CONFLICT_RETRIES is less than 0")
+	}
+
+	if cfg.DatasetAPIPatchMaxOptions < 0 {
+		errs = append(errs, "DATASET_API_PATCH_MAX_OPTIONS is less than 0")
+	}
+
+	if cfg.DatasetAPIPatchMaxBytes < 0 {
+		errs = append(errs, "DATASET_API_PATCH_MAX_BYTES is less than 0")
+	}
+
+	if cfg.DatasetAPIPatchParallelism < 1 {
+		errs = append(errs, "DATASET_API_PATCH_PARALLELISM is less than 1")
+	}
+
+	if cfg.ImportReportStoreSize < 1 {
+		errs = append(errs, "IMPORT_REPORT_STORE_SIZE is less than 1")
+	}
+
+	if cfg.OptionMaxLength < 0 {
+		errs = append(errs, "OPTION_MAX_LENGTH is less than 0")
+	}
+
+	if _, err := regexp.Compile(cfg.OptionAllowedPattern); err != nil {
+		errs = append(errs, "OPTION_ALLOWED_PATTERN is not a valid regular expression")
+	}
+
+	if !isPipelineProfile(cfg.DefaultProfile) {
+		errs = append(errs, "DEFAULT_PIPELINE_PROFILE has invalid value")
+	}
+
+	for instanceType, profile := range cfg.InstanceTypeProfiles {
+		if !isPipelineProfile(profile) {
+			errs = append(errs, fmt.Sprintf("INSTANCE_TYPE_PROFILES has invalid value for instance type %s", instanceType))
+		}
+	}
+
+	for _, fallback := range cfg.OrderFallbacks {
+		switch fallback {
+		case "explicit":
+			if cfg.OrderFallbackDir == "" {
+				errs = append(errs, "no ORDER_FALLBACK_DIR given for the explicit fallback orderer")
+			}
+		case "chronological", "natural":
+		default:
+			errs = append(errs, fmt.Sprintf("ORDER_FALLBACKS has invalid value %s", fallback))
+		}
+	}
+
+	if len(cfg.OrderFallbacks) > 0 && cfg.StreamDimensions {
+		errs = append(errs, "ORDER_FALLBACKS cannot be used with STREAM_DIMENSIONS")
+	}
+
+	switch cfg.PatchVerification {
+	case "off", "repair", "fail":
+	default:
+		errs = append(errs, "PATCH_VERIFICATION has invalid value")
+	}
+
+	if cfg.ReconcileEnabled && (cfg.ReconcileWindow <= 0 || cfg.ReconcileInterval <= 0) {
+		errs = append(errs, "RECONCILE_WINDOW and RECONCILE_INTERVAL must be greater than 0")
+	}
+
+	if cfg.ReconcileCheckDelay < 0 {
+		errs = append(errs, "RECONCILE_CHECK_DELAY cannot be negative")
+	}
+
+	if cfg.SchemaRegistryURL != "" && cfg.SchemaRegistryFile != "" {
+		errs = append(errs, "SCHEMA_REGISTRY_URL and SCHEMA_REGISTRY_FILE cannot both be given")
+	}
+
+	if cfg.SchemaRegistryURL != "" && cfg.SchemaRegistryTimeout <= 0 {
+		errs = append(errs, "SCHEMA_REGISTRY_TIMEOUT must be greater than 0")
+	}
+
+	if cfg.SchemaRegistryBackoff < 0 || cfg.SchemaRegistryMaxBackoff < 0 {
+		errs = append(errs, "SCHEMA_REGISTRY_BACKOFF and SCHEMA_REGISTRY_MAX_BACKOFF cannot be negative")
+	}
+
+	if cfg.IdempotencyEnabled && (cfg.IdempotencyStoreSize < 1 || cfg.IdempotencyWindow <= 0) {
+		errs = append(errs, "IDEMPOTENCY_STORE_SIZE and IDEMPOTENCY_WINDOW must be greater than 0")
+	}
+
 	kafkaCfgErrs := validateKafkaValues(cfg.KafkaConfig)
 	if len(kafkaCfgErrs) != 0 {
 		log.Info(ctx, "failed kafka configuration validation")
@@ -22,6 +120,15 @@ func validateConfig(ctx context.Context, cfg *Config) []string {
 	return errs
 }
 
+// isPipelineProfile returns true if the provided value is the name of a pipeline profile supported by the handler
+func isPipelineProfile(profile string) bool {
+	switch profile {
+	case "graph", "order_only", "noop":
+		return true
+	}
+	return false
+}
+
 func validateKafkaValues(kafkaConfig KafkaConfig) []string {
 	errs := []string{}
 
@@ -3,6 +3,7 @@ package config
 import (
 	"context"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -34,6 +35,177 @@ func TestValidateConfig(t *testing.T) {
 				})
 			})
 		})
+
+		Convey("And IMPORT_REPORT_STORE_SIZE is less than 1", func() {
+			cfg.ImportReportStoreSize = 0
+
+			Convey("When validateConfig is called", func() {
+				errs := validateConfig(ctx, cfg)
+
+				Convey("Then an error message should be returned", func() {
+					So(errs, ShouldResemble, []string{"IMPORT_REPORT_STORE_SIZE is less than 1"})
+				})
+			})
+		})
+
+		Convey("And OPTION_MAX_LENGTH is negative and OPTION_ALLOWED_PATTERN is not a valid regular expression", func() {
+			cfg.OptionMaxLength = -1
+			cfg.OptionAllowedPattern = "[a-z"
+
+			Convey("When validateConfig is called", func() {
+				errs := validateConfig(ctx, cfg)
+
+				Convey("Then the expected error messages should be returned", func() {
+					So(errs, ShouldResemble, []string{
+						"OPTION_MAX_LENGTH is less than 0",
+						"OPTION_ALLOWED_PATTERN is not a valid regular expression",
+					})
+				})
+			})
+		})
+
+		Convey("And DATASET_API_PATCH_MAX_OPTIONS and DATASET_API_PATCH_MAX_BYTES are negative and DATASET_API_PATCH_PARALLELISM is 0", func() {
+			cfg.DatasetAPIPatchMaxOptions = -1
+			cfg.DatasetAPIPatchMaxBytes = -1
+			cfg.DatasetAPIPatchParallelism = 0
+
+			Convey("When validateConfig is called", func() {
+				errs := validateConfig(ctx, cfg)
+
+				Convey("Then the expected error messages should be returned", func() {
+					So(errs, ShouldResemble, []string{
+						"DATASET_API_PATCH_MAX_OPTIONS is less than 0",
+						"DATASET_API_PATCH_MAX_BYTES is less than 0",
+						"DATASET_API_PATCH_PARALLELISM is less than 1",
+					})
+				})
+			})
+		})
+
+		Convey("And a DATASET_API timeout, DATASET_API_MAX_RETRIES, DATASET_API_BREAKER_THRESHOLD and DATASET_API_CONFLICT_RETRIES are negative", func() {
+			cfg.DatasetAPIPatchDimensionsTimeout = -time.Second
+			cfg.DatasetAPIMaxRetries = -1
+			cfg.DatasetAPIBreakerThreshold = -1
+			cfg.DatasetAPIConflictRetries = -1
+
+			Convey("When validateConfig is called", func() {
+				errs := validateConfig(ctx, cfg)
+
+				Convey("Then the expected error messages should be returned", func() {
+					So(errs, ShouldResemble, []string{
+						"DATASET_API timeouts cannot be negative",
+						"DATASET_API_MAX_RETRIES is less than 0",
+						"DATASET_API_BREAKER_THRESHOLD is less than 0",
+						"DATASET_API_CONFLICT_RETRIES is less than 0",
+					})
+				})
+			})
+		})
+
+		Convey("And DEFAULT_PIPELINE_PROFILE and INSTANCE_TYPE_PROFILES have invalid values", func() {
+			cfg.DefaultProfile = "unknown"
+			cfg.InstanceTypeProfiles = map[string]string{"cantabular_table": "noop", "v4": "invalid"}
+
+			Convey("When validateConfig is called", func() {
+				errs := validateConfig(ctx, cfg)
+
+				Convey("Then the expected error messages should be returned", func() {
+					So(errs, ShouldResemble, []string{
+						"DEFAULT_PIPELINE_PROFILE has invalid value",
+						"INSTANCE_TYPE_PROFILES has invalid value for instance type v4",
+					})
+				})
+			})
+		})
+
+		Convey("And PATCH_VERIFICATION has an invalid value", func() {
+			cfg.PatchVerification = "sometimes"
+
+			Convey("When validateConfig is called", func() {
+				errs := validateConfig(ctx, cfg)
+
+				Convey("Then the expected error message should be returned", func() {
+					So(errs, ShouldResemble, []string{"PATCH_VERIFICATION has invalid value"})
+				})
+			})
+		})
+
+		Convey("And ORDER_FALLBACKS has an invalid value and the explicit fallback without ORDER_FALLBACK_DIR, with STREAM_DIMENSIONS", func() {
+			cfg.OrderFallbacks = []string{"explicit", "random", "natural"}
+			cfg.StreamDimensions = true
+
+			Convey("When validateConfig is called", func() {
+				errs := validateConfig(ctx, cfg)
+
+				Convey("Then the expected error messages should be returned", func() {
+					So(errs, ShouldResemble, []string{
+						"no ORDER_FALLBACK_DIR given for the explicit fallback orderer",
+						"ORDER_FALLBACKS has invalid value random",
+						"ORDER_FALLBACKS cannot be used with STREAM_DIMENSIONS",
+					})
+				})
+			})
+		})
+
+		Convey("And the background reconciliation is enabled with a zero RECONCILE_INTERVAL and a negative RECONCILE_CHECK_DELAY", func() {
+			cfg.ReconcileEnabled = true
+			cfg.ReconcileInterval = 0
+			cfg.ReconcileCheckDelay = -time.Second
+
+			Convey("When validateConfig is called", func() {
+				errs := validateConfig(ctx, cfg)
+
+				Convey("Then the expected error messages should be returned", func() {
+					So(errs, ShouldResemble, []string{
+						"RECONCILE_WINDOW and RECONCILE_INTERVAL must be greater than 0",
+						"RECONCILE_CHECK_DELAY cannot be negative",
+					})
+				})
+			})
+		})
+
+		Convey("And both SCHEMA_REGISTRY_URL and SCHEMA_REGISTRY_FILE are given", func() {
+			cfg.SchemaRegistryURL = "http://localhost:8081"
+			cfg.SchemaRegistryFile = "registry.json"
+
+			Convey("When validateConfig is called", func() {
+				errs := validateConfig(ctx, cfg)
+
+				Convey("Then the expected error message should be returned", func() {
+					So(errs, ShouldResemble, []string{"SCHEMA_REGISTRY_URL and SCHEMA_REGISTRY_FILE cannot both be given"})
+				})
+			})
+		})
+
+		Convey("And SCHEMA_REGISTRY_URL is given with a zero SCHEMA_REGISTRY_TIMEOUT, and SCHEMA_REGISTRY_BACKOFF is negative", func() {
+			cfg.SchemaRegistryURL = "http://localhost:8081"
+			cfg.SchemaRegistryTimeout = 0
+			cfg.SchemaRegistryBackoff = -time.Second
+
+			Convey("When validateConfig is called", func() {
+				errs := validateConfig(ctx, cfg)
+
+				Convey("Then the expected error messages should be returned", func() {
+					So(errs, ShouldResemble, []string{
+						"SCHEMA_REGISTRY_TIMEOUT must be greater than 0",
+						"SCHEMA_REGISTRY_BACKOFF and SCHEMA_REGISTRY_MAX_BACKOFF cannot be negative",
+					})
+				})
+			})
+		})
+
+		Convey("And the idempotency store is enabled with a zero IDEMPOTENCY_STORE_SIZE", func() {
+			cfg.IdempotencyEnabled = true
+			cfg.IdempotencyStoreSize = 0
+
+			Convey("When validateConfig is called", func() {
+				errs := validateConfig(ctx, cfg)
+
+				Convey("Then the expected error message should be returned", func() {
+					So(errs, ShouldResemble, []string{"IDEMPOTENCY_STORE_SIZE and IDEMPOTENCY_WINDOW must be greater than 0"})
+				})
+			})
+		})
 	})
 }
 
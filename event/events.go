@@ -1,13 +1,116 @@
 package event
 
+// The types of the events consumed by the service, given by the event type header of their kafka messages
+const (
+	TypeNewInstance     = "dimensions-extracted"
+	TypeInstanceDeleted = "instance-deleted"
+)
+
 // NewInstance represents a 'Dimensions Extracted' kafka messagae.
+// The dataset, edition, version, trace ID, force and incremental fields are optional, and they are empty if the message does not have them.
 type NewInstance struct {
 	FileURL    string `avro:"file_url"`
 	InstanceID string `avro:"instance_id"`
+	DatasetID  string `avro:"dataset_id"`
+	Edition    string `avro:"edition"`
+	Version    int32  `avro:"version"`
+	TraceID    string `avro:"trace_id"`
+	Force      bool   `avro:"force"` // import the instance again, deleting its existing nodes, if it has already been imported
+
+	Incremental bool `avro:"incremental"` // import only the dimension options missing from the graph database, if the instance has already been imported
+}
+
+// Repeatable returns true if the event requests to import the instance again, or to import its missing options,
+// which must be done every time it is sent, even if an identical event has already been processed
+func (e NewInstance) Repeatable() bool {
+	return e.Force || e.Incremental
 }
 
-// InstanceCompleted represents a 'Dimensions Inserted' kafka message
+// InstanceCompleted represents a 'Dimensions Inserted' kafka message.
+// The dataset, edition, version, instance type and trace ID fields are optional, and they are empty if the instance or the NewInstance event do not have them.
+// The import statistics are empty if the message has been written by an older version of the service.
 type InstanceCompleted struct {
-	FileURL    string `avro:"file_url"`
+	FileURL        string `avro:"file_url"`
+	InstanceID     string `avro:"instance_id"`
+	DatasetID      string `avro:"dataset_id"`
+	Edition        string `avro:"edition"`
+	Version        int32  `avro:"version"`
+	InstanceType   string `avro:"instance_type"`
+	TraceID        string `avro:"trace_id"`
+	DimensionCount int64  `avro:"dimension_count"` // number of dimensions of the instance
+	OptionCount    int64  `avro:"option_count"`    // number of dimension options of the instance
+
+	DurationMS      int64    `avro:"duration_ms"`      // time taken by the import, in milliseconds
+	CodeLists       []string `avro:"code_lists"`       // sorted IDs of the code lists of the dimensions of the instance
+	OptionsHash     string   `avro:"options_hash"`     // hash of the dimension options of the instance, see report.Report.OptionsHash
+	ImporterVersion string   `avro:"importer_version"` // version of the dimension importer that imported the instance
+
+	Rebuild bool `avro:"rebuild"` // whether the existing nodes of the instance have been deleted to import it again, following a forced import
+}
+
+// InstanceDeleted represents an 'Instance Deleted' kafka message, requesting the removal of the graph data of an instance
+type InstanceDeleted struct {
+	InstanceID string `avro:"instance_id"`
+	TraceID    string `avro:"trace_id"`
+}
+
+// Repeatable returns true, as the graph data of an instance must be removed every time it is deleted, including after it is imported again
+func (e InstanceDeleted) Repeatable() bool {
+	return true
+}
+
+// DimensionsDeleted represents a 'Dimensions Deleted' kafka message, confirming that the graph data of an instance has been removed
+type DimensionsDeleted struct {
+	InstanceID string `avro:"instance_id"`
+	Existed    bool   `avro:"existed"` // whether the instance existed in the graph database before it was deleted
+	TraceID    string `avro:"trace_id"`
+}
+
+// ImportFailed represents a 'Dimensions Import Failed' kafka message, describing why the import of an instance failed
+type ImportFailed struct {
 	InstanceID string `avro:"instance_id"`
+	Stage      string `avro:"stage"`      // stage of the import that was running when it failed
+	ErrorCode  string `avro:"error_code"` // category of the failure, see handler.ClassifyError
+	Error      string `avro:"error"`
+	Retryable  bool   `avro:"retryable"` // whether importing the instance again might succeed
+	Dimension  string `avro:"dimension"` // dimension of the offending option, empty if the failure is not caused by an option
+	Option     string `avro:"option"`    // offending option, empty if the failure is not caused by an option
+	Attempt    int32  `avro:"attempt"`   // number of consecutive failed imports of the instance by the service, including this one
+	TraceID    string `avro:"trace_id"`
+}
+
+// ImportReport represents a 'Dimensions Import Report' kafka message, containing data-quality information about an import
+type ImportReport struct {
+	InstanceID               string                `avro:"instance_id"`
+	Status                   string                `avro:"status"`
+	Error                    string                `avro:"error"`
+	Dimensions               []DimensionReport     `avro:"dimensions"`
+	OptionsWithoutOrder      []string              `avro:"options_without_order"`
+	OrderFallbacks           []OrderFallbackReport `avro:"order_fallbacks"`
+	SkippedCodeRelationships []string              `avro:"skipped_code_relationships"`
+	DuplicateOptions         []string              `avro:"duplicate_options"`
+	Stages                   []StageReport         `avro:"stages"`
+	GraphCalls               int64                 `avro:"graph_calls"`
+
+	Attempt     int32 `avro:"attempt"`     // number of consecutive imports of the instance, including this one
+	Rebuild     bool  `avro:"rebuild"`     // whether the existing nodes of the instance have been deleted to import it again
+	Incremental bool  `avro:"incremental"` // whether only the options missing from the existing instance have been imported
+}
+
+// DimensionReport represents the number of options imported for a dimension
+type DimensionReport struct {
+	Name        string `avro:"name"`
+	OptionCount int64  `avro:"option_count"`
+}
+
+// OrderFallbackReport represents the fallback orderer used for the options of a dimension without a code list order
+type OrderFallbackReport struct {
+	Name     string `avro:"name"`
+	Fallback string `avro:"fallback"`
+}
+
+// StageReport represents the time taken by a stage of the import
+type StageReport struct {
+	Name       string `avro:"name"`
+	DurationMS int64  `avro:"duration_ms"`
 }
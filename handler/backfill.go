@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
+	"github.com/ONSdigital/dp-dimension-importer/model"
+	"github.com/ONSdigital/dp-dimension-importer/report"
+	"github.com/ONSdigital/dp-dimension-importer/store"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// BackfillResult is the outcome of backfilling the node IDs of an instance
+type BackfillResult struct {
+	InstanceID   string   `json:"instance_id"`
+	Options      int      `json:"options"`       // number of dimension options of the instance in dataset API
+	Patched      int      `json:"patched"`       // number of options whose node ID has been patched
+	MissingNodes []string `json:"missing_nodes"` // options without a dimension node in the graph database, which have not been patched
+}
+
+// BackfillNodeIDs patches into dataset API the node IDs of the dimension options of an already imported instance,
+// as read from the graph database by the provided reader. It is meant for instances imported while EnablePatchNodeID was false.
+// The options are read from dataset API one page at a time (see client.DatasetAPI.StreamDimensions), and the node IDs of each page are patched together,
+// only for the options whose node ID differs. No dimensions are inserted: options without a dimension node are listed in the result and left untouched.
+func (hdlr *InstanceEventHandler) BackfillNodeIDs(ctx context.Context, instanceID string, reader store.GraphReader) (*BackfillResult, error) {
+	instance, eTag, err := hdlr.DatasetAPICli.GetInstance(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("dataset api client get instance returned an error: %w", err)
+	}
+	if err := ValidateInstance(instance); err != nil {
+		return nil, err
+	}
+
+	result := &BackfillResult{InstanceID: instanceID, MissingNodes: []string{}}
+	tracker := newPatchTracker(eTag, false)
+	nodeIDsByDimension := map[string]map[string]string{}
+
+	err = hdlr.DatasetAPICli.StreamDimensions(ctx, instanceID, func(dimensions []*model.Dimension) error {
+		if err := ValidateDimensions(dimensions); err != nil {
+			return err
+		}
+		result.Options += len(dimensions)
+
+		updates := []*dataset.OptionUpdate{}
+		for _, d := range dimensions {
+			dimensionID, option := d.DBModel().DimensionID, d.DBModel().Option
+			nodeIDs, found := nodeIDsByDimension[dimensionID]
+			if !found {
+				var err error
+				if nodeIDs, err = reader.GetDimensionNodeIDs(ctx, instanceID, dimensionID); err != nil {
+					return fmt.Errorf("get dimension node ids returned an error: %w", err)
+				}
+				nodeIDsByDimension[dimensionID] = nodeIDs
+			}
+
+			nodeID, found := nodeIDs[option]
+			if !found {
+				result.MissingNodes = append(result.MissingNodes, report.OptionKey(dimensionID, option))
+				continue
+			}
+			if nodeID != d.DBModel().NodeID {
+				updates = append(updates, &dataset.OptionUpdate{Name: dimensionID, Option: option, NodeID: nodeID})
+			}
+		}
+
+		if len(updates) == 0 {
+			return nil
+		}
+		if err := hdlr.patchDimensionOptions(ctx, instanceID, updates, tracker); err != nil {
+			return fmt.Errorf("DatasetAPICli.PatchDimensionOption returned an error: %w", err)
+		}
+		result.Patched += len(updates)
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if len(result.MissingNodes) > 0 {
+		log.Warn(ctx, "some dimension options do not have a node in the graph database, their node id has not been backfilled", log.Data{
+			"instance_id":   instanceID,
+			"missing_nodes": result.MissingNodes,
+		})
+	}
+	log.Info(ctx, "node ids have been backfilled", log.Data{"instance_id": instanceID, "options": result.Options, "patched": result.Patched})
+	return result, nil
+}
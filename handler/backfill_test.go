@@ -0,0 +1,97 @@
+package handler_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
+	"github.com/ONSdigital/dp-dimension-importer/report"
+	"github.com/ONSdigital/dp-dimension-importer/store/storertest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// graphReaderMock returns a graph reader mock whose dimension nodes have the provided node ID for each option
+func graphReaderMock(nodeIDs map[string]string) *storertest.GraphReaderMock {
+	return &storertest.GraphReaderMock{
+		GetDimensionNodeIDsFunc: func(ctx context.Context, instanceID string, dimensionID string) (map[string]string, error) {
+			return nodeIDs, nil
+		},
+	}
+}
+
+func TestInstanceEventHandler_BackfillNodeIDs(t *testing.T) {
+	Convey("Given an instance imported without node IDs, whose dimension nodes exist in the graph database", t, func() {
+		storerMock := storerMockHappy()
+		datasetAPIMock := datasetAPIMockHappy()
+		d1NoNodeID, d2NoNodeID, d3NoNodeID := d1Api, d2Api, d3Api
+		d1NoNodeID.NodeID, d2NoNodeID.NodeID, d3NoNodeID.NodeID = "", "", "3"
+		setPagedDimensions(datasetAPIMock, d1NoNodeID, d2NoNodeID, d3NoNodeID)
+		reader := graphReaderMock(map[string]string{"England": "1", "Wales": "2", "Scotland": "3"})
+		h := setUp(storerMock, datasetAPIMock, completedProducerHappy())
+		h.EnablePatchNodeID = false
+
+		Convey("When its node IDs are backfilled", func() {
+			result, err := h.BackfillNodeIDs(ctx, testInstanceID, reader)
+
+			Convey("Then the options whose node ID differs are patched, one page at a time", func() {
+				So(err, ShouldBeNil)
+				So(result.Options, ShouldEqual, 3)
+				So(result.Patched, ShouldEqual, 2)
+				So(result.MissingNodes, ShouldBeEmpty)
+				calls := datasetAPIMock.PatchInstanceDimensionsCalls()
+				So(calls, ShouldHaveLength, 1)
+				So(calls[0].Updates, ShouldResemble, []*dataset.OptionUpdate{
+					{Name: d1Api.DimensionID, Option: "England", NodeID: "1"},
+					{Name: d2Api.DimensionID, Option: "Wales", NodeID: "2"},
+				})
+			})
+
+			Convey("Then the node IDs of each dimension are only read once", func() {
+				So(reader.GetDimensionNodeIDsCalls(), ShouldHaveLength, 1)
+				So(reader.GetDimensionNodeIDsCalls()[0].DimensionID, ShouldEqual, d1Api.DimensionID)
+			})
+
+			validateNoGraphWrites(storerMock)
+		})
+	})
+
+	Convey("Given an instance with an option that has no dimension node in the graph database", t, func() {
+		storerMock := storerMockHappy()
+		datasetAPIMock := datasetAPIMockHappy()
+		d1NoNodeID := d1Api
+		d1NoNodeID.NodeID = ""
+		setPagedDimensions(datasetAPIMock, d1NoNodeID, d2Api)
+		h := setUp(storerMock, datasetAPIMock, completedProducerHappy())
+
+		Convey("When its node IDs are backfilled", func() {
+			result, err := h.BackfillNodeIDs(ctx, testInstanceID, graphReaderMock(map[string]string{"England": "1"}))
+
+			Convey("Then the option is listed as missing, and not inserted", func() {
+				So(err, ShouldBeNil)
+				So(result.Patched, ShouldEqual, 1)
+				So(result.MissingNodes, ShouldResemble, []string{report.OptionKey(d2Api.DimensionID, "Wales")})
+				So(storerMock.InsertDimensionCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a graph reader that fails", t, func() {
+		datasetAPIMock := datasetAPIMockHappy()
+		setPagedDimensions(datasetAPIMock, d1Api)
+		reader := &storertest.GraphReaderMock{
+			GetDimensionNodeIDsFunc: func(ctx context.Context, instanceID string, dimensionID string) (map[string]string, error) {
+				return nil, errorMock
+			},
+		}
+		h := setUp(storerMockHappy(), datasetAPIMock, completedProducerHappy())
+
+		Convey("When node IDs are backfilled", func() {
+			_, err := h.BackfillNodeIDs(ctx, testInstanceID, reader)
+
+			Convey("Then the error is returned and nothing is patched", func() {
+				So(err, ShouldWrap, errorMock)
+				So(datasetAPIMock.PatchInstanceDimensionsCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+}
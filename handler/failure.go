@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ONSdigital/dp-dimension-importer/client"
+	"github.com/ONSdigital/dp-dimension-importer/event"
+	"github.com/ONSdigital/dp-dimension-importer/report"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Error codes of the import failures, as sent in the ImportFailed events
+const (
+	ErrorCodeInvalidInstance         = "invalid_instance"          // the event or the instance in dataset API has no instance ID
+	ErrorCodeInvalidDimensions       = "invalid_dimensions"        // the instance has no dimensions, or dimensions without an ID
+	ErrorCodeInvalidOptions          = "invalid_options"           // some dimension options are invalid
+	ErrorCodeCSVHeaderMismatch       = "csv_header_mismatch"       // the CSV header of the instance does not match its dimensions
+	ErrorCodeInstanceModified        = "instance_modified"         // the instance has been modified in dataset API while it was being imported
+	ErrorCodeDatasetAPIUnavailable   = "dataset_api_unavailable"   // dataset API could not serve a request
+	ErrorCodeDatasetAPIRejected      = "dataset_api_rejected"      // dataset API rejected a request
+	ErrorCodePatchFailed             = "patch_failed"              // some dimension options could not be patched in dataset API
+	ErrorCodePatchVerification       = "patch_verification_failed" // some patched dimension options have different values in dataset API
+	ErrorCodeTimeout                 = "timeout"                   // a call took longer than its timeout
+	ErrorCodeRebuildNotSupported     = "rebuild_not_supported"     // the instance already exists, and it cannot be deleted to be imported again
+	ErrorCodeIncrementalNotSupported = "incremental_not_supported" // the instance already exists, and its dimension nodes cannot be read to import it incrementally
+	ErrorCodeUnknown                 = "unknown"                   // any other failure, e.g. a graph database error
+)
+
+// stageValidateEvent is the stage of the failures of events that are rejected before their import is started
+const stageValidateEvent = "validate_event"
+
+// FailureProducer Producer kafka messages describing the failed imports of instances.
+type FailureProducer interface {
+	Failed(ctx context.Context, e event.ImportFailed) error
+}
+
+// Failure is the classification of an import error
+type Failure struct {
+	Code      string
+	Retryable bool   // whether importing the instance again might succeed
+	Dimension string // dimension of the first offending option, if the error is caused by dimension options
+	Option    string
+}
+
+// ClassifyError returns the error code of the provided import error, whether it is retryable, and the first offending dimension option, if any.
+// Errors that are not recognised are considered retryable, as most of them are transient graph database or network failures.
+func ClassifyError(err error) Failure {
+	f := Failure{Code: ErrorCodeUnknown, Retryable: true}
+
+	var validationErr *ValidationError
+	var verificationErr *PatchVerificationError
+	var patchErr *client.PatchError
+	isValidationErr := errors.As(err, &validationErr)
+	isVerificationErr := errors.As(err, &verificationErr)
+	isPatchErr := errors.As(err, &patchErr)
+	switch {
+	case isValidationErr && len(validationErr.Options) > 0:
+		f.Dimension, f.Option = validationErr.Options[0].DimensionID, validationErr.Options[0].Option
+	case isVerificationErr && len(verificationErr.Differences) > 0:
+		f.Dimension, f.Option = verificationErr.Differences[0].DimensionID, verificationErr.Differences[0].Option
+	case isPatchErr && len(patchErr.NotPatched) > 0:
+		f.Dimension, f.Option = patchErr.NotPatched[0].Name, patchErr.NotPatched[0].Option
+	}
+
+	var apiErr interface{ Code() int }
+	switch {
+	case isValidationErr:
+		f.Code, f.Retryable = ErrorCodeInvalidOptions, false
+	case errors.Is(err, client.ErrInstanceIDEmpty):
+		f.Code, f.Retryable = ErrorCodeInvalidInstance, false
+	case errors.Is(err, client.ErrDimensionsNil), errors.Is(err, client.ErrDimensionNil), errors.Is(err, client.ErrDimensionIDEmpty):
+		f.Code, f.Retryable = ErrorCodeInvalidDimensions, false
+	case errors.Is(err, ErrCSVHeaderMismatch):
+		f.Code, f.Retryable = ErrorCodeCSVHeaderMismatch, false
+	case errors.Is(err, ErrRebuildNotSupported):
+		f.Code, f.Retryable = ErrorCodeRebuildNotSupported, false
+	case errors.Is(err, ErrIncrementalNotSupported):
+		f.Code, f.Retryable = ErrorCodeIncrementalNotSupported, false
+	case isVerificationErr:
+		f.Code = ErrorCodePatchVerification
+	case errors.Is(err, client.ErrCircuitOpen):
+		f.Code = ErrorCodeDatasetAPIUnavailable
+	case errors.Is(err, context.DeadlineExceeded):
+		f.Code = ErrorCodeTimeout
+	case client.IsConflict(err):
+		f.Code = ErrorCodeInstanceModified
+	case errors.As(err, &apiErr) && client.IsServerFailure(err):
+		f.Code = ErrorCodeDatasetAPIUnavailable
+	case errors.As(err, &apiErr):
+		f.Code, f.Retryable = ErrorCodeDatasetAPIRejected, false
+	case isPatchErr:
+		f.Code = ErrorCodePatchFailed
+	}
+	return f
+}
+
+// sendFailure sends an ImportFailed event describing the provided import error, if a FailureProducer is provided.
+// The stage and attempt of the failure are taken from the report, if any.
+func (hdlr *InstanceEventHandler) sendFailure(ctx context.Context, newInstance event.NewInstance, rep *report.Report, err error) {
+	if hdlr.FailureProducer == nil {
+		return
+	}
+
+	f := ClassifyError(err)
+	e := event.ImportFailed{
+		InstanceID: newInstance.InstanceID,
+		Stage:      stageValidateEvent,
+		ErrorCode:  f.Code,
+		Error:      err.Error(),
+		Retryable:  f.Retryable,
+		Dimension:  f.Dimension,
+		Option:     f.Option,
+		Attempt:    1,
+		TraceID:    newInstance.TraceID,
+	}
+	if rep != nil {
+		e.Stage = rep.CurrentStage()
+		e.Attempt = int32(rep.Attempt())
+	}
+
+	if err := hdlr.FailureProducer.Failed(ctx, e); err != nil {
+		log.Error(ctx, "failed to send import failed event", err, log.Data{"instance_id": newInstance.InstanceID, "package": packageName})
+	}
+}
@@ -0,0 +1,175 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
+	"github.com/ONSdigital/dp-dimension-importer/client"
+	"github.com/ONSdigital/dp-dimension-importer/event"
+	"github.com/ONSdigital/dp-dimension-importer/handler"
+	"github.com/ONSdigital/dp-dimension-importer/mocks"
+	"github.com/ONSdigital/dp-dimension-importer/report"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// datasetAPIError returns the error of a dataset API response with the provided status code
+func datasetAPIError(statusCode int) error {
+	return dataset.NewDatasetAPIResponse(&http.Response{StatusCode: statusCode, Body: io.NopCloser(strings.NewReader(""))}, "/instances")
+}
+
+func TestClassifyError(t *testing.T) {
+	notPatched := []*dataset.OptionUpdate{{Name: "geography", Option: "E92000001"}}
+
+	cases := []struct {
+		description string
+		err         error
+		expected    handler.Failure
+	}{
+		{
+			description: "invalid dimension options",
+			err: fmt.Errorf("wrapped: %w", &handler.ValidationError{Options: []handler.InvalidOption{
+				{DimensionID: "geography", Option: "", Reason: "is empty"}, {DimensionID: "time", Option: "x", Reason: "is empty"},
+			}}),
+			expected: handler.Failure{Code: handler.ErrorCodeInvalidOptions, Dimension: "geography"},
+		},
+		{
+			description: "an event without instance ID",
+			err:         fmt.Errorf("event validation error: %w", client.ErrInstanceIDEmpty),
+			expected:    handler.Failure{Code: handler.ErrorCodeInvalidInstance},
+		},
+		{
+			description: "an instance without dimensions",
+			err:         fmt.Errorf("dimensions validation error: %w", client.ErrDimensionsNil),
+			expected:    handler.Failure{Code: handler.ErrorCodeInvalidDimensions},
+		},
+		{
+			description: "a CSV header mismatch",
+			err:         fmt.Errorf("%w: missing time", handler.ErrCSVHeaderMismatch),
+			expected:    handler.Failure{Code: handler.ErrorCodeCSVHeaderMismatch},
+		},
+		{
+			description: "a patch verification error",
+			err:         &handler.PatchVerificationError{Differences: []handler.PatchDifference{{DimensionID: "geography", Option: "W92000004"}}},
+			expected:    handler.Failure{Code: handler.ErrorCodePatchVerification, Retryable: true, Dimension: "geography", Option: "W92000004"},
+		},
+		{
+			description: "an open circuit breaker",
+			err:         client.ErrCircuitOpen,
+			expected:    handler.Failure{Code: handler.ErrorCodeDatasetAPIUnavailable, Retryable: true},
+		},
+		{
+			description: "a timeout",
+			err:         fmt.Errorf("get instance: %w", context.DeadlineExceeded),
+			expected:    handler.Failure{Code: handler.ErrorCodeTimeout, Retryable: true},
+		},
+		{
+			description: "a patch rejected because the instance has been modified",
+			err:         &client.PatchError{NotPatched: notPatched, Err: datasetAPIError(http.StatusPreconditionFailed)},
+			expected:    handler.Failure{Code: handler.ErrorCodeInstanceModified, Retryable: true, Dimension: "geography", Option: "E92000001"},
+		},
+		{
+			description: "a dataset API server failure",
+			err:         datasetAPIError(http.StatusBadGateway),
+			expected:    handler.Failure{Code: handler.ErrorCodeDatasetAPIUnavailable, Retryable: true},
+		},
+		{
+			description: "a request rejected by dataset API",
+			err:         datasetAPIError(http.StatusNotFound),
+			expected:    handler.Failure{Code: handler.ErrorCodeDatasetAPIRejected},
+		},
+		{
+			description: "a patch that failed with a network error",
+			err:         &client.PatchError{NotPatched: notPatched, Err: errors.New("connection reset")},
+			expected:    handler.Failure{Code: handler.ErrorCodePatchFailed, Retryable: true, Dimension: "geography", Option: "E92000001"},
+		},
+		{
+			description: "any other error",
+			err:         errors.New("graph database error"),
+			expected:    handler.Failure{Code: handler.ErrorCodeUnknown, Retryable: true},
+		},
+	}
+
+	Convey("Given import errors", t, func() {
+		for _, c := range cases {
+			Convey("Then "+c.description+" is classified as "+c.expected.Code, func() {
+				So(handler.ClassifyError(c.err), ShouldResemble, c.expected)
+			})
+		}
+	})
+}
+
+func failureProducerHappy() *mocks.FailureProducerMock {
+	return &mocks.FailureProducerMock{
+		FailedFunc: func(ctx context.Context, e event.ImportFailed) error {
+			return nil
+		},
+	}
+}
+
+func TestInstanceEventHandler_Handle_Failure(t *testing.T) {
+	Convey("Given a handler with a failure producer, a report store and a dataset api that returns a duplicate option", t, func() {
+		datasetAPIMock := datasetAPIMockHappy()
+		datasetAPIMock.GetInstanceDimensionsInBatchesFunc = func(ctx context.Context, serviceAuthToken string, instanceID string, batchSize, maxWorkers int) (dataset.Dimensions, string, error) {
+			return dataset.Dimensions{Items: []dataset.Dimension{d1Api, d2Api, d1Api}}, "", nil
+		}
+		failureProducer := failureProducerHappy()
+		h := setUp(storerMockHappy(), datasetAPIMock, completedProducerHappy())
+		h.FailureProducer = failureProducer
+		h.Reports = report.NewStore(10)
+
+		Convey("When the event is handled twice", func() {
+			err1 := h.Handle(ctx, newInstance)
+			err2 := h.Handle(ctx, newInstance)
+
+			Convey("Then a failed event is sent for each failure, with the consecutive attempt number", func() {
+				So(err1, ShouldNotBeNil)
+				So(err2, ShouldNotBeNil)
+				calls := failureProducer.FailedCalls()
+				So(calls, ShouldHaveLength, 2)
+				So(calls[0].E, ShouldResemble, event.ImportFailed{
+					InstanceID: testInstanceID,
+					Stage:      report.StageGetDimensions,
+					ErrorCode:  handler.ErrorCodeInvalidOptions,
+					Error:      err1.Error(),
+					Dimension:  d1Api.DimensionID,
+					Option:     d1Api.Option,
+					Attempt:    1,
+					TraceID:    testTraceID,
+				})
+				So(calls[1].E.Attempt, ShouldEqual, 2)
+			})
+
+			Convey("Then a successful import is not reported as a failure, and the attempts are counted again after it", func() {
+				datasetAPIMock.GetInstanceDimensionsInBatchesFunc = datasetAPIMockHappy().GetInstanceDimensionsInBatchesFunc
+				So(h.Handle(ctx, newInstance), ShouldBeNil)
+				So(failureProducer.FailedCalls(), ShouldHaveLength, 2)
+				rep, _ := h.Reports.Get(testInstanceID)
+				So(rep.Attempt(), ShouldEqual, 3)
+				So(rep.NextAttempt(), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When an event without instance ID is handled", func() {
+			err := h.Handle(ctx, event.NewInstance{FileURL: fileURL, TraceID: testTraceID})
+
+			Convey("Then a failed event is sent for the event validation stage", func() {
+				So(err, ShouldNotBeNil)
+				calls := failureProducer.FailedCalls()
+				So(calls, ShouldHaveLength, 1)
+				So(calls[0].E, ShouldResemble, event.ImportFailed{
+					Stage:     "validate_event",
+					ErrorCode: handler.ErrorCodeInvalidInstance,
+					Error:     err.Error(),
+					Attempt:   1,
+					TraceID:   testTraceID,
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ONSdigital/dp-dimension-importer/model"
+	"github.com/ONSdigital/dp-dimension-importer/order"
+	"github.com/ONSdigital/dp-dimension-importer/report"
+	"github.com/ONSdigital/dp-dimension-importer/store"
+)
+
+// fallbackOrders holds the orders assigned by the OrderFallbacks to the codes of each code list used by an instance.
+// Its methods can be called on a nil fallbackOrders, in which case no fallback order is found.
+type fallbackOrders struct {
+	orders    map[string]map[string]int // order of each code, by code list ID
+	fallbacks map[string]string         // name of the fallback used, by code list ID
+}
+
+// newFallbackOrders orders the codes of each code list used by the provided dimension options that do not have an order in the provided source with OrderFallbacks.
+// Only the codes without order are ordered, relative to each other, and their fallback orders start after the largest order of the other codes of their code list,
+// so that they never collide with the orders given by the source. As the largest order is only known from all the codes of the code list, all the options of the instance must be provided.
+// It returns nil if no OrderFallbacks are configured.
+func (hdlr *InstanceEventHandler) newFallbackOrders(ctx context.Context, dimensions []*model.Dimension, source order.Source, rep *report.Report) (*fallbackOrders, error) {
+	if len(hdlr.OrderFallbacks) == 0 {
+		return nil, nil
+	}
+
+	codesByCodeListID := map[string][]string{}
+	seen := map[string]map[string]struct{}{}
+	for _, d := range dimensions {
+		codeListID, code := d.CodeListID(), d.DBModel().Option
+		if seen[codeListID] == nil {
+			seen[codeListID] = map[string]struct{}{}
+		}
+		if _, found := seen[codeListID][code]; found {
+			continue
+		}
+		seen[codeListID][code] = struct{}{}
+		codesByCodeListID[codeListID] = append(codesByCodeListID[codeListID], code)
+	}
+
+	f := &fallbackOrders{
+		orders:    make(map[string]map[string]int, len(codesByCodeListID)),
+		fallbacks: make(map[string]string, len(codesByCodeListID)),
+	}
+	_, isGraphSource := source.(store.Storer)
+	for codeListID, codes := range codesByCodeListID {
+		if isGraphSource {
+			rep.GraphCall()
+		}
+		sourceOrders, err := source.GetCodesOrder(ctx, codeListID, codes)
+		if err != nil {
+			return nil, fmt.Errorf("error while attempting to get dimension order using codes: %w", err)
+		}
+		unordered := []string{}
+		offset := 0
+		for _, code := range codes {
+			if o := sourceOrders[code]; o != nil {
+				offset = max(offset, *o+1)
+			} else {
+				unordered = append(unordered, code)
+			}
+		}
+		if len(unordered) == 0 {
+			continue
+		}
+
+		if orders, fallback := hdlr.OrderFallbacks.Order(codeListID, unordered); orders != nil {
+			for code := range orders {
+				orders[code] += offset
+			}
+			f.orders[codeListID] = orders
+			f.fallbacks[codeListID] = fallback
+		}
+	}
+	return f, nil
+}
+
+// get returns the fallback order of the provided code along with the name of the fallback used,
+// or nil if the codes of the code list could not be ordered by any fallback
+func (f *fallbackOrders) get(codeListID, code string) (*int, string) {
+	if f == nil {
+		return nil, ""
+	}
+	o, found := f.orders[codeListID][code]
+	if !found {
+		return nil, ""
+	}
+	return &o, f.fallbacks[codeListID]
+}
+
+// orderSource returns the source of the codes order of the provided pipeline profile:
+// OrderSource for the order-only profile, if configured, and the code lists in the graph database otherwise
+func (hdlr *InstanceEventHandler) orderSource(profile Profile) order.Source {
+	if profile == ProfileOrderOnly && hdlr.OrderSource != nil {
+		return hdlr.OrderSource
+	}
+	return hdlr.Store
+}
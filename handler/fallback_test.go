@@ -0,0 +1,115 @@
+package handler_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
+	"github.com/ONSdigital/dp-dimension-importer/order"
+	"github.com/ONSdigital/dp-dimension-importer/report"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInstanceEventHandler_Handle_OrderFallbacks(t *testing.T) {
+	Convey("Given a handler with the natural fallback orderer and a code list without orders in the graph database", t, func() {
+		storerMock := storerMockHappy()
+		storerMock.GetCodesOrderFunc = func(ctx context.Context, codeListID string, codes []string) (map[string]*int, error) {
+			return map[string]*int{}, nil
+		}
+		datasetAPIMock := datasetAPIMockHappy()
+		h := setUp(storerMock, datasetAPIMock, completedProducerHappy())
+		h.OrderFallbacks = order.Fallbacks{order.Natural{}}
+		h.Reports = report.NewStore(1)
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then no error is returned", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then the options are patched with their order among all the options of the code list, across batches", func() {
+				england, scotland, wales := 0, 1, 2
+				calls := datasetAPIMock.PatchInstanceDimensionsCalls()
+				So(calls, ShouldHaveLength, 2)
+				So(calls[0].Updates, ShouldResemble, []*dataset.OptionUpdate{
+					{Name: d1Api.DimensionID, Option: d1Api.Option, NodeID: d1Api.NodeID, Order: &england},
+					{Name: d2Api.DimensionID, Option: d2Api.Option, NodeID: d2Api.NodeID, Order: &wales},
+				})
+				So(calls[1].Updates, ShouldResemble, []*dataset.OptionUpdate{
+					{Name: d3Api.DimensionID, Option: d3Api.Option, NodeID: d3Api.NodeID, Order: &scotland},
+				})
+			})
+
+			Convey("Then the fallback used is recorded in the report, and no option is reported without order", func() {
+				r, found := h.Reports.Get(testInstanceID)
+				So(found, ShouldBeTrue)
+				So(r.Summary().OrderFallbacks, ShouldResemble, map[string]string{d1Api.DimensionID: order.FallbackNatural})
+				So(r.Summary().OptionsWithoutOrder, ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a handler with the natural fallback orderer and a code list where only some codes have an order in the graph database", t, func() {
+		storerMock := storerMockHappy()
+		storerMock.GetCodesOrderFunc = func(ctx context.Context, codeListID string, codes []string) (map[string]*int, error) {
+			walesOrder := 3
+			orders := map[string]*int{}
+			for _, code := range codes {
+				if code == d2Api.Option {
+					orders[code] = &walesOrder
+				}
+			}
+			return orders, nil
+		}
+		datasetAPIMock := datasetAPIMockHappy()
+		h := setUp(storerMock, datasetAPIMock, completedProducerHappy())
+		h.OrderFallbacks = order.Fallbacks{order.Natural{}}
+		h.Reports = report.NewStore(1)
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then the options without order are ordered relative to each other, after the largest order of the code list", func() {
+				So(err, ShouldBeNil)
+				wales, england, scotland := 3, 4, 5
+				calls := datasetAPIMock.PatchInstanceDimensionsCalls()
+				So(calls, ShouldHaveLength, 2)
+				So(calls[0].Updates, ShouldResemble, []*dataset.OptionUpdate{
+					{Name: d1Api.DimensionID, Option: d1Api.Option, NodeID: d1Api.NodeID, Order: &england},
+					{Name: d2Api.DimensionID, Option: d2Api.Option, NodeID: d2Api.NodeID, Order: &wales},
+				})
+				So(calls[1].Updates, ShouldResemble, []*dataset.OptionUpdate{
+					{Name: d3Api.DimensionID, Option: d3Api.Option, NodeID: d3Api.NodeID, Order: &scotland},
+				})
+			})
+
+			Convey("Then the fallback used is recorded in the report", func() {
+				r, found := h.Reports.Get(testInstanceID)
+				So(found, ShouldBeTrue)
+				So(r.Summary().OrderFallbacks, ShouldResemble, map[string]string{d1Api.DimensionID: order.FallbackNatural})
+				So(r.Summary().OptionsWithoutOrder, ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a handler with a fallback orderer that cannot order the code list", t, func() {
+		storerMock := storerMockHappy()
+		datasetAPIMock := datasetAPIMockHappy()
+		h := setUp(storerMock, datasetAPIMock, completedProducerHappy())
+		h.OrderFallbacks = order.Fallbacks{order.Chronological{}}
+		h.Reports = report.NewStore(1)
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then the options without a code list order are reported without order", func() {
+				So(err, ShouldBeNil)
+				r, found := h.Reports.Get(testInstanceID)
+				So(found, ShouldBeTrue)
+				So(r.Summary().OrderFallbacks, ShouldBeEmpty)
+				So(r.Summary().OptionsWithoutOrder, ShouldResemble, []string{report.OptionKey(d3Api.DimensionID, d3Api.Option)})
+			})
+		})
+	})
+}
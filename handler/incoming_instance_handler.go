@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
@@ -12,15 +13,20 @@ import (
 	"github.com/ONSdigital/dp-dimension-importer/client"
 	"github.com/ONSdigital/dp-dimension-importer/event"
 	"github.com/ONSdigital/dp-dimension-importer/model"
+	"github.com/ONSdigital/dp-dimension-importer/order"
+	"github.com/ONSdigital/dp-dimension-importer/report"
 	"github.com/ONSdigital/dp-dimension-importer/store"
 	"github.com/ONSdigital/log.go/v2/log"
 )
 
-//go:generate moq -out ../mocks/incoming_instance_generated_mocks.go -pkg mocks . CompletedProducer
+//go:generate moq -out ../mocks/incoming_instance_generated_mocks.go -pkg mocks . CompletedProducer ReportProducer FailureProducer DeletedProducer
 
 var (
 	errInstanceExists = errors.New("[handler.InstanceEventHandler] instance already exists")
 	packageName       = "handler.InstanceEventHandler"
+
+	// ErrRebuildNotSupported is returned when an existing instance is forced to be imported again, but its nodes cannot be deleted
+	ErrRebuildNotSupported = errors.New("instance already exists and it cannot be deleted to be imported again")
 )
 
 // CompletedProducer Producer kafka messages for instances that have been successfully processed.
@@ -28,75 +34,386 @@ type CompletedProducer interface {
 	Completed(ctx context.Context, e event.InstanceCompleted) error
 }
 
+// ReportProducer Producer kafka messages containing the data-quality report of processed instances.
+type ReportProducer interface {
+	Report(ctx context.Context, e event.ImportReport) error
+}
+
 // InstanceEventHandler provides functions for handling DimensionsExtractedEvents.
 type InstanceEventHandler struct {
-	Store             store.Storer
-	DatasetAPICli     *client.DatasetAPI
-	Producer          CompletedProducer
-	BatchSize         int
-	EnablePatchNodeID bool
+	Store              store.Storer
+	Deleter            store.InstanceDeleter // deletes the nodes of existing instances forced to be imported again, which fail with ErrRebuildNotSupported if nil
+	Reader             store.GraphReader     // reads back the dimension nodes of existing instances imported incrementally, which fail with ErrIncrementalNotSupported if nil
+	DatasetAPICli      *client.DatasetAPI
+	Producer           CompletedProducer
+	ReportProducer     ReportProducer
+	FailureProducer    FailureProducer // optional producer of an ImportFailed event for each failed import
+	Reports            *report.Store
+	OptionPolicy       OptionPolicy
+	OrderSource        order.Source    // source of the codes order for the order-only profile, the graph database code lists are used if nil
+	OrderFallbacks     order.Fallbacks // orderers for the options without a code list order, which require all the options in memory (StreamDimensions false)
+	BatchSize          int
+	EnablePatchNodeID  bool
+	MaxConflictRetries int              // number of times that a patch rejected because the instance has been modified is retried
+	StreamDimensions   bool             // retrieve and process the dimension options one page at a time, instead of loading all of them in memory
+	PatchVerification  VerificationMode // whether the patched values are read back once the import is done, VerifyOff if empty
+	ImporterVersion    string           // version of the service, sent in the completion events
+
+	InstanceTypeProfiles map[string]Profile // pipeline profile to use for each instance type
+	DefaultProfile       Profile            // pipeline profile to use for instance types without a profile, ProfileGraph if empty
+
+	inProgress int64 // number of events being handled, accessed atomically
 }
 
 // Handle retrieves the dimensions for specified instanceID from the Import API, creates an MyInstance entity for
 // provided instanceID, creates a Dimension entity for each dimension and a relationship to the MyInstance it belongs to
 // and makes a PUT request to the Import API with the database ID of each Dimension entity.
+// The pipeline used for each instance depends on its type: instance types mapped to a different Profile skip some or all of these steps.
+// A data-quality report is generated for every instance that is processed, and it is kept in Reports and sent via ReportProducer, if provided.
+// If the event is ignored, the previous report of the instance is kept instead.
+// Every failure is also described by an ImportFailed event sent via FailureProducer, if provided.
+// If the instance node already exists, the event is ignored, unless it is forced, in which case the existing nodes of the instance are deleted
+// and it is imported again, or it is incremental, in which case only the dimension options without a dimension node are imported.
 func (hdlr *InstanceEventHandler) Handle(ctx context.Context, newInstance event.NewInstance) error {
 	if err := hdlr.Validate(newInstance); err != nil {
+		hdlr.sendFailure(ctx, newInstance, nil, err)
 		return err
 	}
+	atomic.AddInt64(&hdlr.inProgress, 1)
+	defer atomic.AddInt64(&hdlr.inProgress, -1)
+
+	rep := report.New(newInstance.InstanceID)
+	var previous *report.Report
+	if hdlr.Reports != nil {
+		if previous, _ = hdlr.Reports.Get(newInstance.InstanceID); previous != nil {
+			rep.SetAttempt(previous.NextAttempt())
+		}
+		hdlr.Reports.Put(rep)
+	}
 
+	imported, err := hdlr.handle(ctx, newInstance, rep)
+	if err != nil {
+		rep.Fail(err)
+		hdlr.sendFailure(ctx, newInstance, rep, err)
+	} else if imported {
+		rep.Complete()
+	}
+
+	// an instance that already existed has not been imported by this event, so there is nothing to report,
+	// and the report of its previous import is kept
+	if imported || err != nil {
+		hdlr.sendReport(ctx, rep)
+	} else if hdlr.Reports != nil {
+		hdlr.Reports.Restore(rep, previous)
+	}
+	return err
+}
+
+// ImportsInProgress returns the number of instance events that are being handled
+func (hdlr *InstanceEventHandler) ImportsInProgress() int {
+	return int(atomic.LoadInt64(&hdlr.inProgress))
+}
+
+// handle performs the import of the provided instance, recording the data-quality information in the provided report.
+// It returns false if the instance already existed and the event has been ignored.
+func (hdlr *InstanceEventHandler) handle(ctx context.Context, newInstance event.NewInstance, rep *report.Report) (bool, error) {
 	logData := log.Data{"instance_id": newInstance.InstanceID, "package": packageName}
 	log.Info(ctx, "handling new instance event", logData)
 	start := time.Now()
 
-	// retrieve the dimensions from dataset API
-	dimensions, err := hdlr.DatasetAPICli.GetDimensions(ctx, newInstance.InstanceID, headers.IfMatchAnyETag)
-	if err != nil {
-		return fmt.Errorf("DatasetAPICli.GetDimensions returned an error: %w", err)
-	}
-	if err := ValidateDimensions(dimensions); err != nil {
-		return err
+	// retrieve all the dimensions from dataset API, unless they are streamed while they are processed
+	var dimensions []*model.Dimension
+	var dimensionsETag string
+	var err error
+	if !hdlr.StreamDimensions {
+		if dimensions, dimensionsETag, err = hdlr.getDimensions(ctx, newInstance.InstanceID, rep); err != nil {
+			return false, err
+		}
+		logData["dimensions_count"] = len(dimensions)
 	}
-	logData["dimensions_count"] = len(dimensions)
 
 	// retrieve the CSV header from the dataset API and attach it to the instance node allowing it to be used after import.
-	instance, err := hdlr.DatasetAPICli.GetInstance(ctx, newInstance.InstanceID)
+	stageDone := rep.StartStage(report.StageGetInstance)
+	instance, instanceETag, err := hdlr.DatasetAPICli.GetInstance(ctx, newInstance.InstanceID)
+	stageDone()
 	if err != nil {
-		return fmt.Errorf("dataset api client get instance returned an error: %w", err)
+		return false, fmt.Errorf("dataset api client get instance returned an error: %w", err)
+	}
+
+	// patches are only applied if the instance has not changed since its dimensions were retrieved
+	tracker := newPatchTracker(dimensionsETag, hdlr.verifyPatchesEnabled())
+	if dimensionsETag == "" {
+		tracker.SetETag(instanceETag)
 	}
 	if err := ValidateInstance(instance); err != nil {
-		return err
+		return false, err
+	}
+	profile := hdlr.ProfileFor(instance.Type())
+	logData["profile"] = profile
+	for k, v := range instance.LogData() {
+		logData[k] = v
 	}
+	log.Info(ctx, "retrieved instance from dataset api", logData)
 
-	// create instance node to the DB if it does not exist already
-	if err = hdlr.createInstanceNode(ctx, instance); err != nil {
+	// the options without a code list order are ordered after the other options of their code list, which are only known if they are not streamed
+	var fallbacks *fallbackOrders
+	if profile != ProfileNoop {
+		if fallbacks, err = hdlr.newFallbackOrders(ctx, dimensions, hdlr.orderSource(profile), rep); err != nil {
+			return false, err
+		}
+	}
+
+	switch profile {
+	case ProfileNoop:
+		log.Info(ctx, "pipeline profile does not import dimensions, only the completion event will be produced", logData)
+	case ProfileOrderOnly:
+		patch := func(dimensions []*model.Dimension) error {
+			return hdlr.patchOrders(ctx, instance, dimensions, fallbacks, tracker, rep)
+		}
+		if hdlr.StreamDimensions {
+			err = hdlr.streamDimensions(ctx, instance, nil, rep, patch)
+		} else {
+			err = patch(dimensions)
+		}
+		if err != nil {
+			return true, err
+		}
+	default:
+		imported, err := hdlr.importToGraph(ctx, instance, newInstance, dimensions, fallbacks, tracker, rep)
 		if err == errInstanceExists {
 			log.Info(ctx, "an instance with this id already exists, ignoring this event", logData)
-			return nil // ignoring
+			return false, nil // ignoring
+		}
+		if !imported || err != nil {
+			return imported, err
 		}
-		return err
 	}
 
-	// insertDimensions to graph db and mongoDB
-	if err := hdlr.insertDimensions(ctx, instance, dimensions); err != nil {
-		return err
+	if profile != ProfileNoop && hdlr.verifyPatchesEnabled() {
+		stageDone = rep.StartStage(report.StageVerifyPatches)
+		err = hdlr.verifyPatches(ctx, newInstance.InstanceID, tracker)
+		stageDone()
+		if err != nil {
+			return true, err
+		}
 	}
 
-	if err := hdlr.createObservationConstraint(ctx, instance); err != nil {
-		return err
+	instanceProcessed := event.InstanceCompleted{
+		FileURL:      newInstance.FileURL,
+		InstanceID:   newInstance.InstanceID,
+		DatasetID:    instance.DatasetID(),
+		Edition:      instance.Edition(),
+		Version:      int32(instance.Version()),
+		InstanceType: instance.Type(),
+		TraceID:      newInstance.TraceID,
+
+		CodeLists:       rep.CodeLists(),
+		OptionsHash:     rep.OptionsHash(),
+		ImporterVersion: hdlr.ImporterVersion,
+		Rebuild:         rep.IsRebuild(),
+	}
+	for _, count := range rep.Summary().OptionsPerDimension {
+		instanceProcessed.DimensionCount++
+		instanceProcessed.OptionCount += int64(count)
 	}
 
-	instanceProcessed := event.InstanceCompleted(newInstance)
+	instanceProcessed.DurationMS = time.Since(start).Milliseconds()
 
 	// produce the kafka message to notify that the dimensions have been successfully imported
-	if err := hdlr.Producer.Completed(ctx, instanceProcessed); err != nil {
-		return fmt.Errorf("Producer.Completed returned an error: %w", err)
+	stageDone = rep.StartStage(report.StageProduceCompleted)
+	err = hdlr.Producer.Completed(ctx, instanceProcessed)
+	stageDone()
+	if err != nil {
+		return true, fmt.Errorf("Producer.Completed returned an error: %w", err)
+	}
+
+	logData["processing_time"] = time.Since(start).Seconds()
+	log.Info(ctx, "instance processing completed successfully", logData)
+	return true, nil
+}
+
+// importToGraph creates the instance node, the dimension nodes and the observation constraint in the graph database,
+// and patches the order and node ID of the dimension options in dataset API.
+// If StreamDimensions is true, the provided dimensions are ignored and they are streamed from dataset API once the instance node has been created.
+// If the instance node already exists and the event is incremental, only the dimension options without a dimension node are inserted and patched.
+// It returns false if nothing has been imported, and errInstanceExists if the instance node already existed and the event is neither forced nor incremental.
+func (hdlr *InstanceEventHandler) importToGraph(ctx context.Context, instance *model.Instance, newInstance event.NewInstance, dimensions []*model.Dimension, fallbacks *fallbackOrders, tracker *patchTracker, rep *report.Report) (bool, error) {
+	// the CSV header is stored in the instance node, so it must match the dimensions
+	header, err := newCSVHeaderValidator(ctx, instance)
+	if err != nil {
+		return false, err
+	}
+	if !hdlr.StreamDimensions {
+		header.add(dimensions)
+		if err := header.validate(true); err != nil {
+			return false, err
+		}
+	}
+
+	// create instance node to the DB if it does not exist already, or if the import is forced
+	stageDone := rep.StartStage(report.StageCreateInstance)
+	err = hdlr.createInstanceNode(ctx, instance, newInstance.Force, rep)
+	stageDone()
+	var existing *existingNodes
+	switch {
+	case err == errInstanceExists && newInstance.Incremental:
+		if hdlr.Reader == nil {
+			return false, ErrIncrementalNotSupported
+		}
+		log.Info(ctx, "an instance with this id already exists, only its missing dimension options will be imported", log.Data{"instance_id": newInstance.InstanceID, "package": packageName})
+		existing = &existingNodes{}
+		rep.Incremental()
+	case err != nil:
+		return false, err
+	}
+
+	// insertDimensions to graph db and mongoDB
+	cache := make(map[string]string)
+	cacheMutex := &sync.Mutex{}
+	insert := func(dimensions []*model.Dimension) error {
+		stageDone := rep.StartStage(report.StageInsertDimensions)
+		defer stageDone()
+		if existing != nil {
+			var err error
+			if dimensions, err = hdlr.missingDimensions(ctx, instance.DBModel().InstanceID, dimensions, existing, rep); err != nil {
+				return err
+			}
+			rep.InsertedOptions(len(dimensions))
+		}
+		return hdlr.insertDimensions(ctx, instance, dimensions, cache, cacheMutex, fallbacks, tracker, rep)
+	}
+	if hdlr.StreamDimensions {
+		err = hdlr.streamDimensions(ctx, instance, header, rep, insert)
+	} else {
+		err = insert(dimensions)
+	}
+	if err != nil {
+		return true, err
+	}
+	if existing != nil {
+		return true, nil // the dimensions and the observation constraint of an existing instance have already been created
+	}
+
+	stageDone = rep.StartStage(report.StageInsertDimensions)
+	err = hdlr.addDimensions(ctx, instance, rep)
+	stageDone()
+	if err != nil {
+		return true, err
+	}
+
+	stageDone = rep.StartStage(report.StageCreateConstraint)
+	err = hdlr.createObservationConstraint(ctx, instance, rep)
+	stageDone()
+	if err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// patchOrders patches the order of the dimension options in dataset API, in batches of size BatchSize, without any graph database writes.
+// Orders are obtained from OrderSource, or from the code lists in the graph database if no OrderSource is configured.
+func (hdlr *InstanceEventHandler) patchOrders(ctx context.Context, instance *model.Instance, dimensions []*model.Dimension, fallbacks *fallbackOrders, tracker *patchTracker, rep *report.Report) error {
+	stageDone := rep.StartStage(report.StagePatchOrders)
+	defer stageDone()
+
+	source := hdlr.orderSource(ProfileOrderOnly)
+	for start := 0; start < len(dimensions); start += hdlr.BatchSize {
+		end := min(start+hdlr.BatchSize, len(dimensions))
+		if err := hdlr.setOrderAndNodeIDs(ctx, instance.DBModel().InstanceID, dimensions[start:end], source, fallbacks, false, tracker, rep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getDimensions retrieves all the dimension options of the instance from dataset API, along with the eTag of the instance,
+// validates them and records them in the report
+func (hdlr *InstanceEventHandler) getDimensions(ctx context.Context, instanceID string, rep *report.Report) ([]*model.Dimension, string, error) {
+	stageDone := rep.StartStage(report.StageGetDimensions)
+	dimensions, eTag, err := hdlr.DatasetAPICli.GetDimensions(ctx, instanceID, headers.IfMatchAnyETag)
+	stageDone()
+	if err != nil {
+		return nil, "", fmt.Errorf("DatasetAPICli.GetDimensions returned an error: %w", err)
+	}
+	if err := ValidateDimensions(dimensions); err != nil {
+		return nil, "", err
+	}
+	for _, d := range dimensions {
+		rep.AddOption(d.DBModel().DimensionID, d.DBModel().Option)
+		rep.AddCodeList(d.CodeListID())
 	}
+	if err := ValidateDimensionOptions(dimensions, hdlr.OptionPolicy); err != nil {
+		return nil, "", err
+	}
+	return dimensions, eTag, nil
+}
 
-	log.Info(ctx, "instance processing completed successfully", log.Data{"package": packageName, "processing_time": time.Since(start).Seconds()})
+// streamDimensions retrieves the dimension options of the instance from dataset API one page at a time,
+// validating each page and recording it in the report before calling process with it. Duplicate options are detected across pages.
+// If header is not nil, the dimensions of each page are checked against it, and once all the pages have been processed,
+// every dimension in the header must have had options.
+func (hdlr *InstanceEventHandler) streamDimensions(ctx context.Context, instance *model.Instance, header *csvHeaderValidator, rep *report.Report, process func(dimensions []*model.Dimension) error) error {
+	instanceID := instance.DBModel().InstanceID
+	options := newOptionValidator(hdlr.OptionPolicy)
+	count := 0
+
+	processPage := func(dimensions []*model.Dimension) error {
+		if err := ValidateDimensions(dimensions); err != nil {
+			return err
+		}
+		for _, d := range dimensions {
+			rep.AddOption(d.DBModel().DimensionID, d.DBModel().Option)
+			rep.AddCodeList(d.CodeListID())
+		}
+		if err := options.validate(dimensions); err != nil {
+			return err
+		}
+		if header != nil {
+			header.add(dimensions)
+			if err := header.validate(false); err != nil {
+				return err
+			}
+		}
+		count += len(dimensions)
+		return process(dimensions)
+	}
+
+	var processErr error
+	fetchStart := time.Now()
+	err := hdlr.DatasetAPICli.StreamDimensions(ctx, instanceID, func(dimensions []*model.Dimension) error {
+		rep.StageCompleted(report.StageGetDimensions, time.Since(fetchStart))
+		defer func() { fetchStart = time.Now() }()
+		processErr = processPage(dimensions)
+		return processErr
+	})
+	if processErr != nil {
+		return processErr
+	}
+	if err != nil {
+		return fmt.Errorf("DatasetAPICli.StreamDimensions returned an error: %w", err)
+	}
+	if count == 0 {
+		return ValidateDimensions(nil)
+	}
+
+	log.Info(ctx, "all dimension options have been streamed from dataset api", log.Data{"instance_id": instanceID, "dimensions_count": count})
+	if header != nil {
+		return header.validate(true)
+	}
 	return nil
 }
 
+// sendReport sends the provided report via the ReportProducer, if one has been configured.
+// Failing to send a report is logged, but it does not fail the import.
+func (hdlr *InstanceEventHandler) sendReport(ctx context.Context, rep *report.Report) {
+	if hdlr.ReportProducer == nil {
+		return
+	}
+	if err := hdlr.ReportProducer.Report(ctx, rep.Event()); err != nil {
+		log.Error(ctx, "failed to send import report", err, log.Data{"instance_id": rep.InstanceID(), "package": packageName})
+	}
+}
+
 func (hdlr *InstanceEventHandler) Validate(newInstance event.NewInstance) error {
 	if hdlr.DatasetAPICli == nil {
 		return fmt.Errorf("event validation error: %w", client.ErrNoDatasetAPI)
@@ -136,10 +453,9 @@ func ValidateDimensions(dimensions []*model.Dimension) error {
 // for all the provided dimensions, in batches of size BatchSize. For each batch:
 // - we trigger BatchSize go-routines, each one will insert a dimension node to the graph database
 // - when all go-routines finish their execution, we perform one patch call to dataset api to update the order and node_id values
-// Once all batches have been processed, a final AddDimensions call is performed
-func (hdlr *InstanceEventHandler) insertDimensions(ctx context.Context, instance *model.Instance, dimensions []*model.Dimension) error {
-	cache := make(map[string]string)
-	cacheMutex := &sync.Mutex{}
+// The provided cache of dimension nodes must be shared by all the calls for the same instance.
+// Once all the dimensions of the instance have been inserted, a final call to addDimensions must be performed
+func (hdlr *InstanceEventHandler) insertDimensions(ctx context.Context, instance *model.Instance, dimensions []*model.Dimension, cache map[string]string, cacheMutex *sync.Mutex, fallbacks *fallbackOrders, tracker *patchTracker, rep *report.Report) error {
 	wg := &sync.WaitGroup{}
 	problem := make(chan error, len(dimensions))
 
@@ -167,7 +483,7 @@ func (hdlr *InstanceEventHandler) insertDimensions(ctx context.Context, instance
 			wg.Add(1)
 			go func(d *model.Dimension) {
 				defer wg.Done()
-				hdlr.insertDimension(ctx, cache, cacheMutex, instance, d, problem)
+				hdlr.insertDimension(ctx, cache, cacheMutex, instance, d, problem, rep)
 			}(dimension)
 		}
 
@@ -177,7 +493,7 @@ func (hdlr *InstanceEventHandler) insertDimensions(ctx context.Context, instance
 		}
 
 		// set dimension options' order and nodeID for the current batch (one call per batch)
-		if err := hdlr.SetOrderAndNodeIDs(ctx, instance.DBModel().InstanceID, dimensionsBatch); err != nil {
+		if err := hdlr.setOrderAndNodeIDs(ctx, instance.DBModel().InstanceID, dimensionsBatch, hdlr.Store, fallbacks, hdlr.EnablePatchNodeID, tracker, rep); err != nil {
 			return err
 		}
 		return nil
@@ -203,7 +519,12 @@ func (hdlr *InstanceEventHandler) insertDimensions(ctx context.Context, instance
 		}
 	}
 
-	// Add dimensions to graph database
+	return nil
+}
+
+// addDimensions adds the dimensions of the instance to the graph database
+func (hdlr *InstanceEventHandler) addDimensions(ctx context.Context, instance *model.Instance, rep *report.Report) error {
+	rep.GraphCall()
 	if err := hdlr.Store.AddDimensions(ctx, instance.DBModel().InstanceID, instance.DBModel().Dimensions); err != nil {
 		return fmt.Errorf("AddDimensions returned an error: %w", err)
 	}
@@ -215,6 +536,13 @@ func (hdlr *InstanceEventHandler) insertDimensions(ctx context.Context, instance
 // and patches the existing dimension options in dataset API (updating node_id and order values)
 // this method assumes that valid non-nil values are provided (have been created or validated by caller)
 func (hdlr *InstanceEventHandler) SetOrderAndNodeIDs(ctx context.Context, instanceID string, dimensions []*model.Dimension) error {
+	return hdlr.setOrderAndNodeIDs(ctx, instanceID, dimensions, hdlr.Store, nil, hdlr.EnablePatchNodeID, nil, nil)
+}
+
+// setOrderAndNodeIDs implements SetOrderAndNodeIDs, obtaining the codes order from the provided source and only patching node IDs if patchNodeID is true.
+// Options without an order from the source are given their fallback order, if any.
+// Any graph calls, fallbacks used and options without order are recorded in the provided report
+func (hdlr *InstanceEventHandler) setOrderAndNodeIDs(ctx context.Context, instanceID string, dimensions []*model.Dimension, source order.Source, fallbacks *fallbackOrders, patchNodeID bool, tracker *patchTracker, rep *report.Report) error {
 	// get a map of codes by codelistID
 	codesByCodelistID := map[string][]string{}
 	for _, d := range dimensions {
@@ -224,8 +552,12 @@ func (hdlr *InstanceEventHandler) SetOrderAndNodeIDs(ctx context.Context, instan
 
 	// get a map of orders by code (one call to dp-graph per codeListID)
 	orderByCode := map[string]*int{}
+	_, isGraphSource := source.(store.Storer)
 	for codeListID, codes := range codesByCodelistID {
-		o, err := hdlr.Store.GetCodesOrder(ctx, codeListID, codes)
+		if isGraphSource {
+			rep.GraphCall()
+		}
+		o, err := source.GetCodesOrder(ctx, codeListID, codes)
 		if err != nil {
 			err = fmt.Errorf("error while attempting to get dimension order using codes: %w", err)
 			log.Error(ctx, "error in setOrderAndNodeIDs while getting orders from the graph database", err, log.Data{
@@ -244,10 +576,18 @@ func (hdlr *InstanceEventHandler) SetOrderAndNodeIDs(ctx context.Context, instan
 	updates := []*dataset.OptionUpdate{}
 	for _, d := range dimensions {
 		nodeID := ""
-		if hdlr.EnablePatchNodeID {
+		if patchNodeID {
 			nodeID = d.DBModel().NodeID
 		}
 		order := orderByCode[d.DBModel().Option]
+		if order == nil {
+			var fallback string
+			if order, fallback = fallbacks.get(d.CodeListID(), d.DBModel().Option); order != nil {
+				rep.OrderFallback(d.DBModel().DimensionID, fallback)
+			} else {
+				rep.OptionWithoutOrder(d.DBModel().DimensionID, d.DBModel().Option)
+			}
+		}
 
 		if nodeID == "" && order == nil {
 			continue // no update for the current dimension
@@ -267,22 +607,96 @@ func (hdlr *InstanceEventHandler) SetOrderAndNodeIDs(ctx context.Context, instan
 	}
 
 	// Send a patch to dataset api with all the updates in a single call
-	// so that the mongodb lock will be acquired only once per batch.
+	// so that the mongodb lock will be acquired only once per batch, unless the updates exceed the dataset API client patch limits.
 	// The reason is that releasing a lock has been observed in 'develop' environment to take about 40 or more milliseconds.
-	if _, err := hdlr.DatasetAPICli.PatchDimensionOption(ctx, instanceID, updates); err != nil {
-		err = fmt.Errorf("DatasetAPICli.PatchDimensionOption returned an error: %w", err)
-		log.Error(ctx, "patch error in setOrderAndNodeIDs", err, log.Data{
+	if err := hdlr.patchDimensionOptions(ctx, instanceID, updates, tracker); err != nil {
+		logData := log.Data{
 			"instance_id": instanceID,
-			"updates":     updates})
+			"updates":     updates,
+		}
+		var patchErr *client.PatchError
+		if errors.As(err, &patchErr) {
+			logData["patched_options"] = client.OptionKeys(patchErr.Patched)
+			logData["not_patched_options"] = client.OptionKeys(patchErr.NotPatched)
+		}
+		err = fmt.Errorf("DatasetAPICli.PatchDimensionOption returned an error: %w", err)
+		log.Error(ctx, "patch error in setOrderAndNodeIDs", err, logData)
 		return err
 	}
 	return nil
 }
 
+// patchDimensionOptions patches the provided updates in dataset API with the latest known eTag of the instance, keeping track of the new eTag
+// and of the patched values.
+// If the instance has been modified since its eTag was obtained (409 or 412 response), the options are reloaded from dataset API
+// and the patch is retried, up to MaxConflictRetries times, only for the options that still exist and were not already patched.
+func (hdlr *InstanceEventHandler) patchDimensionOptions(ctx context.Context, instanceID string, updates []*dataset.OptionUpdate, tracker *patchTracker) error {
+	for attempt := 0; ; attempt++ {
+		newETag, err := hdlr.DatasetAPICli.PatchDimensionOption(ctx, instanceID, tracker.ETag(), updates)
+		if err == nil {
+			tracker.SetETag(newETag)
+			tracker.Record(updates)
+			return nil
+		}
+		var patchErr *client.PatchError
+		if errors.As(err, &patchErr) {
+			tracker.Record(patchErr.Patched)
+		}
+		if !client.IsConflict(err) || attempt >= hdlr.MaxConflictRetries {
+			return err
+		}
+		if patchErr != nil {
+			updates = patchErr.NotPatched // only the updates that were not patched need to be retried
+		}
+
+		log.Warn(ctx, "instance has been modified during the import, reloading its dimension options", log.Data{
+			"instance_id": instanceID,
+			"attempt":     attempt + 1,
+		})
+		if updates, err = hdlr.reloadUpdates(ctx, instanceID, updates, tracker); err != nil {
+			return err
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+	}
+}
+
+// reloadUpdates obtains the current dimension options of the instance and its eTag from dataset API,
+// returning the provided updates whose options still exist. The patch tracker is updated with the reloaded eTag.
+func (hdlr *InstanceEventHandler) reloadUpdates(ctx context.Context, instanceID string, updates []*dataset.OptionUpdate, tracker *patchTracker) ([]*dataset.OptionUpdate, error) {
+	dimensions, newETag, err := hdlr.DatasetAPICli.GetDimensions(ctx, instanceID, headers.IfMatchAnyETag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reload dimension options after a conflict: %w", err)
+	}
+
+	existing := make(map[string]struct{}, len(dimensions))
+	for _, d := range dimensions {
+		existing[optionKey(d.DBModel().DimensionID, d.DBModel().Option)] = struct{}{}
+	}
+
+	reloaded := make([]*dataset.OptionUpdate, 0, len(updates))
+	for _, u := range updates {
+		if _, found := existing[optionKey(u.Name, u.Option)]; !found {
+			log.Warn(ctx, "dimension option no longer exists, it will not be patched", log.Data{
+				"instance_id":  instanceID,
+				"dimension_id": u.Name,
+				"option":       u.Option,
+			})
+			continue
+		}
+		reloaded = append(reloaded, u)
+	}
+
+	tracker.SetETag(newETag)
+	return reloaded, nil
+}
+
 // insertDimension inserts the dimension to the graph database
 // and creates the code relationship if DimensionID is time
 // this method assumes that valid non-nil values are provided (have been created or validated by caller)
-func (hdlr *InstanceEventHandler) insertDimension(ctx context.Context, cache map[string]string, cacheMutex *sync.Mutex, instance *model.Instance, d *model.Dimension, problem chan error) {
+func (hdlr *InstanceEventHandler) insertDimension(ctx context.Context, cache map[string]string, cacheMutex *sync.Mutex, instance *model.Instance, d *model.Dimension, problem chan error, rep *report.Report) {
+	rep.GraphCall()
 	dbDimension, err := hdlr.Store.InsertDimension(ctx, cache, cacheMutex, instance.DBModel().InstanceID, d.DBModel())
 	if err != nil {
 		err = fmt.Errorf("error while attempting to insert a dimension to the graph database: %w", err)
@@ -293,33 +707,62 @@ func (hdlr *InstanceEventHandler) insertDimension(ctx context.Context, cache map
 
 	// todo: remove this temp hack once the time codelist / input data has been fixed.
 	if dbDimension.DimensionID != "time" {
+		rep.GraphCall()
 		if err = hdlr.Store.CreateCodeRelationship(context.Background(), instance.DBModel().InstanceID, d.CodeListID(), dbDimension.Option); err != nil {
 			err = fmt.Errorf("error attempting to create relationship to code: %w", err)
 			log.Error(ctx, "error attempting to create relationship to code", err, log.Data{"instance_id": instance.DBModel().InstanceID, "dimension_id": dbDimension.DimensionID})
 			problem <- err
 			return
 		}
+	} else {
+		rep.SkippedCodeRelationship(dbDimension.DimensionID, dbDimension.Option)
 	}
 }
 
-func (hdlr *InstanceEventHandler) createInstanceNode(ctx context.Context, instance *model.Instance) error {
+// createInstanceNode creates the instance node, returning errInstanceExists if it already exists.
+// If force is true, an existing instance node is deleted along with its dimension nodes, and the instance is recorded as a rebuild in the report.
+func (hdlr *InstanceEventHandler) createInstanceNode(ctx context.Context, instance *model.Instance, force bool, rep *report.Report) error {
+	rep.GraphCall()
 	exists, err := hdlr.Store.InstanceExists(ctx, instance.DBModel().InstanceID)
 	if err != nil {
 		return fmt.Errorf("instance exists check returned an error: %w", err)
 	}
 
 	if exists {
-		return errInstanceExists
+		if !force {
+			return errInstanceExists
+		}
+		if hdlr.Deleter == nil {
+			return ErrRebuildNotSupported
+		}
+		log.Info(ctx, "an instance with this id already exists, deleting it to import it again", log.Data{"instance_id": instance.DBModel().InstanceID, "package": packageName})
+		rep.GraphCall()
+		if err = hdlr.Deleter.DeleteInstance(ctx, instance.DBModel().InstanceID); err != nil {
+			return fmt.Errorf("delete instance returned an error: %w", err)
+		}
+		rep.Rebuilt()
 	}
 
+	rep.GraphCall()
 	if err = hdlr.Store.CreateInstance(ctx, instance.DBModel().InstanceID, instance.DBModel().CSVHeader); err != nil {
 		return fmt.Errorf("create instance returned an error: %w", err)
 	}
 
+	// attach the dataset version details to the instance node, if the store supports it
+	versionStore, ok := hdlr.Store.(store.VersionDetailsStorer)
+	if !ok || instance.DatasetID() == "" {
+		return nil
+	}
+	rep.GraphCall()
+	if err = versionStore.AddVersionDetailsToInstance(ctx, instance.DBModel().InstanceID, instance.DatasetID(), instance.Edition(), instance.Version()); err != nil {
+		return fmt.Errorf("add version details to instance returned an error: %w", err)
+	}
+
 	return nil
 }
 
-func (hdlr *InstanceEventHandler) createObservationConstraint(ctx context.Context, instance *model.Instance) error {
+func (hdlr *InstanceEventHandler) createObservationConstraint(ctx context.Context, instance *model.Instance, rep *report.Report) error {
+	rep.GraphCall()
 	if err := hdlr.Store.CreateInstanceConstraint(ctx, instance.DBModel().InstanceID); err != nil {
 		return fmt.Errorf("error while attempting to add the unique observation constraint: %w", err)
 	}
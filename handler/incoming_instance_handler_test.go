@@ -2,24 +2,35 @@ package handler_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"strings"
 	"sync"
 	"testing"
 
 	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
+	"github.com/ONSdigital/dp-api-clients-go/v2/headers"
 	"github.com/ONSdigital/dp-dimension-importer/client"
 	"github.com/ONSdigital/dp-dimension-importer/event"
 	"github.com/ONSdigital/dp-dimension-importer/handler"
 	"github.com/ONSdigital/dp-dimension-importer/mocks"
 	"github.com/ONSdigital/dp-dimension-importer/model"
+	"github.com/ONSdigital/dp-dimension-importer/report"
 	"github.com/ONSdigital/dp-dimension-importer/store/storertest"
 	"github.com/ONSdigital/dp-graph/v2/models"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
 const (
-	testBatchSize = 2
+	testBatchSize    = 2
+	testDatasetID    = "cpih01"
+	testEdition      = "2021"
+	testVersion      = 1
+	testInstanceType = "v4"
+	testTraceID      = "trace1"
 )
 
 var ctx = context.Background()
@@ -71,24 +82,47 @@ var (
 	instanceAPI = dataset.Instance{
 		Version: dataset.Version{
 			ID:        testInstanceID,
-			CSVHeader: []string{"the", "CSV", "header"},
+			CSVHeader: []string{"V4_0", "geography_code", "Geography"},
+			Edition:   testEdition,
+			Version:   testVersion,
+			Links:     dataset.Links{Dataset: dataset.Link{ID: testDatasetID}},
 		},
 	}
+	instanceAPIBytes, _ = json.Marshal(struct {
+		dataset.Instance
+		Type string `json:"type"`
+	}{instanceAPI, testInstanceType})
 	instance = model.NewInstance(&instanceAPI)
 
 	newInstance = event.NewInstance{
 		InstanceID: testInstanceID,
 		FileURL:    fileURL,
+		TraceID:    testTraceID,
 	}
 
 	instanceCompleted = event.InstanceCompleted{
-		FileURL:    fileURL,
-		InstanceID: testInstanceID,
+		FileURL:        fileURL,
+		InstanceID:     testInstanceID,
+		DatasetID:      testDatasetID,
+		Edition:        testEdition,
+		Version:        testVersion,
+		InstanceType:   testInstanceType,
+		TraceID:        testTraceID,
+		DimensionCount: 1,
+		OptionCount:    3,
+		CodeLists:      []string{testCodeListID},
+		OptionsHash:    "4d2bed3a6c94ce107d9689303b9b7163cb670f585602414ffda41374d4a75996", // SHA-256 of the sorted option keys of d1, d2 and d3
 	}
 
 	errorMock = errors.New("mock error")
 )
 
+// withoutDuration returns the provided completion event without its duration, which depends on the time taken by the test
+func withoutDuration(e event.InstanceCompleted) event.InstanceCompleted {
+	e.DurationMS = 0
+	return e
+}
+
 // validateDatastGetSuccessful checks that GetInstance and GetInstanceDimensionsInBatches are called exactly once with the expected paramters
 func validateDatastGetSuccessful(datasetAPIMock *mocks.IClientMock) {
 	Convey("Then DatasetAPICli.GetInstanceDimensions is called 1 time with the expected parameters", func() {
@@ -98,8 +132,8 @@ func validateDatastGetSuccessful(datasetAPIMock *mocks.IClientMock) {
 	})
 
 	Convey("Then DatasetAPICli.GetInstance is called 1 time with the expected paramters", func() {
-		So(datasetAPIMock.GetInstanceCalls(), ShouldHaveLength, 1)
-		So(datasetAPIMock.GetInstanceCalls()[0].InstanceID, ShouldEqual, testInstanceID)
+		So(datasetAPIMock.GetInstanceBytesCalls(), ShouldHaveLength, 1)
+		So(datasetAPIMock.GetInstanceBytesCalls()[0].InstanceID, ShouldEqual, testInstanceID)
 	})
 }
 
@@ -235,7 +269,7 @@ func TestInstanceEventHandler_Handle(t *testing.T) {
 			Convey("Then Producer.Complete is called 1 time with the expected parameters", func() {
 				calls := completedProducer.CompletedCalls()
 				So(calls, ShouldHaveLength, 1)
-				So(calls[0].E, ShouldResemble, instanceCompleted)
+				So(withoutDuration(calls[0].E), ShouldResemble, instanceCompleted)
 			})
 
 			Convey("Then no error is returned", func() {
@@ -336,8 +370,8 @@ func TestInstanceEventHandler_Handle(t *testing.T) {
 		e := event.NewInstance{InstanceID: testInstanceID}
 
 		datasetAPIMock := datasetAPIMockHappy()
-		datasetAPIMock.GetInstanceFunc = func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) (dataset.Instance, string, error) {
-			return dataset.Instance{}, "", errorMock
+		datasetAPIMock.GetInstanceBytesFunc = func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) ([]byte, string, error) {
+			return nil, "", errorMock
 		}
 		h := setUp(nil, datasetAPIMock, nil)
 		err := h.Handle(ctx, e)
@@ -351,7 +385,7 @@ func TestInstanceEventHandler_Handle(t *testing.T) {
 		})
 
 		Convey("Then DatasetAPICli.GetInstance is called 1 time", func() {
-			So(datasetAPIMock.GetInstanceCalls(), ShouldHaveLength, 1)
+			So(datasetAPIMock.GetInstanceBytesCalls(), ShouldHaveLength, 1)
 		})
 	})
 
@@ -415,8 +449,8 @@ func TestInstanceEventHandler_Handle(t *testing.T) {
 			GetInstanceDimensionsInBatchesFunc: func(ctx context.Context, serviceAuthToken string, instanceID string, maxWorkers, batchSize int) (dataset.Dimensions, string, error) {
 				return dataset.Dimensions{Items: []dataset.Dimension{d1Api, d2Api, d3Api}}, "", nil
 			},
-			GetInstanceFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) (dataset.Instance, string, error) {
-				return dataset.Instance{}, "", nil
+			GetInstanceBytesFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) ([]byte, string, error) {
+				return []byte("{}"), "", nil
 			},
 		}
 		h := setUp(nil, datasetAPIMock, nil)
@@ -607,7 +641,7 @@ func TestInstanceEventHandler_Handle(t *testing.T) {
 			Convey("Then Producer.Complete is called 1 time with the expected parameters", func() {
 				calls := completedProducer.CompletedCalls()
 				So(calls, ShouldHaveLength, 1)
-				So(calls[0].E, ShouldResemble, instanceCompleted)
+				So(withoutDuration(calls[0].E), ShouldResemble, instanceCompleted)
 			})
 
 			Convey("Then the expected error is returned", func() {
@@ -965,6 +999,236 @@ func TestInstanceEventHandler_Handle_ExistingInstance(t *testing.T) {
 			})
 		})
 	})
+
+	Convey("Given an instance with the event ID already exists, and a handler with the report of its previous import", t, func() {
+		storerMock := &storertest.StorerMock{
+			InstanceExistsFunc: func(ctx context.Context, instanceID string) (bool, error) {
+				return true, nil
+			},
+		}
+		h := setUp(storerMock, datasetAPIMockHappy(), nil)
+		h.Reports = report.NewStore(10)
+		previous := report.New(testInstanceID)
+		previous.Complete()
+		h.Reports.Put(previous)
+
+		Convey("When Handle is given a NewInstance event with the same instanceID", func() {
+			err := h.Handle(ctx, newInstance)
+			So(err, ShouldBeNil)
+
+			Convey("Then the stored report is unchanged", func() {
+				rep, found := h.Reports.Get(testInstanceID)
+				So(found, ShouldBeTrue)
+				So(rep, ShouldEqual, previous)
+				So(rep.Attempt(), ShouldEqual, 1)
+			})
+		})
+	})
+
+	Convey("Given an instance with the event ID already exists, and a handler without any report for it", t, func() {
+		storerMock := &storertest.StorerMock{
+			InstanceExistsFunc: func(ctx context.Context, instanceID string) (bool, error) {
+				return true, nil
+			},
+		}
+		h := setUp(storerMock, datasetAPIMockHappy(), nil)
+		h.Reports = report.NewStore(10)
+
+		Convey("When Handle is given a NewInstance event with the same instanceID", func() {
+			err := h.Handle(ctx, newInstance)
+			So(err, ShouldBeNil)
+
+			Convey("Then no report is stored", func() {
+				_, found := h.Reports.Get(testInstanceID)
+				So(found, ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestInstanceEventHandler_Handle_ForceExistingInstance(t *testing.T) {
+	Convey("Given an instance with the event ID already exists, and a handler with an instance deleter", t, func() {
+		storerMock := storerMockHappy()
+		storerMock.InstanceExistsFunc = func(ctx context.Context, instanceID string) (bool, error) {
+			return true, nil
+		}
+		deleter := &storertest.InstanceDeleterMock{
+			DeleteInstanceFunc: func(ctx context.Context, instanceID string) error {
+				return nil
+			},
+		}
+		datasetAPIMock := datasetAPIMockHappy()
+		completedProducer := completedProducerHappy()
+		h := setUp(storerMock, datasetAPIMock, completedProducer)
+		h.Deleter = deleter
+		h.Reports = report.NewStore(10)
+		forced := newInstance
+		forced.Force = true
+
+		Convey("When Handle is given a forced NewInstance event with the same instanceID", func() {
+			err := h.Handle(ctx, forced)
+
+			Convey("Then the existing instance is deleted and created again", func() {
+				So(err, ShouldBeNil)
+				So(deleter.DeleteInstanceCalls(), ShouldHaveLength, 1)
+				So(deleter.DeleteInstanceCalls()[0].InstanceID, ShouldEqual, testInstanceID)
+				So(storerMock.CreateInstanceCalls(), ShouldHaveLength, 1)
+				So(storerMock.InsertDimensionCalls(), ShouldHaveLength, 3)
+			})
+
+			Convey("Then the completion event and the report record that the instance has been rebuilt", func() {
+				expected := instanceCompleted
+				expected.ImporterVersion = h.ImporterVersion
+				expected.Rebuild = true
+				So(completedProducer.CompletedCalls(), ShouldHaveLength, 1)
+				So(withoutDuration(completedProducer.CompletedCalls()[0].E), ShouldResemble, expected)
+				rep, _ := h.Reports.Get(testInstanceID)
+				So(rep.Summary().Rebuild, ShouldBeTrue)
+			})
+		})
+
+		Convey("When the existing instance cannot be deleted", func() {
+			deleter.DeleteInstanceFunc = func(ctx context.Context, instanceID string) error {
+				return errorMock
+			}
+			err := h.Handle(ctx, forced)
+
+			Convey("Then the error is returned and the instance is not created", func() {
+				So(errors.Is(err, errorMock), ShouldBeTrue)
+				So(storerMock.CreateInstanceCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When Handle is given a NewInstance event that is not forced", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then the event is ignored, and nothing is deleted", func() {
+				So(err, ShouldBeNil)
+				So(deleter.DeleteInstanceCalls(), ShouldBeEmpty)
+				So(completedProducer.CompletedCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given an instance with the event ID already exists, and a handler without an instance deleter", t, func() {
+		storerMock := storerMockHappy()
+		storerMock.InstanceExistsFunc = func(ctx context.Context, instanceID string) (bool, error) {
+			return true, nil
+		}
+		h := setUp(storerMock, datasetAPIMockHappy(), completedProducerHappy())
+		forced := newInstance
+		forced.Force = true
+
+		Convey("When Handle is given a forced NewInstance event with the same instanceID", func() {
+			err := h.Handle(ctx, forced)
+
+			Convey("Then ErrRebuildNotSupported is returned", func() {
+				So(errors.Is(err, handler.ErrRebuildNotSupported), ShouldBeTrue)
+				So(storerMock.CreateInstanceCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestInstanceEventHandler_Handle_IncrementalExistingInstance(t *testing.T) {
+	Convey("Given an instance with the event ID already exists with two of its three options, and a handler with a graph reader", t, func() {
+		storerMock := storerMockHappy()
+		storerMock.InstanceExistsFunc = func(ctx context.Context, instanceID string) (bool, error) {
+			return true, nil
+		}
+		reader := &storertest.GraphReaderMock{
+			GetDimensionNodeIDsFunc: func(ctx context.Context, instanceID string, dimensionID string) (map[string]string, error) {
+				return map[string]string{d1Api.Option: "n1", d2Api.Option: "n2"}, nil
+			},
+		}
+		datasetAPIMock := datasetAPIMockHappy()
+		completedProducer := completedProducerHappy()
+		h := setUp(storerMock, datasetAPIMock, completedProducer)
+		h.Reader = reader
+		h.Reports = report.NewStore(10)
+		incremental := newInstance
+		incremental.Incremental = true
+
+		Convey("When Handle is given an incremental NewInstance event with the same instanceID", func() {
+			err := h.Handle(ctx, incremental)
+
+			Convey("Then the existing dimension nodes are read once", func() {
+				So(err, ShouldBeNil)
+				So(reader.GetDimensionNodeIDsCalls(), ShouldHaveLength, 1)
+				So(reader.GetDimensionNodeIDsCalls()[0].InstanceID, ShouldEqual, testInstanceID)
+				So(reader.GetDimensionNodeIDsCalls()[0].DimensionID, ShouldEqual, d1Api.DimensionID)
+			})
+
+			Convey("Then only the missing option is inserted, with its code relationship, and patched", func() {
+				So(storerMock.InsertDimensionCalls(), ShouldHaveLength, 1)
+				So(storerMock.InsertDimensionCalls()[0].Dimension.Option, ShouldEqual, d3Api.Option)
+				So(storerMock.CreateCodeRelationshipCalls(), ShouldHaveLength, 1)
+				So(storerMock.CreateCodeRelationshipCalls()[0].Code, ShouldEqual, d3Api.Option)
+				So(datasetAPIMock.PatchInstanceDimensionsCalls(), ShouldHaveLength, 1)
+				So(datasetAPIMock.PatchInstanceDimensionsCalls()[0].Updates, ShouldHaveLength, 1)
+				So(datasetAPIMock.PatchInstanceDimensionsCalls()[0].Updates[0].Option, ShouldEqual, d3Api.Option)
+			})
+
+			Convey("Then the instance node, its dimensions and its constraint are left untouched", func() {
+				So(storerMock.CreateInstanceCalls(), ShouldBeEmpty)
+				So(storerMock.AddDimensionsCalls(), ShouldBeEmpty)
+				So(storerMock.CreateInstanceConstraintCalls(), ShouldBeEmpty)
+			})
+
+			Convey("Then the completion event is produced with the statistics of all the options, and the report records the incremental import", func() {
+				expected := instanceCompleted
+				expected.ImporterVersion = h.ImporterVersion
+				So(completedProducer.CompletedCalls(), ShouldHaveLength, 1)
+				So(withoutDuration(completedProducer.CompletedCalls()[0].E), ShouldResemble, expected)
+				rep, _ := h.Reports.Get(testInstanceID)
+				So(rep.Summary().Incremental, ShouldBeTrue)
+				So(rep.Summary().InsertedOptions, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When the existing dimension nodes cannot be read", func() {
+			reader.GetDimensionNodeIDsFunc = func(ctx context.Context, instanceID string, dimensionID string) (map[string]string, error) {
+				return nil, errorMock
+			}
+			err := h.Handle(ctx, incremental)
+
+			Convey("Then the error is returned and nothing is inserted", func() {
+				So(errors.Is(err, errorMock), ShouldBeTrue)
+				So(storerMock.InsertDimensionCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the handler streams the dimension options", func() {
+			h.StreamDimensions = true
+			setPagedDimensions(datasetAPIMock, d1Api, d2Api, d3Api)
+			err := h.Handle(ctx, incremental)
+
+			Convey("Then only the missing option is inserted", func() {
+				So(err, ShouldBeNil)
+				So(storerMock.InsertDimensionCalls(), ShouldHaveLength, 1)
+				So(storerMock.InsertDimensionCalls()[0].Dimension.Option, ShouldEqual, d3Api.Option)
+			})
+		})
+	})
+
+	Convey("Given an instance with the event ID already exists, and a handler without a graph reader", t, func() {
+		storerMock := storerMockHappy()
+		storerMock.InstanceExistsFunc = func(ctx context.Context, instanceID string) (bool, error) {
+			return true, nil
+		}
+		h := setUp(storerMock, datasetAPIMockHappy(), completedProducerHappy())
+		incremental := newInstance
+		incremental.Incremental = true
+
+		Convey("When Handle is given an incremental NewInstance event with the same instanceID", func() {
+			err := h.Handle(ctx, incremental)
+
+			Convey("Then ErrIncrementalNotSupported is returned", func() {
+				So(errors.Is(err, handler.ErrIncrementalNotSupported), ShouldBeTrue)
+				So(storerMock.InsertDimensionCalls(), ShouldBeEmpty)
+			})
+		})
+	})
 }
 
 func TestInstanceEventHandler_Handle_InstanceExistsErr(t *testing.T) {
@@ -1003,6 +1267,320 @@ func TestInstanceEventHandler_Handle_InstanceExistsErr(t *testing.T) {
 	})
 }
 
+func TestInstanceEventHandler_Handle_InvalidOptions(t *testing.T) {
+	Convey("Given a handler with a dataset api that returns a duplicate option", t, func() {
+		storerMock := storerMockHappy()
+		datasetAPIMock := datasetAPIMockHappy()
+		datasetAPIMock.GetInstanceDimensionsInBatchesFunc = func(ctx context.Context, serviceAuthToken string, instanceID string, batchSize, maxWorkers int) (dataset.Dimensions, string, error) {
+			return dataset.Dimensions{Items: []dataset.Dimension{d1Api, d2Api, d1Api}}, "", nil
+		}
+		reportProducer := reportProducerHappy()
+		h := setUp(storerMock, datasetAPIMock, completedProducerHappy())
+		h.ReportProducer = reportProducer
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then a validation error listing the duplicate option is returned", func() {
+				var validationErr *handler.ValidationError
+				So(errors.As(err, &validationErr), ShouldBeTrue)
+				So(validationErr.Options, ShouldResemble, []handler.InvalidOption{
+					{DimensionID: d1Api.DimensionID, Option: d1Api.Option, Reason: "is a duplicate option"},
+				})
+			})
+
+			Convey("Then nothing is written to the graph database", func() {
+				So(storerMock.InstanceExistsCalls(), ShouldHaveLength, 0)
+				So(storerMock.CreateInstanceCalls(), ShouldHaveLength, 0)
+				So(storerMock.InsertDimensionCalls(), ShouldHaveLength, 0)
+			})
+
+			Convey("Then the failed report contains the duplicate option", func() {
+				calls := reportProducer.ReportCalls()
+				So(calls, ShouldHaveLength, 1)
+				So(calls[0].E.Status, ShouldEqual, report.StatusFailed)
+				So(calls[0].E.DuplicateOptions, ShouldResemble, []string{report.OptionKey(d1Api.DimensionID, d1Api.Option)})
+			})
+		})
+	})
+}
+
+func TestInstanceEventHandler_Handle_Report(t *testing.T) {
+	Convey("Given a successful handler with a report producer and a report store", t, func() {
+		storerMock := storerMockHappy()
+		datasetAPIMock := datasetAPIMockHappy()
+		reportProducer := reportProducerHappy()
+		h := setUp(storerMock, datasetAPIMock, completedProducerHappy())
+		h.ReportProducer = reportProducer
+		h.Reports = report.NewStore(10)
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+			So(err, ShouldBeNil)
+
+			Convey("Then ReportProducer.Report is called 1 time with the expected report", func() {
+				calls := reportProducer.ReportCalls()
+				So(calls, ShouldHaveLength, 1)
+				e := calls[0].E
+				So(e.InstanceID, ShouldEqual, testInstanceID)
+				So(e.Status, ShouldEqual, report.StatusCompleted)
+				So(e.Dimensions, ShouldResemble, []event.DimensionReport{{Name: d1Api.DimensionID, OptionCount: 3}})
+				So(e.OptionsWithoutOrder, ShouldResemble, []string{report.OptionKey(d3Api.DimensionID, d3Api.Option)})
+				So(e.DuplicateOptions, ShouldBeEmpty)
+				So(e.SkippedCodeRelationships, ShouldBeEmpty)
+				So(e.GraphCalls, ShouldEqual, 12)
+				So(e.Stages, ShouldHaveLength, 6)
+			})
+
+			Convey("Then the report can be retrieved from the report store", func() {
+				r, found := h.Reports.Get(testInstanceID)
+				So(found, ShouldBeTrue)
+				So(r.Summary().Status, ShouldEqual, report.StatusCompleted)
+			})
+		})
+	})
+
+	Convey("Given a handler with a report producer and a failing datastore", t, func() {
+		storerMock := storerMockHappy()
+		storerMock.CreateInstanceConstraintFunc = func(ctx context.Context, instanceID string) error {
+			return errorMock
+		}
+		reportProducer := reportProducerHappy()
+		h := setUp(storerMock, datasetAPIMockHappy(), completedProducerHappy())
+		h.ReportProducer = reportProducer
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+			So(err, ShouldNotBeNil)
+
+			Convey("Then ReportProducer.Report is called 1 time with a failed report", func() {
+				calls := reportProducer.ReportCalls()
+				So(calls, ShouldHaveLength, 1)
+				So(calls[0].E.Status, ShouldEqual, report.StatusFailed)
+				So(calls[0].E.Error, ShouldEqual, err.Error())
+			})
+		})
+	})
+
+	Convey("Given a handler with a report producer and an instance that already exists", t, func() {
+		storerMock := &storertest.StorerMock{
+			InstanceExistsFunc: func(ctx context.Context, instanceID string) (bool, error) {
+				return true, nil
+			},
+		}
+		reportProducer := reportProducerHappy()
+		h := setUp(storerMock, datasetAPIMockHappy(), nil)
+		h.ReportProducer = reportProducer
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+			So(err, ShouldBeNil)
+
+			Convey("Then ReportProducer.Report is not called", func() {
+				So(reportProducer.ReportCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+}
+
+func TestInstanceEventHandler_Handle_ETags(t *testing.T) {
+	errPreconditionFailed := dataset.NewDatasetAPIResponse(&http.Response{StatusCode: http.StatusPreconditionFailed, Body: io.NopCloser(strings.NewReader(""))}, "/instances")
+
+	Convey("Given a handler with a dataset api that returns an eTag for the instance dimensions and for each patch", t, func() {
+		datasetAPIMock := datasetAPIMockHappy()
+		datasetAPIMock.GetInstanceDimensionsInBatchesFunc = func(ctx context.Context, serviceAuthToken string, instanceID string, batchSize, maxWorkers int) (dataset.Dimensions, string, error) {
+			return dataset.Dimensions{Items: []dataset.Dimension{d1Api, d2Api, d3Api}}, "etag-0", nil
+		}
+		datasetAPIMock.PatchInstanceDimensionsFunc = func(ctx context.Context, serviceAuthToken string, instanceID string, upserts []*dataset.OptionPost, updates []*dataset.OptionUpdate, ifMatch string) (string, error) {
+			return fmt.Sprintf("etag-%d", len(datasetAPIMock.PatchInstanceDimensionsCalls())), nil
+		}
+		h := setUp(storerMockHappy(), datasetAPIMock, completedProducerHappy())
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+			So(err, ShouldBeNil)
+
+			Convey("Then each patch is sent with the eTag returned by the previous call as If-Match", func() {
+				calls := datasetAPIMock.PatchInstanceDimensionsCalls()
+				So(calls, ShouldHaveLength, 2)
+				So(calls[0].IfMatch, ShouldEqual, "etag-0")
+				So(calls[1].IfMatch, ShouldEqual, "etag-1")
+			})
+		})
+
+		Convey("When a valid event is handled with patches split in requests of 1 option, with a parallelism of 2", func() {
+			h.DatasetAPICli.Patch = client.PatchLimits{MaxOptions: 1, Parallelism: 2}
+			err := h.Handle(ctx, newInstance)
+			So(err, ShouldBeNil)
+
+			Convey("Then every request, including the ones after a split patch, is sent with the eTag returned by the previous one, never the wildcard eTag", func() {
+				calls := datasetAPIMock.PatchInstanceDimensionsCalls()
+				So(len(calls), ShouldBeGreaterThan, 2)
+				for i, call := range calls {
+					So(call.IfMatch, ShouldNotEqual, headers.IfMatchAnyETag)
+					So(call.IfMatch, ShouldEqual, fmt.Sprintf("etag-%d", i))
+				}
+			})
+		})
+	})
+
+	Convey("Given a handler with a dataset api that rejects the first patch because the instance has been modified", t, func() {
+		datasetAPIMock := datasetAPIMockHappy()
+		datasetAPIMock.GetInstanceDimensionsInBatchesFunc = func(ctx context.Context, serviceAuthToken string, instanceID string, batchSize, maxWorkers int) (dataset.Dimensions, string, error) {
+			if len(datasetAPIMock.GetInstanceDimensionsInBatchesCalls()) > 1 {
+				// Wales has been removed from the instance
+				return dataset.Dimensions{Items: []dataset.Dimension{d1Api, d3Api}}, "etag-reloaded", nil
+			}
+			return dataset.Dimensions{Items: []dataset.Dimension{d1Api, d2Api, d3Api}}, "etag-0", nil
+		}
+		datasetAPIMock.PatchInstanceDimensionsFunc = func(ctx context.Context, serviceAuthToken string, instanceID string, upserts []*dataset.OptionPost, updates []*dataset.OptionUpdate, ifMatch string) (string, error) {
+			if len(datasetAPIMock.PatchInstanceDimensionsCalls()) == 1 {
+				return "", errPreconditionFailed
+			}
+			return "etag-patched", nil
+		}
+		h := setUp(storerMockHappy(), datasetAPIMock, completedProducerHappy())
+		h.MaxConflictRetries = 1
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+			So(err, ShouldBeNil)
+
+			Convey("Then the dimension options are reloaded once after the conflict", func() {
+				So(datasetAPIMock.GetInstanceDimensionsInBatchesCalls(), ShouldHaveLength, 2)
+			})
+
+			Convey("Then the patch is retried with the reloaded eTag, only for the options that still exist", func() {
+				calls := datasetAPIMock.PatchInstanceDimensionsCalls()
+				So(calls, ShouldHaveLength, 3)
+				So(calls[0].IfMatch, ShouldEqual, "etag-0")
+				So(calls[0].Updates, ShouldHaveLength, 2)
+				So(calls[1].IfMatch, ShouldEqual, "etag-reloaded")
+				So(calls[1].Updates, ShouldHaveLength, 1)
+				So(calls[1].Updates[0].Option, ShouldEqual, d1Api.Option)
+				So(calls[2].IfMatch, ShouldEqual, "etag-patched")
+			})
+		})
+	})
+
+	Convey("Given a handler with a dataset api client that splits patches and rejects the second request of the first patch", t, func() {
+		datasetAPIMock := datasetAPIMockHappy()
+		datasetAPIMock.PatchInstanceDimensionsFunc = func(ctx context.Context, serviceAuthToken string, instanceID string, upserts []*dataset.OptionPost, updates []*dataset.OptionUpdate, ifMatch string) (string, error) {
+			if len(datasetAPIMock.PatchInstanceDimensionsCalls()) == 2 {
+				return "", errPreconditionFailed
+			}
+			return "", nil
+		}
+		h := setUp(storerMockHappy(), datasetAPIMock, completedProducerHappy())
+		h.DatasetAPICli.Patch = client.PatchLimits{MaxOptions: 1}
+		h.MaxConflictRetries = 1
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+			So(err, ShouldBeNil)
+
+			Convey("Then only the option that was not patched is retried after the conflict", func() {
+				calls := datasetAPIMock.PatchInstanceDimensionsCalls()
+				So(calls, ShouldHaveLength, 4)
+				So(calls[0].Updates[0].Option, ShouldEqual, d1Api.Option)
+				So(calls[1].Updates[0].Option, ShouldEqual, d2Api.Option)
+				So(calls[2].Updates, ShouldHaveLength, 1)
+				So(calls[2].Updates[0].Option, ShouldEqual, d2Api.Option)
+				So(calls[3].Updates[0].Option, ShouldEqual, d3Api.Option)
+			})
+		})
+	})
+
+	Convey("Given a handler with a dataset api that always rejects patches because the instance has been modified", t, func() {
+		datasetAPIMock := datasetAPIMockHappy()
+		datasetAPIMock.PatchInstanceDimensionsFunc = func(ctx context.Context, serviceAuthToken string, instanceID string, upserts []*dataset.OptionPost, updates []*dataset.OptionUpdate, ifMatch string) (string, error) {
+			return "", errPreconditionFailed
+		}
+		h := setUp(storerMockHappy(), datasetAPIMock, completedProducerHappy())
+		h.MaxConflictRetries = 2
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then the conflict error is returned once the retries are exhausted", func() {
+				So(client.IsConflict(err), ShouldBeTrue)
+				So(datasetAPIMock.PatchInstanceDimensionsCalls(), ShouldHaveLength, 3)
+				So(datasetAPIMock.GetInstanceDimensionsInBatchesCalls(), ShouldHaveLength, 3)
+			})
+		})
+	})
+}
+
+// versionDetailsStorer is a store that supports attaching version details to instance nodes
+type versionDetailsStorer struct {
+	*storertest.StorerMock
+	*storertest.VersionDetailsStorerMock
+}
+
+func TestInstanceEventHandler_Handle_VersionDetails(t *testing.T) {
+	Convey("Given a handler with a store that supports version details", t, func() {
+		storerMock := storerMockHappy()
+		versionDetailsMock := &storertest.VersionDetailsStorerMock{
+			AddVersionDetailsToInstanceFunc: func(ctx context.Context, instanceID string, datasetID string, edition string, version int) error {
+				return nil
+			},
+		}
+		h := setUp(storerMock, datasetAPIMockHappy(), completedProducerHappy())
+		h.Store = versionDetailsStorer{storerMock, versionDetailsMock}
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then no error is returned", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then the version details are added to the instance node after it is created", func() {
+				So(storerMock.CreateInstanceCalls(), ShouldHaveLength, 1)
+				calls := versionDetailsMock.AddVersionDetailsToInstanceCalls()
+				So(calls, ShouldHaveLength, 1)
+				So(calls[0].InstanceID, ShouldEqual, testInstanceID)
+				So(calls[0].DatasetID, ShouldEqual, testDatasetID)
+				So(calls[0].Edition, ShouldEqual, testEdition)
+				So(calls[0].Version, ShouldEqual, testVersion)
+			})
+		})
+
+		Convey("When adding the version details fails", func() {
+			versionDetailsMock.AddVersionDetailsToInstanceFunc = func(ctx context.Context, instanceID string, datasetID string, edition string, version int) error {
+				return errorMock
+			}
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then the expected error is returned and no dimensions are inserted", func() {
+				So(err.Error(), ShouldEqual, fmt.Errorf("add version details to instance returned an error: %w", errorMock).Error())
+				So(storerMock.InsertDimensionCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+
+	Convey("Given a handler with a store that supports version details and an instance without a dataset", t, func() {
+		storerMock := storerMockHappy()
+		versionDetailsMock := &storertest.VersionDetailsStorerMock{}
+		datasetAPIMock := datasetAPIMockHappy()
+		datasetAPIMock.GetInstanceBytesFunc = func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) ([]byte, string, error) {
+			b, err := json.Marshal(dataset.Instance{Version: dataset.Version{ID: testInstanceID, CSVHeader: instanceAPI.CSVHeader}})
+			return b, "", err
+		}
+		h := setUp(storerMock, datasetAPIMock, completedProducerHappy())
+		h.Store = versionDetailsStorer{storerMock, versionDetailsMock}
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then no error is returned and the version details are not added", func() {
+				So(err, ShouldBeNil)
+				So(versionDetailsMock.AddVersionDetailsToInstanceCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+}
+
 func storerMockHappy() *storertest.StorerMock {
 	return &storertest.StorerMock{
 		InstanceExistsFunc: func(ctx context.Context, instanceID string) (bool, error) {
@@ -1044,8 +1622,8 @@ func datasetAPIMockHappy() *mocks.IClientMock {
 		PatchInstanceDimensionsFunc: func(ctx context.Context, serviceAuthToken string, instanceID string, upserts []*dataset.OptionPost, updates []*dataset.OptionUpdate, ifMatch string) (string, error) {
 			return "", nil
 		},
-		GetInstanceFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) (dataset.Instance, string, error) {
-			return instanceAPI, "", nil
+		GetInstanceBytesFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) ([]byte, string, error) {
+			return instanceAPIBytes, "", nil
 		},
 	}
 }
@@ -1057,6 +1635,14 @@ func completedProducerHappy() *mocks.CompletedProducerMock {
 	}
 }
 
+func reportProducerHappy() *mocks.ReportProducerMock {
+	return &mocks.ReportProducerMock{
+		ReportFunc: func(ctx context.Context, e event.ImportReport) error {
+			return nil
+		},
+	}
+}
+
 // Default set up for the handler with provided mocks
 func setUp(storerMock *storertest.StorerMock, datasetAPIMock *mocks.IClientMock, completedProducer *mocks.CompletedProducerMock) handler.InstanceEventHandler {
 	datasetAPIClient := &client.DatasetAPI{
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ONSdigital/dp-dimension-importer/model"
+	"github.com/ONSdigital/dp-dimension-importer/report"
+)
+
+// ErrIncrementalNotSupported is returned when an existing instance is imported incrementally, but its dimension nodes cannot be read back
+var ErrIncrementalNotSupported = errors.New("instance already exists and its dimension nodes cannot be read to import it incrementally")
+
+// existingNodes keeps the options of the dimension nodes of an existing instance, read back from the graph database
+// the first time each dimension is needed. It is concurrency safe.
+type existingNodes struct {
+	mutex   sync.Mutex
+	options map[string]map[string]string // node ID by option, by dimension ID
+}
+
+// missingDimensions returns the provided dimension options that have no dimension node in the graph database,
+// reading back the dimension nodes of the instance with the Reader
+func (hdlr *InstanceEventHandler) missingDimensions(ctx context.Context, instanceID string, dimensions []*model.Dimension, existing *existingNodes, rep *report.Report) ([]*model.Dimension, error) {
+	existing.mutex.Lock()
+	defer existing.mutex.Unlock()
+	if existing.options == nil {
+		existing.options = map[string]map[string]string{}
+	}
+
+	missing := make([]*model.Dimension, 0, len(dimensions))
+	for _, d := range dimensions {
+		dimensionID := d.DBModel().DimensionID
+		nodeIDs, found := existing.options[dimensionID]
+		if !found {
+			rep.GraphCall()
+			var err error
+			if nodeIDs, err = hdlr.Reader.GetDimensionNodeIDs(ctx, instanceID, dimensionID); err != nil {
+				return nil, fmt.Errorf("failed to get the existing dimension nodes: %w", err)
+			}
+			existing.options[dimensionID] = nodeIDs
+		}
+		if _, found := nodeIDs[d.DBModel().Option]; !found {
+			missing = append(missing, d)
+		}
+	}
+	return missing, nil
+}
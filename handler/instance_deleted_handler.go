@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ONSdigital/dp-dimension-importer/client"
+	"github.com/ONSdigital/dp-dimension-importer/event"
+	"github.com/ONSdigital/dp-dimension-importer/idempotency"
+	"github.com/ONSdigital/dp-dimension-importer/store"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// ErrDeleteNotSupported is returned when the graph data of a deleted instance cannot be removed, because the store cannot delete nodes
+var ErrDeleteNotSupported = errors.New("the graph database nodes of deleted instances cannot be removed")
+
+// DeletedProducer Producer kafka messages confirming that the graph data of deleted instances has been removed.
+type DeletedProducer interface {
+	Deleted(ctx context.Context, e event.DimensionsDeleted) error
+}
+
+// InstanceDeletedEventHandler provides functions for handling InstanceDeleted events, removing the graph data created by the import of the instance.
+type InstanceDeletedEventHandler struct {
+	Store     store.Storer
+	Deleter   store.InstanceDeleter
+	Producer  DeletedProducer
+	Processed idempotency.Store // the records of the processed events of deleted instances are removed from it, if not nil
+}
+
+// Handle removes the instance node, its dimension nodes and code relationships, and the observation constraint of the instance
+// from the graph database, if the instance exists, then produces a DimensionsDeleted event to confirm it.
+// Deleting an instance that does not exist is not an error, so that events for cancelled imports that never reached the graph database are confirmed.
+// The records of the processed events of the instance are removed from the idempotency store, so that the instance can be imported again.
+func (hdlr *InstanceDeletedEventHandler) Handle(ctx context.Context, e event.InstanceDeleted) error {
+	if err := hdlr.validate(e); err != nil {
+		return err
+	}
+	logData := log.Data{"instance_id": e.InstanceID, "package": packageName}
+	log.Info(ctx, "handling instance deleted event", logData)
+
+	exists, err := hdlr.Store.InstanceExists(ctx, e.InstanceID)
+	if err != nil {
+		return fmt.Errorf("instance exists check returned an error: %w", err)
+	}
+
+	if exists {
+		if err := hdlr.Deleter.DeleteInstanceConstraint(ctx, e.InstanceID); err != nil {
+			return fmt.Errorf("delete instance constraint returned an error: %w", err)
+		}
+		if err := hdlr.Deleter.DeleteInstance(ctx, e.InstanceID); err != nil {
+			return fmt.Errorf("delete instance returned an error: %w", err)
+		}
+		log.Info(ctx, "instance graph data deleted", logData)
+	} else {
+		log.Info(ctx, "instance does not exist in the graph database, nothing to delete", logData)
+	}
+
+	if hdlr.Processed != nil {
+		if err := hdlr.Processed.DeleteInstance(e.InstanceID); err != nil {
+			return fmt.Errorf("idempotency store delete instance returned an error: %w", err)
+		}
+	}
+
+	deleted := event.DimensionsDeleted{
+		InstanceID: e.InstanceID,
+		Existed:    exists,
+		TraceID:    e.TraceID,
+	}
+	if err := hdlr.Producer.Deleted(ctx, deleted); err != nil {
+		return fmt.Errorf("Producer.Deleted returned an error: %w", err)
+	}
+	return nil
+}
+
+func (hdlr *InstanceDeletedEventHandler) validate(e event.InstanceDeleted) error {
+	if hdlr.Store == nil {
+		return fmt.Errorf("event validation error: %w", client.ErrNoDatastore)
+	}
+	if hdlr.Deleter == nil {
+		return fmt.Errorf("event validation error: %w", ErrDeleteNotSupported)
+	}
+	if e.InstanceID == "" {
+		return fmt.Errorf("event validation error: %w", client.ErrInstanceIDEmpty)
+	}
+	return nil
+}
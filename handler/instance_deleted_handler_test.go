@@ -0,0 +1,143 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-dimension-importer/client"
+	"github.com/ONSdigital/dp-dimension-importer/event"
+	"github.com/ONSdigital/dp-dimension-importer/handler"
+	"github.com/ONSdigital/dp-dimension-importer/idempotency"
+	"github.com/ONSdigital/dp-dimension-importer/mocks"
+	"github.com/ONSdigital/dp-dimension-importer/store/storertest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var instanceDeleted = event.InstanceDeleted{
+	InstanceID: testInstanceID,
+	TraceID:    testTraceID,
+}
+
+func instanceDeleterHappy() *storertest.InstanceDeleterMock {
+	return &storertest.InstanceDeleterMock{
+		DeleteInstanceFunc: func(ctx context.Context, instanceID string) error {
+			return nil
+		},
+		DeleteInstanceConstraintFunc: func(ctx context.Context, instanceID string) error {
+			return nil
+		},
+	}
+}
+
+func deletedProducerHappy() *mocks.DeletedProducerMock {
+	return &mocks.DeletedProducerMock{
+		DeletedFunc: func(ctx context.Context, e event.DimensionsDeleted) error {
+			return nil
+		},
+	}
+}
+
+func TestInstanceDeletedEventHandler_Handle(t *testing.T) {
+	Convey("Given a handler for an instance that exists in the graph database", t, func() {
+		storer := storerMockHappy()
+		storer.InstanceExistsFunc = func(ctx context.Context, instanceID string) (bool, error) {
+			return true, nil
+		}
+		deleter := instanceDeleterHappy()
+		producer := deletedProducerHappy()
+		h := handler.InstanceDeletedEventHandler{Store: storer, Deleter: deleter, Producer: producer}
+
+		Convey("When the event is handled", func() {
+			err := h.Handle(ctx, instanceDeleted)
+
+			Convey("Then the constraint and the instance are deleted and a dimensions deleted event is produced", func() {
+				So(err, ShouldBeNil)
+				So(deleter.DeleteInstanceConstraintCalls(), ShouldHaveLength, 1)
+				So(deleter.DeleteInstanceConstraintCalls()[0].InstanceID, ShouldEqual, testInstanceID)
+				So(deleter.DeleteInstanceCalls(), ShouldHaveLength, 1)
+				So(deleter.DeleteInstanceCalls()[0].InstanceID, ShouldEqual, testInstanceID)
+				So(producer.DeletedCalls(), ShouldHaveLength, 1)
+				So(producer.DeletedCalls()[0].E, ShouldResemble, event.DimensionsDeleted{
+					InstanceID: testInstanceID,
+					Existed:    true,
+					TraceID:    testTraceID,
+				})
+			})
+		})
+
+		Convey("When the event is handled by a handler with an idempotency store", func() {
+			processed := idempotency.NewMemoryStore(10, time.Hour)
+			processed.Put(idempotency.Record{Key: event.TypeNewInstance + ":" + testInstanceID + ":a", Result: idempotency.ResultSucceeded, ProcessedAt: time.Now()})
+			processed.Put(idempotency.Record{Key: event.TypeNewInstance + ":other:b", Result: idempotency.ResultSucceeded, ProcessedAt: time.Now()})
+			h.Processed = processed
+			err := h.Handle(ctx, instanceDeleted)
+
+			Convey("Then the records of the processed events of the instance are removed, so that it can be imported again", func() {
+				So(err, ShouldBeNil)
+				records := processed.Records()
+				So(records, ShouldHaveLength, 1)
+				So(records[0].Key, ShouldEqual, event.TypeNewInstance+":other:b")
+			})
+		})
+
+		Convey("When deleting the instance fails", func() {
+			deleter.DeleteInstanceFunc = func(ctx context.Context, instanceID string) error {
+				return errors.New("delete error")
+			}
+			err := h.Handle(ctx, instanceDeleted)
+
+			Convey("Then the error is returned and no event is produced", func() {
+				So(err.Error(), ShouldEqual, "delete instance returned an error: delete error")
+				So(producer.DeletedCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+
+	Convey("Given a handler for an instance that does not exist in the graph database", t, func() {
+		deleter := instanceDeleterHappy()
+		producer := deletedProducerHappy()
+		h := handler.InstanceDeletedEventHandler{Store: storerMockHappy(), Deleter: deleter, Producer: producer}
+
+		Convey("When the event is handled", func() {
+			err := h.Handle(ctx, instanceDeleted)
+
+			Convey("Then nothing is deleted and the event produced confirms the instance did not exist", func() {
+				So(err, ShouldBeNil)
+				So(deleter.DeleteInstanceConstraintCalls(), ShouldHaveLength, 0)
+				So(deleter.DeleteInstanceCalls(), ShouldHaveLength, 0)
+				So(producer.DeletedCalls(), ShouldHaveLength, 1)
+				So(producer.DeletedCalls()[0].E.Existed, ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("Given a handler without deleter", t, func() {
+		storer := storerMockHappy()
+		producer := deletedProducerHappy()
+		h := handler.InstanceDeletedEventHandler{Store: storer, Producer: producer}
+
+		Convey("When the event is handled", func() {
+			err := h.Handle(ctx, instanceDeleted)
+
+			Convey("Then ErrDeleteNotSupported is returned and the graph database is not queried", func() {
+				So(errors.Is(err, handler.ErrDeleteNotSupported), ShouldBeTrue)
+				So(storer.InstanceExistsCalls(), ShouldHaveLength, 0)
+				So(producer.DeletedCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+
+	Convey("Given a handler and an event without instance ID", t, func() {
+		h := handler.InstanceDeletedEventHandler{Store: storerMockHappy(), Deleter: instanceDeleterHappy(), Producer: deletedProducerHappy()}
+
+		Convey("When the event is handled", func() {
+			err := h.Handle(ctx, event.InstanceDeleted{TraceID: testTraceID})
+
+			Convey("Then ErrInstanceIDEmpty is returned", func() {
+				So(errors.Is(err, client.ErrInstanceIDEmpty), ShouldBeTrue)
+			})
+		})
+	})
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"sync"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
+	"github.com/ONSdigital/dp-api-clients-go/v2/headers"
+)
+
+// patchTracker keeps track of the dimension option patches sent to dataset API for the instance being imported:
+//   - the latest known eTag of the instance, which is sent as If-Match on every patch,
+//     so that the importer does not overwrite changes made to the instance while the import is running.
+//   - the node_id and order values patched for each option, if they need to be verified once the import is done.
+//
+// A nil patchTracker matches any eTag and does not record any values.
+type patchTracker struct {
+	mutex   sync.Mutex
+	eTag    string
+	patched map[string]*dataset.OptionUpdate // patched values by option key, nil if they are not recorded
+}
+
+func newPatchTracker(eTag string, recordPatched bool) *patchTracker {
+	t := &patchTracker{eTag: eTag}
+	if recordPatched {
+		t.patched = map[string]*dataset.OptionUpdate{}
+	}
+	return t
+}
+
+// ETag returns the latest known eTag, or the wildcard eTag if it is not known
+func (t *patchTracker) ETag() string {
+	if t == nil {
+		return headers.IfMatchAnyETag
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.eTag == "" {
+		return headers.IfMatchAnyETag
+	}
+	return t.eTag
+}
+
+// SetETag updates the latest known eTag. An empty eTag is ignored, so that a known eTag is never replaced by the wildcard eTag.
+func (t *patchTracker) SetETag(eTag string) {
+	if t == nil || eTag == "" {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.eTag = eTag
+}
+
+// Record records the values of the provided updates, which have been successfully patched, if patched values are being recorded.
+// The values of an option that had already been patched are merged, keeping the previous ones that are not updated.
+func (t *patchTracker) Record(updates []*dataset.OptionUpdate) {
+	if t == nil || t.patched == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, u := range updates {
+		key := optionKey(u.Name, u.Option)
+		patched, found := t.patched[key]
+		if !found {
+			patched = &dataset.OptionUpdate{Name: u.Name, Option: u.Option}
+			t.patched[key] = patched
+		}
+		if u.NodeID != "" {
+			patched.NodeID = u.NodeID
+		}
+		if u.Order != nil {
+			patched.Order = u.Order
+		}
+	}
+}
+
+// Patched returns the values patched for each option key, or nil if patched values are not being recorded
+func (t *patchTracker) Patched() map[string]*dataset.OptionUpdate {
+	if t == nil {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.patched
+}
+
+// optionKey returns the key used to identify a (dimension, option) pair
+func optionKey(dimensionID, option string) string {
+	return dimensionID + "\x00" + option
+}
@@ -0,0 +1,26 @@
+package handler
+
+// Profile is the name of the pipeline used to import the dimensions of an instance
+type Profile string
+
+// Available pipeline profiles
+const (
+	// ProfileGraph imports the dimensions to the graph database and patches their order and node ID in dataset API
+	ProfileGraph Profile = "graph"
+	// ProfileOrderOnly patches the order of the dimension options in dataset API, without any graph database writes
+	ProfileOrderOnly Profile = "order_only"
+	// ProfileNoop does not import anything, it only emits the completion event
+	ProfileNoop Profile = "noop"
+)
+
+// ProfileFor returns the pipeline profile for the provided instance type,
+// falling back to the default profile, and to the graph profile if no default is configured
+func (hdlr *InstanceEventHandler) ProfileFor(instanceType string) Profile {
+	if p, found := hdlr.InstanceTypeProfiles[instanceType]; found {
+		return p
+	}
+	if hdlr.DefaultProfile != "" {
+		return hdlr.DefaultProfile
+	}
+	return ProfileGraph
+}
@@ -0,0 +1,150 @@
+package handler_test
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
+	"github.com/ONSdigital/dp-dimension-importer/handler"
+	"github.com/ONSdigital/dp-dimension-importer/order"
+	"github.com/ONSdigital/dp-dimension-importer/report"
+	"github.com/ONSdigital/dp-dimension-importer/store/storertest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// validateNoGraphWrites checks that nothing has been written to the graph database
+func validateNoGraphWrites(storerMock *storertest.StorerMock) {
+	Convey("Then nothing is written to the graph database", func() {
+		So(storerMock.InstanceExistsCalls(), ShouldHaveLength, 0)
+		So(storerMock.CreateInstanceCalls(), ShouldHaveLength, 0)
+		So(storerMock.InsertDimensionCalls(), ShouldHaveLength, 0)
+		So(storerMock.CreateCodeRelationshipCalls(), ShouldHaveLength, 0)
+		So(storerMock.AddDimensionsCalls(), ShouldHaveLength, 0)
+		So(storerMock.CreateInstanceConstraintCalls(), ShouldHaveLength, 0)
+	})
+}
+
+func TestInstanceEventHandler_Handle_Profiles(t *testing.T) {
+	Convey("Given a handler that maps the instance type to the noop profile", t, func() {
+		storerMock := storerMockHappy()
+		datasetAPIMock := datasetAPIMockHappy()
+		completedProducer := completedProducerHappy()
+		h := setUp(storerMock, datasetAPIMock, completedProducer)
+		h.InstanceTypeProfiles = map[string]handler.Profile{testInstanceType: handler.ProfileNoop}
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then no error is returned", func() {
+				So(err, ShouldBeNil)
+			})
+
+			validateNoGraphWrites(storerMock)
+
+			Convey("Then no orders are obtained and dataset API is not patched", func() {
+				So(storerMock.GetCodesOrderCalls(), ShouldHaveLength, 0)
+				So(datasetAPIMock.PatchInstanceDimensionsCalls(), ShouldHaveLength, 0)
+			})
+
+			Convey("Then the completion event is produced", func() {
+				So(completedProducer.CompletedCalls(), ShouldHaveLength, 1)
+				So(withoutDuration(completedProducer.CompletedCalls()[0].E), ShouldResemble, instanceCompleted)
+			})
+		})
+	})
+
+	Convey("Given a handler that maps the instance type to the order-only profile without an order source", t, func() {
+		storerMock := storerMockHappy()
+		datasetAPIMock := datasetAPIMockHappy()
+		completedProducer := completedProducerHappy()
+		h := setUp(storerMock, datasetAPIMock, completedProducer)
+		h.InstanceTypeProfiles = map[string]handler.Profile{testInstanceType: handler.ProfileOrderOnly}
+		h.Reports = report.NewStore(1)
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then no error is returned", func() {
+				So(err, ShouldBeNil)
+			})
+
+			validateNoGraphWrites(storerMock)
+
+			Convey("Then the orders are obtained from the code lists in the graph database", func() {
+				So(storerMock.GetCodesOrderCalls(), ShouldHaveLength, 2)
+				r, found := h.Reports.Get(testInstanceID)
+				So(found, ShouldBeTrue)
+				So(r.Summary().GraphCalls, ShouldEqual, 2)
+			})
+
+			Convey("Then dataset API is patched with the orders only, in batches", func() {
+				calls := datasetAPIMock.PatchInstanceDimensionsCalls()
+				So(calls, ShouldHaveLength, 2)
+				So(calls[0].Updates, ShouldResemble, []*dataset.OptionUpdate{
+					{Name: d1Api.DimensionID, Option: d1Api.Option, Order: &d1Order},
+					{Name: d2Api.DimensionID, Option: d2Api.Option, Order: &d2Order},
+				})
+				So(calls[1].Updates, ShouldBeEmpty)
+			})
+
+			Convey("Then the completion event is produced", func() {
+				So(completedProducer.CompletedCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+
+	Convey("Given a handler that defaults to the order-only profile with a local order source", t, func() {
+		storerMock := storerMockHappy()
+		datasetAPIMock := datasetAPIMockHappy()
+		completedProducer := completedProducerHappy()
+		h := setUp(storerMock, datasetAPIMock, completedProducer)
+		h.DefaultProfile = handler.ProfileOrderOnly
+		h.OrderSource = order.NewLocal(map[string][]string{testCodeListID: {"Scotland", "Wales", "England"}})
+		h.Reports = report.NewStore(1)
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then no error is returned", func() {
+				So(err, ShouldBeNil)
+			})
+
+			validateNoGraphWrites(storerMock)
+
+			Convey("Then the graph database is not called", func() {
+				So(storerMock.GetCodesOrderCalls(), ShouldHaveLength, 0)
+				r, found := h.Reports.Get(testInstanceID)
+				So(found, ShouldBeTrue)
+				So(r.Summary().GraphCalls, ShouldEqual, 0)
+			})
+
+			Convey("Then dataset API is patched with the orders from the local source", func() {
+				scotland, wales, england := 0, 1, 2
+				calls := datasetAPIMock.PatchInstanceDimensionsCalls()
+				So(calls, ShouldHaveLength, 2)
+				So(calls[0].Updates, ShouldResemble, []*dataset.OptionUpdate{
+					{Name: d1Api.DimensionID, Option: d1Api.Option, Order: &england},
+					{Name: d2Api.DimensionID, Option: d2Api.Option, Order: &wales},
+				})
+				So(calls[1].Updates, ShouldResemble, []*dataset.OptionUpdate{
+					{Name: d3Api.DimensionID, Option: d3Api.Option, Order: &scotland},
+				})
+			})
+		})
+	})
+
+	Convey("Given a handler that maps a different instance type to the noop profile", t, func() {
+		storerMock := storerMockHappy()
+		h := setUp(storerMock, datasetAPIMockHappy(), completedProducerHappy())
+		h.InstanceTypeProfiles = map[string]handler.Profile{"cantabular_table": handler.ProfileNoop}
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then the instance is imported to the graph database", func() {
+				So(err, ShouldBeNil)
+				So(storerMock.CreateInstanceCalls(), ShouldHaveLength, 1)
+				So(storerMock.InsertDimensionCalls(), ShouldHaveLength, 3)
+			})
+		})
+	})
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ONSdigital/dp-dimension-importer/model"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Reorder recomputes the order of the dimension options of an already imported instance from the current codes order,
+// and patches it in dataset API in batches of size BatchSize, using the same logic as SetOrderAndNodeIDs.
+// Orders are obtained from the source used by the pipeline profile of the instance type, and node IDs are not patched,
+// so nothing is written to the graph database. Instances whose profile does not import any orders are ignored.
+func (hdlr *InstanceEventHandler) Reorder(ctx context.Context, instanceID string) error {
+	logData := log.Data{"instance_id": instanceID, "package": packageName}
+
+	var dimensions []*model.Dimension
+	var dimensionsETag string
+	var err error
+	if !hdlr.StreamDimensions {
+		if dimensions, dimensionsETag, err = hdlr.getDimensions(ctx, instanceID, nil); err != nil {
+			return err
+		}
+	}
+
+	instance, instanceETag, err := hdlr.DatasetAPICli.GetInstance(ctx, instanceID)
+	if err != nil {
+		return fmt.Errorf("dataset api client get instance returned an error: %w", err)
+	}
+	if err := ValidateInstance(instance); err != nil {
+		return err
+	}
+	tracker := newPatchTracker(dimensionsETag, false)
+	if dimensionsETag == "" {
+		tracker.SetETag(instanceETag)
+	}
+
+	profile := hdlr.ProfileFor(instance.Type())
+	logData["profile"] = profile
+	if profile == ProfileNoop {
+		log.Info(ctx, "pipeline profile does not import orders, the instance will not be reordered", logData)
+		return nil
+	}
+	source := hdlr.orderSource(profile)
+
+	fallbacks, err := hdlr.newFallbackOrders(ctx, dimensions, source, nil)
+	if err != nil {
+		return err
+	}
+	count := 0
+	patch := func(dimensions []*model.Dimension) error {
+		for start := 0; start < len(dimensions); start += hdlr.BatchSize {
+			end := min(start+hdlr.BatchSize, len(dimensions))
+			if err := hdlr.setOrderAndNodeIDs(ctx, instanceID, dimensions[start:end], source, fallbacks, false, tracker, nil); err != nil {
+				return err
+			}
+		}
+		count += len(dimensions)
+		return nil
+	}
+	if hdlr.StreamDimensions {
+		err = hdlr.streamDimensions(ctx, instance, nil, nil, patch)
+	} else {
+		err = patch(dimensions)
+	}
+	if err != nil {
+		return err
+	}
+
+	logData["dimensions_count"] = count
+	log.Info(ctx, "dimension options have been reordered", logData)
+	return nil
+}
@@ -0,0 +1,113 @@
+package handler_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
+	"github.com/ONSdigital/dp-dimension-importer/handler"
+	"github.com/ONSdigital/dp-dimension-importer/order"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInstanceEventHandler_Reorder(t *testing.T) {
+	Convey("Given a handler and an instance imported with the graph profile", t, func() {
+		storerMock := storerMockHappy()
+		datasetAPIMock := datasetAPIMockHappy()
+		completedProducer := completedProducerHappy()
+		h := setUp(storerMock, datasetAPIMock, completedProducer)
+		h.OrderSource = order.NewLocal(map[string][]string{testCodeListID: {"Scotland", "Wales", "England"}})
+
+		Convey("When the instance is reordered", func() {
+			err := h.Reorder(ctx, testInstanceID)
+
+			Convey("Then no error is returned", func() {
+				So(err, ShouldBeNil)
+			})
+
+			validateNoGraphWrites(storerMock)
+
+			Convey("Then the orders are obtained from the code lists in the graph database", func() {
+				So(storerMock.GetCodesOrderCalls(), ShouldHaveLength, 2)
+			})
+
+			Convey("Then dataset API is patched with the orders only, in batches", func() {
+				calls := datasetAPIMock.PatchInstanceDimensionsCalls()
+				So(calls, ShouldHaveLength, 2)
+				So(calls[0].Updates, ShouldResemble, []*dataset.OptionUpdate{
+					{Name: d1Api.DimensionID, Option: d1Api.Option, Order: &d1Order},
+					{Name: d2Api.DimensionID, Option: d2Api.Option, Order: &d2Order},
+				})
+				So(calls[1].Updates, ShouldBeEmpty)
+			})
+
+			Convey("Then no completion event is produced", func() {
+				So(completedProducer.CompletedCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a handler that streams the dimension options and an instance imported with the order-only profile and a local order source", t, func() {
+		storerMock := storerMockHappy()
+		datasetAPIMock := datasetAPIMockHappy()
+		setPagedDimensions(datasetAPIMock, d1Api, d2Api, d3Api)
+		h := setUp(storerMock, datasetAPIMock, completedProducerHappy())
+		h.StreamDimensions = true
+		h.DefaultProfile = handler.ProfileOrderOnly
+		h.OrderSource = order.NewLocal(map[string][]string{testCodeListID: {"Scotland", "Wales", "England"}})
+
+		Convey("When the instance is reordered", func() {
+			err := h.Reorder(ctx, testInstanceID)
+
+			Convey("Then dataset API is patched with the orders from the local source, one page at a time", func() {
+				So(err, ShouldBeNil)
+				So(storerMock.GetCodesOrderCalls(), ShouldBeEmpty)
+				scotland, wales, england := 0, 1, 2
+				calls := datasetAPIMock.PatchInstanceDimensionsCalls()
+				So(calls, ShouldHaveLength, 2)
+				So(calls[0].Updates, ShouldResemble, []*dataset.OptionUpdate{
+					{Name: d1Api.DimensionID, Option: d1Api.Option, Order: &england},
+					{Name: d2Api.DimensionID, Option: d2Api.Option, Order: &wales},
+				})
+				So(calls[1].Updates, ShouldResemble, []*dataset.OptionUpdate{
+					{Name: d3Api.DimensionID, Option: d3Api.Option, Order: &scotland},
+				})
+			})
+		})
+	})
+
+	Convey("Given a handler that maps the instance type to the noop profile", t, func() {
+		storerMock := storerMockHappy()
+		datasetAPIMock := datasetAPIMockHappy()
+		h := setUp(storerMock, datasetAPIMock, completedProducerHappy())
+		h.InstanceTypeProfiles = map[string]handler.Profile{testInstanceType: handler.ProfileNoop}
+
+		Convey("When the instance is reordered", func() {
+			err := h.Reorder(ctx, testInstanceID)
+
+			Convey("Then nothing is reordered", func() {
+				So(err, ShouldBeNil)
+				So(storerMock.GetCodesOrderCalls(), ShouldBeEmpty)
+				So(datasetAPIMock.PatchInstanceDimensionsCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a graph database that fails to return the codes order", t, func() {
+		storerMock := storerMockHappy()
+		storerMock.GetCodesOrderFunc = func(ctx context.Context, codeListID string, codes []string) (map[string]*int, error) {
+			return nil, errorMock
+		}
+		datasetAPIMock := datasetAPIMockHappy()
+		h := setUp(storerMock, datasetAPIMock, completedProducerHappy())
+
+		Convey("When the instance is reordered", func() {
+			err := h.Reorder(ctx, testInstanceID)
+
+			Convey("Then the error is returned and dataset API is not patched", func() {
+				So(err, ShouldWrap, errorMock)
+				So(datasetAPIMock.PatchInstanceDimensionsCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+}
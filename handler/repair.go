@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
+	"github.com/ONSdigital/dp-dimension-importer/model"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// RepairPlan describes the parts of an imported instance that are missing from the graph database,
+// or that differ from dataset API, and the kind of fix that each one needs
+type RepairPlan struct {
+	InstanceNode      bool                    // the instance node needs to be created
+	Dimensions        []*model.Dimension      // dimension options without dimension node, which need to be inserted along with their code relationship
+	CodeRelationships []*model.Dimension      // dimension options with a dimension node but without code relationship, which needs to be created
+	NodeIDs           []*dataset.OptionUpdate // node IDs of the existing dimension nodes, which need to be patched in dataset API
+	Constraint        bool                    // the observation constraint needs to be created
+}
+
+// IsEmpty returns true if there is nothing to repair
+func (p RepairPlan) IsEmpty() bool {
+	return !p.InstanceNode && len(p.Dimensions) == 0 && len(p.CodeRelationships) == 0 && len(p.NodeIDs) == 0 && !p.Constraint
+}
+
+// Repair fixes the parts of an already imported instance described by the provided plan, using the same steps as Handle:
+// the instance node is created if it does not exist, the missing dimension options are inserted to the graph database
+// and their order and node ID are patched in dataset API, and the observation constraint is created.
+// Existing dimension nodes are never inserted again, as that would drop their relationships to the observations:
+// only their missing code relationships are created, and their node IDs are patched in dataset API, as BackfillNodeIDs does.
+// No completed event is produced, as the instance had already been imported.
+func (hdlr *InstanceEventHandler) Repair(ctx context.Context, instance *model.Instance, plan RepairPlan) error {
+	if err := ValidateInstance(instance); err != nil {
+		return err
+	}
+	instanceID := instance.DBModel().InstanceID
+	log.Info(ctx, "repairing imported instance", log.Data{
+		"instance_id":        instanceID,
+		"instance_node":      plan.InstanceNode,
+		"dimensions":         len(plan.Dimensions),
+		"code_relationships": len(plan.CodeRelationships),
+		"node_ids":           len(plan.NodeIDs),
+		"constraint":         plan.Constraint,
+	})
+
+	if plan.InstanceNode {
+		if err := hdlr.createInstanceNode(ctx, instance, false, nil); err != nil && err != errInstanceExists {
+			return err
+		}
+		if err := hdlr.addDimensions(ctx, instance, nil); err != nil {
+			return err
+		}
+	}
+
+	if len(plan.Dimensions) > 0 {
+		if err := ValidateDimensions(plan.Dimensions); err != nil {
+			return err
+		}
+		if err := hdlr.insertDimensions(ctx, instance, plan.Dimensions, make(map[string]string), &sync.Mutex{}, nil, nil, nil); err != nil {
+			return err
+		}
+	}
+
+	for _, d := range plan.CodeRelationships {
+		if err := hdlr.Store.CreateCodeRelationship(ctx, instanceID, d.CodeListID(), d.DBModel().Option); err != nil {
+			return fmt.Errorf("error attempting to create relationship to code: %w", err)
+		}
+	}
+
+	if len(plan.NodeIDs) > 0 {
+		if err := hdlr.patchDimensionOptions(ctx, instanceID, plan.NodeIDs, nil); err != nil {
+			return fmt.Errorf("DatasetAPICli.PatchDimensionOption returned an error: %w", err)
+		}
+	}
+
+	if plan.Constraint {
+		if err := hdlr.createObservationConstraint(ctx, instance, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
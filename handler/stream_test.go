@@ -0,0 +1,158 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
+	"github.com/ONSdigital/dp-dimension-importer/handler"
+	"github.com/ONSdigital/dp-dimension-importer/mocks"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// setPagedDimensions makes the dataset API mock return the provided dimension options in pages, according to the requested offset and limit
+func setPagedDimensions(datasetAPIMock *mocks.IClientMock, items ...dataset.Dimension) {
+	setPagedDimensionsWithOrder(datasetAPIMock, nil, items...)
+}
+
+// setPagedDimensionsWithOrder makes the dataset API mock return the provided dimension options in pages, with the provided order for each option value
+func setPagedDimensionsWithOrder(datasetAPIMock *mocks.IClientMock, orders map[string]*int, items ...dataset.Dimension) {
+	type option struct {
+		dataset.Dimension
+		Order *int `json:"order,omitempty"`
+	}
+	datasetAPIMock.GetInstanceDimensionsBytesFunc = func(ctx context.Context, serviceAuthToken string, instanceID string, q *dataset.QueryParams, ifMatch string) ([]byte, string, error) {
+		page := struct {
+			Items      []option `json:"items"`
+			TotalCount int      `json:"total_count"`
+		}{Items: []option{}, TotalCount: len(items)}
+		for i := q.Offset; i < min(q.Offset+q.Limit, len(items)); i++ {
+			page.Items = append(page.Items, option{items[i], orders[items[i].Option]})
+		}
+		b, err := json.Marshal(page)
+		return b, "", err
+	}
+}
+
+func TestInstanceEventHandler_Handle_StreamDimensions(t *testing.T) {
+	Convey("Given a handler that streams the dimension options in pages of 2 options", t, func() {
+		storerMock := storerMockHappy()
+		datasetAPIMock := datasetAPIMockHappy()
+		setPagedDimensions(datasetAPIMock, d1Api, d2Api, d3Api)
+		completedProducer := completedProducerHappy()
+		h := setUp(storerMock, datasetAPIMock, completedProducer)
+		h.StreamDimensions = true
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then no error is returned", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then the dimension options are requested one page at a time, instead of all at once", func() {
+				So(datasetAPIMock.GetInstanceDimensionsInBatchesCalls(), ShouldHaveLength, 0)
+				calls := datasetAPIMock.GetInstanceDimensionsBytesCalls()
+				So(calls, ShouldHaveLength, 2)
+				So(calls[0].Q, ShouldResemble, &dataset.QueryParams{Offset: 0, Limit: testBatchSize})
+				So(calls[1].Q, ShouldResemble, &dataset.QueryParams{Offset: 2, Limit: testBatchSize})
+			})
+
+			Convey("Then every option is inserted to the graph database and patched in dataset API, one patch per page", func() {
+				validateStorerInsertDimensionCalls(storerMock, 3, testInstanceID, d1.DBModel(), d2.DBModel(), d3.DBModel())
+				So(datasetAPIMock.PatchInstanceDimensionsCalls(), ShouldHaveLength, 2)
+				So(storerMock.AddDimensionsCalls(), ShouldHaveLength, 1)
+				So(storerMock.CreateInstanceConstraintCalls(), ShouldHaveLength, 1)
+			})
+
+			Convey("Then the completed event is produced", func() {
+				So(completedProducer.CompletedCalls(), ShouldHaveLength, 1)
+				So(withoutDuration(completedProducer.CompletedCalls()[0].E), ShouldResemble, instanceCompleted)
+			})
+		})
+	})
+
+	Convey("Given a handler that streams the dimension options and an option that is repeated in a later page", t, func() {
+		storerMock := storerMockHappy()
+		datasetAPIMock := datasetAPIMockHappy()
+		setPagedDimensions(datasetAPIMock, d1Api, d2Api, d1Api)
+		h := setUp(storerMock, datasetAPIMock, completedProducerHappy())
+		h.StreamDimensions = true
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then a validation error listing the duplicate option is returned", func() {
+				var validationErr *handler.ValidationError
+				So(errors.As(err, &validationErr), ShouldBeTrue)
+				So(validationErr.Options, ShouldResemble, []handler.InvalidOption{
+					{DimensionID: d1Api.DimensionID, Option: d1Api.Option, Reason: "is a duplicate option"},
+				})
+			})
+
+			Convey("Then only the first page is imported", func() {
+				So(storerMock.InsertDimensionCalls(), ShouldHaveLength, 2)
+				So(datasetAPIMock.PatchInstanceDimensionsCalls(), ShouldHaveLength, 1)
+				So(storerMock.AddDimensionsCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+
+	Convey("Given a handler that streams the dimension options and a page with a dimension that is missing from the csv header", t, func() {
+		other := dataset.Dimension{DimensionID: "1234567890_Sex", Option: "Male", Links: d1Api.Links}
+		storerMock := storerMockHappy()
+		datasetAPIMock := datasetAPIMockHappy()
+		setPagedDimensions(datasetAPIMock, d1Api, d2Api, other)
+		h := setUp(storerMock, datasetAPIMock, completedProducerHappy())
+		h.StreamDimensions = true
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then the csv header mismatch error is returned before the page is imported", func() {
+				So(errors.Is(err, handler.ErrCSVHeaderMismatch), ShouldBeTrue)
+				So(storerMock.InsertDimensionCalls(), ShouldHaveLength, 2)
+			})
+		})
+	})
+
+	Convey("Given a handler that streams the dimension options and an instance without options", t, func() {
+		storerMock := storerMockHappy()
+		datasetAPIMock := datasetAPIMockHappy()
+		setPagedDimensions(datasetAPIMock)
+		h := setUp(storerMock, datasetAPIMock, completedProducerHappy())
+		h.StreamDimensions = true
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then the expected validation error is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "dimensions validation error: dimension array is required but is nil or empty")
+			})
+		})
+	})
+
+	Convey("Given a handler that streams the dimension options and maps the instance type to the order_only profile", t, func() {
+		storerMock := storerMockHappy()
+		datasetAPIMock := datasetAPIMockHappy()
+		setPagedDimensions(datasetAPIMock, d1Api, d2Api, d3Api)
+		h := setUp(storerMock, datasetAPIMock, completedProducerHappy())
+		h.StreamDimensions = true
+		h.InstanceTypeProfiles = map[string]handler.Profile{testInstanceType: handler.ProfileOrderOnly}
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then the orders are patched one page at a time", func() {
+				So(err, ShouldBeNil)
+				So(datasetAPIMock.GetInstanceDimensionsBytesCalls(), ShouldHaveLength, 2)
+				So(datasetAPIMock.PatchInstanceDimensionsCalls(), ShouldHaveLength, 2)
+			})
+
+			validateNoGraphWrites(storerMock)
+		})
+	})
+}
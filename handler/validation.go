@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ONSdigital/dp-dimension-importer/model"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// ErrCSVHeaderMismatch is returned when the dimensions defined by the CSV header of an instance do not match its dimension options
+var ErrCSVHeaderMismatch = errors.New("csv header does not match the instance dimensions")
+
+// OptionPolicy defines the constraints that the value of every dimension option must satisfy
+type OptionPolicy struct {
+	MaxLength         int            // maximum number of characters of an option value, 0 means no limit
+	AllowedCharacters *regexp.Regexp // pattern that option values must fully match, nil means any value is allowed
+}
+
+// NewOptionPolicy creates an OptionPolicy from the provided maximum length and allowed characters pattern.
+// The pattern is anchored so that it must match the whole option value. An empty pattern allows any value.
+func NewOptionPolicy(maxLength int, allowedPattern string) (OptionPolicy, error) {
+	policy := OptionPolicy{MaxLength: maxLength}
+	if allowedPattern == "" {
+		return policy, nil
+	}
+	re, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", allowedPattern))
+	if err != nil {
+		return OptionPolicy{}, fmt.Errorf("invalid allowed characters pattern: %w", err)
+	}
+	policy.AllowedCharacters = re
+	return policy, nil
+}
+
+// InvalidOption describes a dimension option that failed validation, and the reason why
+type InvalidOption struct {
+	DimensionID string
+	Option      string
+	Reason      string
+}
+
+func (o InvalidOption) String() string {
+	return fmt.Sprintf("%s:%q %s", o.DimensionID, o.Option, o.Reason)
+}
+
+// ValidationError is returned when one or more dimension options are invalid. It lists every offending option.
+type ValidationError struct {
+	Options []InvalidOption
+}
+
+func (e *ValidationError) Error() string {
+	descriptions := make([]string, len(e.Options))
+	for i, o := range e.Options {
+		descriptions[i] = o.String()
+	}
+	return fmt.Sprintf("dimension options validation error: %d invalid options: [%s]", len(e.Options), strings.Join(descriptions, "; "))
+}
+
+// ValidateDimensionOptions checks that every dimension option has a value and a code list link,
+// that no (dimension, option) pair is repeated, and that the option values satisfy the provided policy.
+// All the dimensions are validated, and the returned *ValidationError lists every offending option.
+// This method assumes that non-nil dimensions are provided (validated by ValidateDimensions)
+func ValidateDimensionOptions(dimensions []*model.Dimension, policy OptionPolicy) error {
+	return newOptionValidator(policy).validate(dimensions)
+}
+
+// optionValidator validates dimension options as described in ValidateDimensionOptions,
+// keeping track of the options it has already seen, so that duplicates are detected across multiple calls to validate.
+type optionValidator struct {
+	policy OptionPolicy
+	seen   map[string]struct{}
+}
+
+func newOptionValidator(policy OptionPolicy) *optionValidator {
+	return &optionValidator{policy: policy, seen: map[string]struct{}{}}
+}
+
+func (v *optionValidator) validate(dimensions []*model.Dimension) error {
+	invalid := []InvalidOption{}
+
+	for _, d := range dimensions {
+		dimensionID, option := d.DBModel().DimensionID, d.DBModel().Option
+		addInvalid := func(reason string) {
+			invalid = append(invalid, InvalidOption{DimensionID: dimensionID, Option: option, Reason: reason})
+		}
+
+		if err := d.Validate(); err != nil {
+			addInvalid(err.Error())
+			continue
+		}
+
+		key := optionKey(dimensionID, option)
+		if _, found := v.seen[key]; found {
+			addInvalid("is a duplicate option")
+		}
+		v.seen[key] = struct{}{}
+
+		if d.CodeListID() == "" {
+			addInvalid("code list link is required but was empty")
+		}
+		if v.policy.MaxLength > 0 && utf8.RuneCountInString(option) > v.policy.MaxLength {
+			addInvalid(fmt.Sprintf("exceeds the maximum length of %d characters", v.policy.MaxLength))
+		}
+		if v.policy.AllowedCharacters != nil && !v.policy.AllowedCharacters.MatchString(option) {
+			addInvalid("contains characters that are not allowed")
+		}
+	}
+
+	if len(invalid) > 0 {
+		return &ValidationError{Options: invalid}
+	}
+	return nil
+}
+
+// ValidateCSVHeader checks that the dimensions defined by the V4 CSV header of the instance match the dimensions of the provided options:
+// every dimension in the header must have at least one option, and no option can belong to a dimension that is missing from the header.
+// Dimension IDs are compared case-insensitively, ignoring any instance ID prefix.
+// Instances without a CSV header are not checked, and a warning is logged.
+// This method assumes that valid non-nil values are provided (validated by ValidateInstance and ValidateDimensions)
+func ValidateCSVHeader(instance *model.Instance, dimensions []*model.Dimension) error {
+	v, err := newCSVHeaderValidator(context.Background(), instance)
+	if err != nil {
+		return err
+	}
+	v.add(dimensions)
+	return v.validate(true)
+}
+
+// csvHeaderValidator checks the dimensions of an instance against its V4 CSV header, as described in ValidateCSVHeader,
+// allowing the dimension options to be provided in multiple calls to add.
+// Its methods can be called on a nil csvHeaderValidator, in which case nothing is checked.
+type csvHeaderValidator struct {
+	instanceID  string
+	headerNames []string
+	inHeader    map[string]bool // header dimension names, true if at least one option has been added for them
+	notInHeader []string
+}
+
+// newCSVHeaderValidator returns the validator of the CSV header of the provided instance,
+// or nil if the instance has no CSV header, logging a warning as its dimensions cannot be checked
+func newCSVHeaderValidator(ctx context.Context, instance *model.Instance) (*csvHeaderValidator, error) {
+	if len(instance.DBModel().CSVHeader) == 0 {
+		log.Warn(ctx, "instance has no csv header, its dimensions will not be checked against it", log.Data{
+			"instance_id": instance.DBModel().InstanceID,
+			"package":     packageName,
+		})
+		return nil, nil
+	}
+	headerNames, err := model.DimensionNamesFromV4Header(instance.DBModel().CSVHeader)
+	if err != nil {
+		return nil, fmt.Errorf("csv header validation error: %w", err)
+	}
+
+	inHeader := make(map[string]bool, len(headerNames))
+	for _, name := range headerNames {
+		inHeader[name] = false
+	}
+	return &csvHeaderValidator{
+		instanceID:  instance.DBModel().InstanceID,
+		headerNames: headerNames,
+		inHeader:    inHeader,
+		notInHeader: []string{},
+	}, nil
+}
+
+// add records the dimensions of the provided options
+func (v *csvHeaderValidator) add(dimensions []*model.Dimension) {
+	if v == nil {
+		return
+	}
+	for _, d := range dimensions {
+		name := strings.ToLower(strings.TrimPrefix(d.DBModel().DimensionID, v.instanceID+"_"))
+		if _, found := v.inHeader[name]; !found {
+			if !contains(v.notInHeader, name) {
+				v.notInHeader = append(v.notInHeader, name)
+			}
+			continue
+		}
+		v.inHeader[name] = true
+	}
+}
+
+// validate returns an error if any of the options added so far belongs to a dimension that is missing from the header.
+// If all the options have been added (complete is true), it also returns an error if any header dimension does not have options.
+func (v *csvHeaderValidator) validate(complete bool) error {
+	if v == nil {
+		return nil
+	}
+	withoutOptions := []string{}
+	if complete {
+		for _, name := range v.headerNames {
+			if !v.inHeader[name] {
+				withoutOptions = append(withoutOptions, name)
+			}
+		}
+	}
+
+	if len(withoutOptions) == 0 && len(v.notInHeader) == 0 {
+		return nil
+	}
+	return fmt.Errorf("csv header validation error: %w: header dimensions without options: [%s], option dimensions missing from header: [%s]",
+		ErrCSVHeaderMismatch, strings.Join(withoutOptions, ", "), strings.Join(v.notInHeader, ", "))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package handler_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
+	"github.com/ONSdigital/dp-dimension-importer/handler"
+	"github.com/ONSdigital/dp-dimension-importer/model"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newTestDimension(dimensionID, option, codeListID string) *model.Dimension {
+	return model.NewDimension(&dataset.Dimension{
+		DimensionID: dimensionID,
+		Option:      option,
+		Links:       dataset.Links{CodeList: dataset.Link{ID: codeListID}},
+	})
+}
+
+func TestNewOptionPolicy(t *testing.T) {
+	Convey("Given a valid pattern, NewOptionPolicy returns an anchored policy", t, func() {
+		policy, err := handler.NewOptionPolicy(10, "[a-zA-Z0-9 ]+")
+		So(err, ShouldBeNil)
+		So(policy.MaxLength, ShouldEqual, 10)
+		So(policy.AllowedCharacters.MatchString("England 2021"), ShouldBeTrue)
+		So(policy.AllowedCharacters.MatchString("England!"), ShouldBeFalse)
+	})
+
+	Convey("Given an empty pattern, NewOptionPolicy returns a policy that allows any character", t, func() {
+		policy, err := handler.NewOptionPolicy(0, "")
+		So(err, ShouldBeNil)
+		So(policy.AllowedCharacters, ShouldBeNil)
+	})
+
+	Convey("Given an invalid pattern, NewOptionPolicy returns an error", t, func() {
+		_, err := handler.NewOptionPolicy(0, "[a-z")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestValidateDimensionOptions(t *testing.T) {
+	Convey("Given a list of valid dimension options", t, func() {
+		dimensions := []*model.Dimension{
+			newTestDimension("geography", "K02000001", "geography-codelist"),
+			newTestDimension("geography", "K02000002", "geography-codelist"),
+			newTestDimension("time", "2021", "time-codelist"),
+		}
+
+		Convey("Then ValidateDimensionOptions succeeds with the default policy", func() {
+			So(handler.ValidateDimensionOptions(dimensions, handler.OptionPolicy{}), ShouldBeNil)
+		})
+	})
+
+	Convey("Given a list of dimension options with several problems", t, func() {
+		dimensions := []*model.Dimension{
+			newTestDimension("geography", "K02000001", "geography-codelist"),
+			newTestDimension("geography", "", "geography-codelist"),
+			newTestDimension("geography", "K02000001", "geography-codelist"),
+			newTestDimension("time", "2021", ""),
+			newTestDimension("time", "2021-Q1!", "time-codelist"),
+		}
+		policy, err := handler.NewOptionPolicy(9, "[A-Za-z0-9-]*")
+		So(err, ShouldBeNil)
+
+		Convey("When ValidateDimensionOptions is called", func() {
+			err := handler.ValidateDimensionOptions(dimensions, policy)
+
+			Convey("Then a ValidationError listing every offending option is returned", func() {
+				var validationErr *handler.ValidationError
+				So(errors.As(err, &validationErr), ShouldBeTrue)
+				So(validationErr.Options, ShouldResemble, []handler.InvalidOption{
+					{DimensionID: "geography", Option: "", Reason: "dimension value is required but was empty"},
+					{DimensionID: "geography", Option: "K02000001", Reason: "is a duplicate option"},
+					{DimensionID: "time", Option: "2021", Reason: "code list link is required but was empty"},
+					{DimensionID: "time", Option: "2021-Q1!", Reason: "contains characters that are not allowed"},
+				})
+				So(err.Error(), ShouldEqual, `dimension options validation error: 4 invalid options: [`+
+					`geography:"" dimension value is required but was empty; `+
+					`geography:"K02000001" is a duplicate option; `+
+					`time:"2021" code list link is required but was empty; `+
+					`time:"2021-Q1!" contains characters that are not allowed]`)
+			})
+		})
+	})
+
+	Convey("Given a dimension option that exceeds the maximum length", t, func() {
+		dimensions := []*model.Dimension{newTestDimension("geography", "Englandshire", "geography-codelist")}
+
+		Convey("Then ValidateDimensionOptions returns the expected error", func() {
+			err := handler.ValidateDimensionOptions(dimensions, handler.OptionPolicy{MaxLength: 7})
+			So(err.Error(), ShouldEqual, `dimension options validation error: 1 invalid options: [geography:"Englandshire" exceeds the maximum length of 7 characters]`)
+		})
+	})
+}
+
+func TestValidateCSVHeader(t *testing.T) {
+	newTestInstance := func(header ...string) *model.Instance {
+		return model.NewInstance(&dataset.Instance{Version: dataset.Version{ID: "inst1", CSVHeader: header}})
+	}
+
+	Convey("Given an instance whose header matches the dimension options", t, func() {
+		i := newTestInstance("V4_1", "Data Marking", "mmm-yy", "Time", "uk-only", "Geography")
+		dimensions := []*model.Dimension{
+			newTestDimension("time", "Jan-21", "mmm-yy"),
+			newTestDimension("inst1_Geography", "K02000001", "uk-only"),
+			newTestDimension("geography", "K02000002", "uk-only"),
+		}
+
+		Convey("Then ValidateCSVHeader succeeds", func() {
+			So(handler.ValidateCSVHeader(i, dimensions), ShouldBeNil)
+		})
+	})
+
+	Convey("Given an instance whose header does not match the dimension options", t, func() {
+		i := newTestInstance("V4_0", "mmm-yy", "Time", "uk-only", "Geography", "sex", "Sex")
+		dimensions := []*model.Dimension{
+			newTestDimension("time", "Jan-21", "mmm-yy"),
+			newTestDimension("aggregate", "cpih1dim1A0", "cpih1dim1aggid"),
+			newTestDimension("aggregate", "cpih1dim1A1", "cpih1dim1aggid"),
+		}
+
+		Convey("Then ValidateCSVHeader returns a descriptive error", func() {
+			err := handler.ValidateCSVHeader(i, dimensions)
+			So(errors.Is(err, handler.ErrCSVHeaderMismatch), ShouldBeTrue)
+			So(err.Error(), ShouldEqual, "csv header validation error: csv header does not match the instance dimensions: "+
+				"header dimensions without options: [geography, sex], option dimensions missing from header: [aggregate]")
+		})
+	})
+
+	Convey("Given an instance without header", t, func() {
+		i := newTestInstance()
+
+		Convey("Then ValidateCSVHeader skips the check and succeeds", func() {
+			So(handler.ValidateCSVHeader(i, []*model.Dimension{newTestDimension("time", "Jan-21", "mmm-yy")}), ShouldBeNil)
+		})
+	})
+
+	Convey("Given an instance with a header that is not in V4 format", t, func() {
+		i := newTestInstance("the", "CSV", "header")
+
+		Convey("Then ValidateCSVHeader returns an invalid header error", func() {
+			err := handler.ValidateCSVHeader(i, []*model.Dimension{newTestDimension("time", "Jan-21", "mmm-yy")})
+			So(errors.Is(err, model.ErrInvalidV4Header), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
+	"github.com/ONSdigital/dp-dimension-importer/model"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// VerificationMode defines what is done once the dimension options of an instance have been patched in dataset API
+type VerificationMode string
+
+// Available verification modes
+const (
+	// VerifyOff does not verify the patched values
+	VerifyOff VerificationMode = "off"
+	// VerifyRepair reads back the patched values, patches again any that differ, and fails the import if they still differ
+	VerifyRepair VerificationMode = "repair"
+	// VerifyFail reads back the patched values, and fails the import if any of them differ
+	VerifyFail VerificationMode = "fail"
+)
+
+// PatchDifference describes a dimension option whose values in dataset API differ from the values that were patched.
+// Only the values that were patched are compared: an empty ExpectedNodeID or a nil ExpectedOrder mean that value was not patched.
+type PatchDifference struct {
+	DimensionID    string
+	Option         string
+	Missing        bool // the option does not exist in dataset API
+	ExpectedNodeID string
+	ActualNodeID   string
+	ExpectedOrder  *int
+	ActualOrder    *int
+}
+
+func (d PatchDifference) String() string {
+	if d.Missing {
+		return fmt.Sprintf("%s:%q is missing", d.DimensionID, d.Option)
+	}
+	diffs := []string{}
+	if d.ExpectedNodeID != "" && d.ExpectedNodeID != d.ActualNodeID {
+		diffs = append(diffs, fmt.Sprintf("node_id is %q instead of %q", d.ActualNodeID, d.ExpectedNodeID))
+	}
+	if d.ExpectedOrder != nil && !sameOrder(d.ExpectedOrder, d.ActualOrder) {
+		diffs = append(diffs, fmt.Sprintf("order is %s instead of %s", formatOrder(d.ActualOrder), formatOrder(d.ExpectedOrder)))
+	}
+	return fmt.Sprintf("%s:%q %s", d.DimensionID, d.Option, strings.Join(diffs, ", "))
+}
+
+// PatchVerificationError is returned when the values of one or more dimension options in dataset API differ from the values that were patched.
+// It lists every difference.
+type PatchVerificationError struct {
+	Differences []PatchDifference
+}
+
+func (e *PatchVerificationError) Error() string {
+	descriptions := make([]string, len(e.Differences))
+	for i, d := range e.Differences {
+		descriptions[i] = d.String()
+	}
+	return fmt.Sprintf("patch verification error: %d options differ from the patched values: [%s]", len(e.Differences), strings.Join(descriptions, "; "))
+}
+
+// verifyPatchesEnabled returns true if the patched values need to be verified
+func (hdlr *InstanceEventHandler) verifyPatchesEnabled() bool {
+	return hdlr.PatchVerification == VerifyRepair || hdlr.PatchVerification == VerifyFail
+}
+
+// verifyPatches reads back the dimension options of the instance from dataset API and compares their node_id and order values
+// with the values recorded by the tracker. If PatchVerification is VerifyRepair, the options that differ are patched again and verified once more.
+// A *PatchVerificationError listing the remaining differences is returned, if any.
+func (hdlr *InstanceEventHandler) verifyPatches(ctx context.Context, instanceID string, tracker *patchTracker) error {
+	patched := tracker.Patched()
+	differences, err := hdlr.diffPatched(ctx, instanceID, patched)
+	if err != nil {
+		return err
+	}
+
+	if len(differences) > 0 && hdlr.PatchVerification == VerifyRepair {
+		repairs := []*dataset.OptionUpdate{}
+		for _, d := range differences {
+			if !d.Missing {
+				repairs = append(repairs, patched[optionKey(d.DimensionID, d.Option)])
+			}
+		}
+		log.Warn(ctx, "dimension options differ from the patched values, repairing them", log.Data{
+			"instance_id": instanceID,
+			"differences": len(differences),
+			"repairs":     len(repairs),
+		})
+		if len(repairs) > 0 {
+			if err := hdlr.patchDimensionOptions(ctx, instanceID, repairs, tracker); err != nil {
+				return fmt.Errorf("failed to repair the patched dimension options: %w", err)
+			}
+			if differences, err = hdlr.diffPatched(ctx, instanceID, patched); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(differences) > 0 {
+		return &PatchVerificationError{Differences: differences}
+	}
+	log.Info(ctx, "patched dimension options have been verified", log.Data{"instance_id": instanceID, "options": len(patched)})
+	return nil
+}
+
+// diffPatched reads the dimension options of the instance from dataset API, one page at a time,
+// and returns the differences with the provided patched values, sorted by dimension and option.
+func (hdlr *InstanceEventHandler) diffPatched(ctx context.Context, instanceID string, patched map[string]*dataset.OptionUpdate) ([]PatchDifference, error) {
+	differences := []PatchDifference{}
+	found := make(map[string]struct{}, len(patched))
+
+	err := hdlr.DatasetAPICli.StreamDimensions(ctx, instanceID, func(dimensions []*model.Dimension) error {
+		for _, d := range dimensions {
+			key := optionKey(d.DBModel().DimensionID, d.DBModel().Option)
+			expected, isPatched := patched[key]
+			if !isPatched {
+				continue
+			}
+			found[key] = struct{}{}
+
+			nodeIDDiffers := expected.NodeID != "" && expected.NodeID != d.DBModel().NodeID
+			orderDiffers := expected.Order != nil && !sameOrder(expected.Order, d.Order)
+			if nodeIDDiffers || orderDiffers {
+				differences = append(differences, PatchDifference{
+					DimensionID:    expected.Name,
+					Option:         expected.Option,
+					ExpectedNodeID: expected.NodeID,
+					ActualNodeID:   d.DBModel().NodeID,
+					ExpectedOrder:  expected.Order,
+					ActualOrder:    d.Order,
+				})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read back the patched dimension options: %w", err)
+	}
+
+	for key, expected := range patched {
+		if _, ok := found[key]; !ok {
+			differences = append(differences, PatchDifference{DimensionID: expected.Name, Option: expected.Option, Missing: true})
+		}
+	}
+	sort.Slice(differences, func(i, j int) bool {
+		if differences[i].DimensionID != differences[j].DimensionID {
+			return differences[i].DimensionID < differences[j].DimensionID
+		}
+		return differences[i].Option < differences[j].Option
+	})
+	return differences, nil
+}
+
+func sameOrder(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func formatOrder(o *int) string {
+	if o == nil {
+		return "empty"
+	}
+	return strconv.Itoa(*o)
+}
@@ -0,0 +1,150 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
+	"github.com/ONSdigital/dp-dimension-importer/handler"
+	"github.com/ONSdigital/dp-dimension-importer/mocks"
+	"github.com/ONSdigital/dp-dimension-importer/report"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// patchedOrders are the orders patched by the default storer mock
+var patchedOrders = map[string]*int{"England": &d1Order, "Wales": &d2Order}
+
+// setReadBack makes the dataset API mock return the provided options when they are read back,
+// and the fixed options once more than numPatches patches have been sent
+func setReadBack(datasetAPIMock *mocks.IClientMock, numPatches int, orders map[string]*int, items []dataset.Dimension, fixed ...dataset.Dimension) {
+	broken, repaired := &mocks.IClientMock{}, &mocks.IClientMock{}
+	setPagedDimensionsWithOrder(broken, orders, items...)
+	setPagedDimensionsWithOrder(repaired, patchedOrders, fixed...)
+	datasetAPIMock.GetInstanceDimensionsBytesFunc = func(ctx context.Context, serviceAuthToken string, instanceID string, q *dataset.QueryParams, ifMatch string) ([]byte, string, error) {
+		if len(fixed) > 0 && len(datasetAPIMock.PatchInstanceDimensionsCalls()) > numPatches {
+			return repaired.GetInstanceDimensionsBytes(ctx, serviceAuthToken, instanceID, q, ifMatch)
+		}
+		return broken.GetInstanceDimensionsBytes(ctx, serviceAuthToken, instanceID, q, ifMatch)
+	}
+}
+
+func TestInstanceEventHandler_Handle_PatchVerification(t *testing.T) {
+	Convey("Given a handler that verifies the patched values and a dataset api that stored all of them", t, func() {
+		datasetAPIMock := datasetAPIMockHappy()
+		setPagedDimensionsWithOrder(datasetAPIMock, patchedOrders, d1Api, d2Api, d3Api)
+		completedProducer := completedProducerHappy()
+		h := setUp(storerMockHappy(), datasetAPIMock, completedProducer)
+		h.PatchVerification = handler.VerifyFail
+		h.Reports = report.NewStore(1)
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then the options are read back and no error is returned", func() {
+				So(err, ShouldBeNil)
+				So(datasetAPIMock.GetInstanceDimensionsBytesCalls(), ShouldNotBeEmpty)
+				So(completedProducer.CompletedCalls(), ShouldHaveLength, 1)
+			})
+
+			Convey("Then the verification stage is recorded in the report", func() {
+				r, _ := h.Reports.Get(testInstanceID)
+				stages := []string{}
+				for _, s := range r.Summary().Stages {
+					stages = append(stages, s.Name)
+				}
+				So(stages, ShouldContain, report.StageVerifyPatches)
+			})
+		})
+	})
+
+	Convey("Given a handler that fails on differences and a dataset api that lost an order and an option", t, func() {
+		datasetAPIMock := datasetAPIMockHappy()
+		setPagedDimensionsWithOrder(datasetAPIMock, map[string]*int{"England": &d1Order}, d1Api, d2Api)
+		completedProducer := completedProducerHappy()
+		h := setUp(storerMockHappy(), datasetAPIMock, completedProducer)
+		h.PatchVerification = handler.VerifyFail
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then a verification error listing the differences is returned", func() {
+				var verificationErr *handler.PatchVerificationError
+				So(errors.As(err, &verificationErr), ShouldBeTrue)
+				So(verificationErr.Differences, ShouldResemble, []handler.PatchDifference{
+					{DimensionID: d3Api.DimensionID, Option: d3Api.Option, Missing: true},
+					{DimensionID: d2Api.DimensionID, Option: d2Api.Option, ExpectedNodeID: "2", ActualNodeID: "2", ExpectedOrder: &d2Order},
+				})
+				So(err.Error(), ShouldEqual, `patch verification error: 2 options differ from the patched values: `+
+					`[1234567890_Geography:"Scotland" is missing; 1234567890_Geography:"Wales" order is empty instead of 1]`)
+			})
+
+			Convey("Then nothing is repaired and the completed event is not produced", func() {
+				So(datasetAPIMock.PatchInstanceDimensionsCalls(), ShouldHaveLength, 2)
+				So(completedProducer.CompletedCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+
+	Convey("Given a handler that repairs differences and a dataset api that lost a node ID until it is patched again", t, func() {
+		d2WithoutNodeID := d2Api
+		d2WithoutNodeID.NodeID = ""
+		datasetAPIMock := datasetAPIMockHappy()
+		setReadBack(datasetAPIMock, 2, patchedOrders, []dataset.Dimension{d1Api, d2WithoutNodeID, d3Api}, d1Api, d2Api, d3Api)
+		completedProducer := completedProducerHappy()
+		h := setUp(storerMockHappy(), datasetAPIMock, completedProducer)
+		h.PatchVerification = handler.VerifyRepair
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then only the option that differs is patched again with the expected values", func() {
+				So(err, ShouldBeNil)
+				calls := datasetAPIMock.PatchInstanceDimensionsCalls()
+				So(calls, ShouldHaveLength, 3)
+				So(calls[2].Updates, ShouldResemble, []*dataset.OptionUpdate{
+					{Name: d2Api.DimensionID, Option: d2Api.Option, NodeID: d2Api.NodeID, Order: &d2Order},
+				})
+			})
+
+			Convey("Then the completed event is produced", func() {
+				So(completedProducer.CompletedCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+
+	Convey("Given a handler that repairs differences and a dataset api that never stores a node ID", t, func() {
+		d2WithoutNodeID := d2Api
+		d2WithoutNodeID.NodeID = ""
+		datasetAPIMock := datasetAPIMockHappy()
+		setReadBack(datasetAPIMock, 2, patchedOrders, []dataset.Dimension{d1Api, d2WithoutNodeID, d3Api})
+		h := setUp(storerMockHappy(), datasetAPIMock, completedProducerHappy())
+		h.PatchVerification = handler.VerifyRepair
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then the option is patched again and a verification error is returned", func() {
+				var verificationErr *handler.PatchVerificationError
+				So(errors.As(err, &verificationErr), ShouldBeTrue)
+				So(verificationErr.Differences, ShouldHaveLength, 1)
+				So(verificationErr.Differences[0].String(), ShouldEqual, `1234567890_Geography:"Wales" node_id is "" instead of "2"`)
+				So(datasetAPIMock.PatchInstanceDimensionsCalls(), ShouldHaveLength, 3)
+			})
+		})
+	})
+
+	Convey("Given a handler that does not verify the patched values", t, func() {
+		datasetAPIMock := datasetAPIMockHappy()
+		h := setUp(storerMockHappy(), datasetAPIMock, completedProducerHappy())
+
+		Convey("When a valid event is handled", func() {
+			err := h.Handle(ctx, newInstance)
+
+			Convey("Then the options are not read back", func() {
+				So(err, ShouldBeNil)
+				So(datasetAPIMock.GetInstanceDimensionsBytesCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+}
@@ -0,0 +1,142 @@
+package idempotency
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileStore is a MemoryStore whose changes are also appended to a local file, one JSON entry per line, so that they are kept when the service restarts.
+// The file is compacted to the records within the window when the store is created, and whenever it holds twice as many entries as the store size.
+// The file is only read when the store is created, so it must not be shared with other processes.
+type FileStore struct {
+	*MemoryStore
+	path    string
+	entries int // number of entries in the file
+}
+
+// entry is a line of the idempotency file: either a record, or the deletion of the records of an instance
+type entry struct {
+	*Record
+	DeletedInstance string `json:"deleted_instance,omitempty"`
+}
+
+// NewFileStore creates a new FileStore that keeps up to size records for the provided window,
+// loading the records of the provided file if it exists. The file is created when the first record is added.
+func NewFileStore(path string, size int, window time.Duration) (*FileStore, error) {
+	s := &FileStore{MemoryStore: NewMemoryStore(size, window), path: path}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read idempotency file: %w", err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	scanner.Buffer(make([]byte, 64*1024), len(b)+1)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("invalid idempotency file %s, line %d: %w", path, line, err)
+		}
+		s.apply(e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read idempotency file: %w", err)
+	}
+
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Put records the result of the processing of an event, replacing any previous record with the same key,
+// and appends it to the file
+func (s *FileStore) Put(r Record) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.put(r)
+	return s.append(entry{Record: &r})
+}
+
+// DeleteInstance removes the records of the events of the provided instance, and appends their deletion to the file
+func (s *FileStore) DeleteInstance(instanceID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.deleteInstance(instanceID)
+	return s.append(entry{DeletedInstance: instanceID})
+}
+
+// apply adds the record of the provided entry, or deletes the records of its instance. The caller must hold the write lock.
+func (s *FileStore) apply(e entry) {
+	if e.Record != nil && e.Key != "" {
+		s.put(*e.Record)
+	}
+	if e.DeletedInstance != "" {
+		s.deleteInstance(e.DeletedInstance)
+	}
+}
+
+// append adds the provided entry at the end of the file, compacting it if it holds too many entries. The caller must hold the write lock.
+func (s *FileStore) append(e entry) error {
+	if s.entries >= 2*s.size {
+		return s.compact()
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write idempotency file: %w", err)
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write idempotency file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write idempotency file: %w", err)
+	}
+	s.entries++
+	return nil
+}
+
+// compact replaces the content of the file with the records within the window. The caller must hold the write lock.
+func (s *FileStore) compact() error {
+	records := s.list()
+	var buf bytes.Buffer
+	for i := range records {
+		b, err := json.Marshal(entry{Record: &records[i]})
+		if err != nil {
+			return err
+		}
+		buf.Write(append(b, '\n'))
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write idempotency file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write idempotency file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write idempotency file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write idempotency file: %w", err)
+	}
+	s.entries = len(records)
+	return nil
+}
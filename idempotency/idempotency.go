@@ -0,0 +1,67 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// The results of the processing of an event
+const (
+	ResultSucceeded = "succeeded"
+	ResultFailed    = "failed"
+)
+
+// Record is the result of the processing of an event
+type Record struct {
+	Key         string    `json:"key"`
+	Result      string    `json:"result"`
+	Error       string    `json:"error,omitempty"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+// Succeeded returns true if the event has been processed successfully
+func (r Record) Succeeded() bool {
+	return r.Result == ResultSucceeded
+}
+
+// Store records the result of the processing of the consumed events, so that an event delivered more than once can be skipped
+type Store interface {
+	// Lock blocks until no other worker is processing the event with the provided key, and returns the function that releases it,
+	// so that checking whether an event has been processed, processing it and recording its result is atomic across workers
+	Lock(key string) (unlock func())
+	// Get returns the record of the event with the provided key, if it has been processed
+	Get(key string) (Record, bool)
+	// Put records the result of the processing of an event, replacing any previous record with the same key
+	Put(r Record) error
+	// DeleteInstance removes the records of the events of the provided instance, so that they can be processed again
+	DeleteInstance(instanceID string) error
+}
+
+// Repeatable is implemented by the events that must be processed every time they are delivered, even if an identical event has been processed,
+// such as the events requesting to import an instance again
+type Repeatable interface {
+	Repeatable() bool
+}
+
+// Key returns the key of the provided event of an instance: the event type and the instance ID,
+// followed by the hex encoded SHA-256 hash of the JSON encoding of the event, so that two identical events have the same key
+func Key(eventType, instanceID string, e interface{}) (string, error) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(b)
+	return eventType + ":" + instanceID + ":" + hex.EncodeToString(hash[:]), nil
+}
+
+// keyInstanceID returns the instance ID of the provided key
+func keyInstanceID(key string) string {
+	first, last := strings.Index(key, ":"), strings.LastIndex(key, ":")
+	if first < 0 || first == last {
+		return ""
+	}
+	return key[first+1 : last]
+}
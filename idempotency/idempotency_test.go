@@ -0,0 +1,216 @@
+package idempotency_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-dimension-importer/event"
+	"github.com/ONSdigital/dp-dimension-importer/idempotency"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func record(key, result string, processedAt time.Time) idempotency.Record {
+	return idempotency.Record{Key: key, Result: result, ProcessedAt: processedAt}
+}
+
+func TestKey(t *testing.T) {
+	Convey("Given two identical events and a different event of the same instance", t, func() {
+		e := event.NewInstance{InstanceID: "instance1", FileURL: "s3://bucket/file.csv"}
+		forced := e
+		forced.Force = true
+
+		Convey("Then the identical events have the same key, prefixed with the event type and instance ID", func() {
+			k1, err := idempotency.Key(event.TypeNewInstance, e.InstanceID, e)
+			So(err, ShouldBeNil)
+			k2, _ := idempotency.Key(event.TypeNewInstance, e.InstanceID, e)
+			So(k1, ShouldEqual, k2)
+			So(k1, ShouldStartWith, "dimensions-extracted:instance1:")
+		})
+
+		Convey("Then the different event has a different key", func() {
+			k1, _ := idempotency.Key(event.TypeNewInstance, e.InstanceID, e)
+			k2, _ := idempotency.Key(event.TypeNewInstance, e.InstanceID, forced)
+			So(k1, ShouldNotEqual, k2)
+		})
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	Convey("Given a memory store of 2 records", t, func() {
+		s := idempotency.NewMemoryStore(2, time.Hour)
+		now := time.Now()
+
+		Convey("When a record is put", func() {
+			So(s.Put(record("a", idempotency.ResultSucceeded, now)), ShouldBeNil)
+
+			Convey("Then it can be retrieved by its key", func() {
+				r, found := s.Get("a")
+				So(found, ShouldBeTrue)
+				So(r.Succeeded(), ShouldBeTrue)
+			})
+
+			Convey("Then a record with the same key replaces it", func() {
+				So(s.Put(record("a", idempotency.ResultFailed, now)), ShouldBeNil)
+				r, _ := s.Get("a")
+				So(r.Succeeded(), ShouldBeFalse)
+				So(s.Records(), ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When more records than its size are put", func() {
+			s.Put(record("a", idempotency.ResultSucceeded, now))
+			s.Put(record("b", idempotency.ResultSucceeded, now))
+			s.Put(record("c", idempotency.ResultSucceeded, now))
+
+			Convey("Then the oldest record is evicted", func() {
+				_, found := s.Get("a")
+				So(found, ShouldBeFalse)
+				So(s.Records(), ShouldResemble, []idempotency.Record{
+					record("b", idempotency.ResultSucceeded, now),
+					record("c", idempotency.ResultSucceeded, now),
+				})
+			})
+		})
+
+		Convey("When the records of an instance are deleted", func() {
+			s.Put(record("dimensions-extracted:instance1:a", idempotency.ResultSucceeded, now))
+			s.Put(record("dimensions-extracted:instance2:b", idempotency.ResultSucceeded, now))
+			So(s.DeleteInstance("instance1"), ShouldBeNil)
+
+			Convey("Then only the records of the other instances are kept", func() {
+				_, found := s.Get("dimensions-extracted:instance1:a")
+				So(found, ShouldBeFalse)
+				So(s.Records(), ShouldResemble, []idempotency.Record{
+					record("dimensions-extracted:instance2:b", idempotency.ResultSucceeded, now),
+				})
+			})
+		})
+
+		Convey("When a record older than the window is put", func() {
+			s.Put(record("a", idempotency.ResultSucceeded, now.Add(-2*time.Hour)))
+
+			Convey("Then it is ignored", func() {
+				_, found := s.Get("a")
+				So(found, ShouldBeFalse)
+				So(s.Records(), ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestMemoryStore_Lock(t *testing.T) {
+	Convey("Given a memory store whose lock of a key is held", t, func() {
+		s := idempotency.NewMemoryStore(2, time.Hour)
+		unlock := s.Lock("a")
+
+		Convey("When another worker locks the same key", func() {
+			locked := make(chan struct{})
+			go func() {
+				s.Lock("a")()
+				close(locked)
+			}()
+
+			Convey("Then it waits until the lock is released", func() {
+				select {
+				case <-locked:
+					t.Error("the lock has been acquired twice")
+				case <-time.After(50 * time.Millisecond):
+				}
+				unlock()
+				<-locked
+			})
+		})
+
+		Convey("When another worker locks a different key", func() {
+			locked := make(chan struct{})
+			go func() {
+				s.Lock("b")()
+				close(locked)
+			}()
+
+			Convey("Then it does not wait", func() {
+				select {
+				case <-locked:
+				case <-time.After(time.Second):
+					t.Error("the lock of a different key has not been acquired")
+				}
+				unlock()
+			})
+		})
+	})
+}
+
+func TestFileStore(t *testing.T) {
+	Convey("Given a file store without file", t, func() {
+		path := filepath.Join(t.TempDir(), "processed.json")
+		s, err := idempotency.NewFileStore(path, 10, time.Hour)
+		So(err, ShouldBeNil)
+		now := time.Now().UTC().Truncate(time.Second)
+
+		Convey("When records are put", func() {
+			So(s.Put(record("a", idempotency.ResultSucceeded, now)), ShouldBeNil)
+			So(s.Put(record("b", idempotency.ResultFailed, now)), ShouldBeNil)
+
+			Convey("Then a new store created from the same file has the same records", func() {
+				reloaded, err := idempotency.NewFileStore(path, 10, time.Hour)
+				So(err, ShouldBeNil)
+				So(reloaded.Records(), ShouldResemble, s.Records())
+				r, found := reloaded.Get("a")
+				So(found, ShouldBeTrue)
+				So(r.Succeeded(), ShouldBeTrue)
+			})
+		})
+
+		Convey("When records are put and the records of an instance are deleted", func() {
+			So(s.Put(record("dimensions-extracted:instance1:a", idempotency.ResultSucceeded, now)), ShouldBeNil)
+			So(s.Put(record("dimensions-extracted:instance2:b", idempotency.ResultSucceeded, now)), ShouldBeNil)
+			So(s.DeleteInstance("instance1"), ShouldBeNil)
+
+			Convey("Then each change is appended to the file as a line", func() {
+				b, err := os.ReadFile(path)
+				So(err, ShouldBeNil)
+				So(strings.Split(strings.TrimSpace(string(b)), "\n"), ShouldHaveLength, 3)
+			})
+
+			Convey("Then a new store created from the same file does not have the deleted records, and compacts the file", func() {
+				reloaded, err := idempotency.NewFileStore(path, 10, time.Hour)
+				So(err, ShouldBeNil)
+				So(reloaded.Records(), ShouldResemble, []idempotency.Record{
+					record("dimensions-extracted:instance2:b", idempotency.ResultSucceeded, now),
+				})
+				b, err := os.ReadFile(path)
+				So(err, ShouldBeNil)
+				So(strings.Split(strings.TrimSpace(string(b)), "\n"), ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When twice as many records as its size are put", func() {
+			small, err := idempotency.NewFileStore(path, 2, time.Hour)
+			So(err, ShouldBeNil)
+			for _, key := range []string{"a", "b", "c", "d", "e"} {
+				So(small.Put(record(key, idempotency.ResultSucceeded, now)), ShouldBeNil)
+			}
+
+			Convey("Then the file is compacted to the records of the store", func() {
+				b, err := os.ReadFile(path)
+				So(err, ShouldBeNil)
+				So(strings.Split(strings.TrimSpace(string(b)), "\n"), ShouldHaveLength, 2)
+				reloaded, err := idempotency.NewFileStore(path, 2, time.Hour)
+				So(err, ShouldBeNil)
+				So(reloaded.Records(), ShouldResemble, small.Records())
+			})
+		})
+
+		Convey("When the file is invalid", func() {
+			So(os.WriteFile(path, []byte("not json"), 0o600), ShouldBeNil)
+			_, err := idempotency.NewFileStore(path, 10, time.Hour)
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
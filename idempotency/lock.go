@@ -0,0 +1,39 @@
+package idempotency
+
+import "sync"
+
+// keyLocks serialises the processing of the events with the same key, keeping a lock only while it is held or awaited
+type keyLocks struct {
+	mutex sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	sync.Mutex
+	holders int // number of workers holding or waiting for the lock
+}
+
+// lock blocks until the lock of the provided key is free, and returns the function that releases it
+func (l *keyLocks) lock(key string) func() {
+	l.mutex.Lock()
+	if l.locks == nil {
+		l.locks = map[string]*keyLock{}
+	}
+	k, found := l.locks[key]
+	if !found {
+		k = &keyLock{}
+		l.locks[key] = k
+	}
+	k.holders++
+	l.mutex.Unlock()
+
+	k.Lock()
+	return func() {
+		k.Unlock()
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		if k.holders--; k.holders == 0 {
+			delete(l.locks, key)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package idempotency
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the records of the most recently processed events in memory.
+// Once the store is full, the oldest record is evicted to make room for a new one, and records older than the window are ignored.
+type MemoryStore struct {
+	mutex   sync.RWMutex
+	size    int
+	window  time.Duration
+	records map[string]Record
+	order   []string
+	locks   keyLocks
+}
+
+// NewMemoryStore creates a new MemoryStore that keeps up to size records, for the provided window
+func NewMemoryStore(size int, window time.Duration) *MemoryStore {
+	if size < 1 {
+		size = 1
+	}
+	return &MemoryStore{
+		size:    size,
+		window:  window,
+		records: make(map[string]Record, size),
+	}
+}
+
+// Lock blocks until no other worker holds the lock of the provided key, and returns the function that releases it
+func (s *MemoryStore) Lock(key string) func() {
+	return s.locks.lock(key)
+}
+
+// Get returns the record of the event with the provided key, if it has been processed within the window
+func (s *MemoryStore) Get(key string) (Record, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	r, found := s.records[key]
+	if !found || s.expired(r) {
+		return Record{}, false
+	}
+	return r, true
+}
+
+// Put records the result of the processing of an event, replacing any previous record with the same key
+func (s *MemoryStore) Put(r Record) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.put(r)
+	return nil
+}
+
+// DeleteInstance removes the records of the events of the provided instance
+func (s *MemoryStore) DeleteInstance(instanceID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.deleteInstance(instanceID)
+	return nil
+}
+
+// Records returns the records within the window, from the oldest to the most recently added record
+func (s *MemoryStore) Records() []Record {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.list()
+}
+
+// put adds the provided record, evicting the oldest records if the store is full. The caller must hold the write lock.
+func (s *MemoryStore) put(r Record) {
+	if _, found := s.records[r.Key]; found {
+		s.remove(r.Key)
+	}
+	for len(s.order) >= s.size {
+		delete(s.records, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.records[r.Key] = r
+	s.order = append(s.order, r.Key)
+}
+
+// list returns the records within the window, in the order they have been added. The caller must hold the lock.
+func (s *MemoryStore) list() []Record {
+	records := make([]Record, 0, len(s.order))
+	for _, key := range s.order {
+		if r := s.records[key]; !s.expired(r) {
+			records = append(records, r)
+		}
+	}
+	return records
+}
+
+// deleteInstance removes the records of the events of the provided instance. The caller must hold the write lock.
+func (s *MemoryStore) deleteInstance(instanceID string) {
+	order := s.order[:0]
+	for _, key := range s.order {
+		if keyInstanceID(key) == instanceID {
+			delete(s.records, key)
+			continue
+		}
+		order = append(order, key)
+	}
+	s.order = order
+}
+
+// expired returns true if the provided record is older than the window
+func (s *MemoryStore) expired(r Record) bool {
+	return time.Since(r.ProcessedAt) > s.window
+}
+
+// remove deletes the record with the provided key. The caller must hold the write lock.
+func (s *MemoryStore) remove(key string) {
+	delete(s.records, key)
+	for i, k := range s.order {
+		if k == key {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			return
+		}
+	}
+}
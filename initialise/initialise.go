@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/ONSdigital/dp-dimension-importer/schema"
 	"github.com/ONSdigital/dp-dimension-importer/store"
 
 	"github.com/ONSdigital/dp-dimension-importer/config"
+	"github.com/ONSdigital/dp-dimension-importer/idempotency"
 	"github.com/ONSdigital/dp-graph/v2/graph"
 	"github.com/ONSdigital/dp-healthcheck/healthcheck"
 	kafka "github.com/ONSdigital/dp-kafka/v2"
@@ -15,11 +17,15 @@ import (
 
 // ExternalServiceList represents a list of services
 type ExternalServiceList struct {
-	InstanceConsumer         bool
-	InstanceCompleteProducer bool
-	ErrorReporterProducer    bool
-	GraphDB                  bool
-	HealthCheck              bool
+	InstanceConsumer          bool
+	InstanceDeletedConsumer   bool
+	InstanceCompleteProducer  bool
+	ErrorReporterProducer     bool
+	ImportReportProducer      bool
+	ImportFailedProducer      bool
+	DimensionsDeletedProducer bool
+	GraphDB                   bool
+	HealthCheck               bool
 }
 
 // KafkaProducerName represents a type for kafka producer name used by iota constants
@@ -29,9 +35,12 @@ type KafkaProducerName int
 const (
 	InstanceComplete = iota
 	ErrorReporter
+	ImportReport
+	ImportFailed
+	DimensionsDeleted
 )
 
-var kafkaProducerNames = []string{"InstanceComplete", "ErrorReporter"}
+var kafkaProducerNames = []string{"InstanceComplete", "ErrorReporter", "ImportReport", "ImportFailed", "DimensionsDeleted"}
 
 // Values of the kafka producers names
 func (k KafkaProducerName) String() string {
@@ -40,6 +49,26 @@ func (k KafkaProducerName) String() string {
 
 // GetConsumer returns a kafka consumer, which might not be initialised
 func (e *ExternalServiceList) GetConsumer(ctx context.Context, kafkaConfig config.KafkaConfig) (kafkaConsumer *kafka.ConsumerGroup, err error) {
+	consumer, err := newConsumer(ctx, kafkaConfig.IncomingInstancesTopic, kafkaConfig.IncomingInstancesConsumerGroup, kafkaConfig)
+	if err != nil {
+		return nil, err
+	}
+	e.InstanceConsumer = true
+	return consumer, nil
+}
+
+// GetInstanceDeletedConsumer returns a kafka consumer of the instance deleted events, which might not be initialised
+func (e *ExternalServiceList) GetInstanceDeletedConsumer(ctx context.Context, kafkaConfig config.KafkaConfig) (kafkaConsumer *kafka.ConsumerGroup, err error) {
+	consumer, err := newConsumer(ctx, kafkaConfig.InstanceDeletedTopic, kafkaConfig.InstanceDeletedConsumerGroup, kafkaConfig)
+	if err != nil {
+		return nil, err
+	}
+	e.InstanceDeletedConsumer = true
+	return consumer, nil
+}
+
+// newConsumer returns a kafka consumer of the provided topic, which might not be initialised
+func newConsumer(ctx context.Context, topic, consumerGroup string, kafkaConfig config.KafkaConfig) (*kafka.ConsumerGroup, error) {
 	cgChannels := kafka.CreateConsumerGroupChannels(1)
 
 	kafkaOffset := kafka.OffsetNewest
@@ -60,19 +89,15 @@ func (e *ExternalServiceList) GetConsumer(ctx context.Context, kafkaConfig confi
 		)
 	}
 
-	consumer, err := kafka.NewConsumerGroup(
-		ctx, kafkaConfig.Brokers, kafkaConfig.IncomingInstancesTopic, kafkaConfig.IncomingInstancesConsumerGroup, cgChannels, cgConfig)
-
+	consumer, err := kafka.NewConsumerGroup(ctx, kafkaConfig.Brokers, topic, consumerGroup, cgChannels, cgConfig)
 	if err != nil {
 		log.Fatal(ctx, "new kafka consumer group returned an error", err, log.Data{
 			"brokers":        kafkaConfig.Brokers,
-			"topic":          kafkaConfig.IncomingInstancesTopic,
-			"consumer_group": kafkaConfig.IncomingInstancesConsumerGroup,
+			"topic":          topic,
+			"consumer_group": consumerGroup,
 		})
 		return nil, err
 	}
-
-	e.InstanceConsumer = true
 	return consumer, nil
 }
 
@@ -103,6 +128,12 @@ func (e *ExternalServiceList) GetProducer(ctx context.Context, topic string, nam
 		e.InstanceCompleteProducer = true
 	case name == ErrorReporter:
 		e.ErrorReporterProducer = true
+	case name == ImportReport:
+		e.ImportReportProducer = true
+	case name == ImportFailed:
+		e.ImportFailedProducer = true
+	case name == DimensionsDeleted:
+		e.DimensionsDeletedProducer = true
 	default:
 		return producer, fmt.Errorf("kafka producer name not recognised: '%s'. valid names: %v", name.String(), kafkaProducerNames)
 	}
@@ -122,6 +153,55 @@ func (e *ExternalServiceList) GetGraphDB(ctx context.Context) (store.Storer, err
 	return graphDB, nil
 }
 
+// GetGraphReader returns a reader of the nodes created by the imports in the provided graph DB, which must be backed by neptune
+func (e *ExternalServiceList) GetGraphReader(graphDB store.Storer) (store.GraphReader, error) {
+	db, ok := graphDB.(*graph.DB)
+	if !ok {
+		return nil, store.ErrNotNeptune
+	}
+	return store.NewNeptuneReader(db)
+}
+
+// GetInstanceDeleter returns a deleter of the nodes created by the imports in the provided graph DB, which must be backed by neptune
+func (e *ExternalServiceList) GetInstanceDeleter(graphDB store.Storer) (store.InstanceDeleter, error) {
+	db, ok := graphDB.(*graph.DB)
+	if !ok {
+		return nil, store.ErrNotNeptune
+	}
+	return store.NewNeptuneDeleter(db)
+}
+
+// GetSchemaRegistry returns the schema registry of the kafka messages, or nil if none is configured.
+// The failures of a schema registry server are remembered for the configured backoff, so that it is not called for every message while it is down.
+func (e *ExternalServiceList) GetSchemaRegistry(cfg *config.Config) schema.Registry {
+	switch {
+	case cfg.SchemaRegistryURL != "":
+		registry := schema.NewHTTPRegistry(cfg.SchemaRegistryURL, cfg.SchemaRegistryTimeout)
+		return schema.NewBackoffRegistry(registry, cfg.SchemaRegistryBackoff, cfg.SchemaRegistryMaxBackoff)
+	case cfg.SchemaRegistryFile != "":
+		return schema.NewFileRegistry(cfg.SchemaRegistryFile)
+	default:
+		return nil
+	}
+}
+
+// GetIdempotencyStore returns the store of the processed events, or nil if idempotency is not enabled.
+// The store is file-backed if an idempotency file is configured, and only kept in memory otherwise.
+func (e *ExternalServiceList) GetIdempotencyStore(cfg *config.Config) (idempotency.Store, error) {
+	switch {
+	case !cfg.IdempotencyEnabled:
+		return nil, nil
+	case cfg.IdempotencyFile != "":
+		fileStore, err := idempotency.NewFileStore(cfg.IdempotencyFile, cfg.IdempotencyStoreSize, cfg.IdempotencyWindow)
+		if err != nil {
+			return nil, err
+		}
+		return fileStore, nil
+	default:
+		return idempotency.NewMemoryStore(cfg.IdempotencyStoreSize, cfg.IdempotencyWindow), nil
+	}
+}
+
 // GetHealthChecker creates a new healthcheck object
 func (e *ExternalServiceList) GetHealthChecker(ctx context.Context, buildTime, gitCommit, version string, cfg *config.Config) (*healthcheck.HealthCheck, error) {
 	versionInfo, err := healthcheck.NewVersionInfo(buildTime, gitCommit, version)
@@ -4,50 +4,93 @@ import (
 	"context"
 
 	"github.com/ONSdigital/dp-dimension-importer/event"
+	"github.com/ONSdigital/dp-dimension-importer/idempotency"
 	"github.com/ONSdigital/dp-dimension-importer/schema"
 	kafka "github.com/ONSdigital/dp-kafka/v2"
 	"github.com/ONSdigital/dp-reporter-client/reporter"
 	"github.com/ONSdigital/log.go/v2/log"
 )
 
-//go:generate moq -out mock/instance_event_handler.go -pkg mock . InstanceEventHandler
+//go:generate moq -out mock/instance_event_handler.go -pkg mock . InstanceEventHandler InstanceDeletedEventHandler
 
 // InstanceEventHandler handles a event.NewInstance
 type InstanceEventHandler interface {
 	Handle(ctx context.Context, e event.NewInstance) error
 }
 
-// KafkaMessageReceiver is a Receiver for handling incoming kafka messages
+// InstanceDeletedEventHandler handles a event.InstanceDeleted
+type InstanceDeletedEventHandler interface {
+	Handle(ctx context.Context, e event.InstanceDeleted) error
+}
+
+// Unmarshaller defines a type for unmarshalling a message into the requested object.
+type Unmarshaller interface {
+	Unmarshal(message []byte, s interface{}) error
+}
+
+// ContextUnmarshaller is an Unmarshaller that can use the context of the message, e.g. to call a schema registry
+type ContextUnmarshaller interface {
+	UnmarshalContext(ctx context.Context, message []byte, s interface{}) error
+}
+
+// unmarshal unmarshals the message with the provided context if the Unmarshaller supports it
+func unmarshal(ctx context.Context, unmarshaller Unmarshaller, message []byte, s interface{}) error {
+	if u, ok := unmarshaller.(ContextUnmarshaller); ok {
+		return u.UnmarshalContext(ctx, message, s)
+	}
+	return unmarshaller.Unmarshal(message, s)
+}
+
+// KafkaMessageReceiver is a Receiver for handling incoming kafka messages.
+// The event type of each message is given by its EventTypeHeader, and the messages without it have the DefaultEventType.
+// NewInstance events are passed to the InstanceHandler, unless a route is registered for them, and the events of the other types to their route.
 type KafkaMessageReceiver struct {
-	InstanceHandler InstanceEventHandler
-	ErrorReporter   reporter.ErrorReporter
+	InstanceHandler  InstanceEventHandler
+	ErrorReporter    reporter.ErrorReporter
+	Unmarshaller     Unmarshaller      // defaults to schema.NewInstanceSchema if nil
+	Routes           map[string]Route  // routes of the events by event type
+	DefaultEventType string            // defaults to event.TypeNewInstance if empty
+	Processed        idempotency.Store // skips the events already processed successfully, if not nil
 }
 
-// OnMessage unmarshal the kafka message and pass it to the InstanceEventHandler any errors are sent to the ErrorReporter
+// OnMessage unmarshal the kafka message and pass it to the handler of its event type, any errors are sent to the ErrorReporter.
+// Messages with an event type without handler are rejected, and only logged. The events that have already been processed successfully
+// are skipped if there is an idempotency store.
 func (r KafkaMessageReceiver) OnMessage(message kafka.Message) {
-	logData := log.Data{"package": "message.KafkaMessageReceiver"}
-	var newInstanceEvent event.NewInstance
+	eventType := message.GetHeader(EventTypeHeader)
+	if eventType == "" {
+		eventType = r.DefaultEventType
+	}
+	if eventType == "" {
+		eventType = event.TypeNewInstance
+	}
+	logData := log.Data{"package": "message.KafkaMessageReceiver", "event_type": eventType}
 
 	// This context will come from the received kafka message
 	ctx := context.Background()
 
-	// unmarshal the event
-	if err := schema.NewInstanceSchema.Unmarshal(message.GetData(), &newInstanceEvent); err != nil {
-		log.Error(ctx, "error while attempting to unmarshal kafka message into event new instance", err, logData)
+	route, ok := r.route(eventType)
+	if !ok {
+		log.Error(ctx, "rejected kafka message", ErrUnknownEventType, logData)
 		return
 	}
+	route.dispatch(ctx, eventType, message.GetData(), r.ErrorReporter, r.Processed, logData)
+}
 
-	logData["event"] = newInstanceEvent
-	log.Info(ctx, "successfully unmarshalled kafka message into event new instance", logData)
-
-	// handle event by the provided handler
-	if err := r.InstanceHandler.Handle(ctx, newInstanceEvent); err != nil {
-		log.Error(ctx, "instance handler handle returned an error", err, logData)
-		if err := r.ErrorReporter.Notify(newInstanceEvent.InstanceID, "InstanceHandler.Handle returned an unexpected error", err); err != nil {
-			log.Error(ctx, "error reporter notify returned an error", err, logData)
-		}
-		return
+// route returns the route of the provided event type
+func (r KafkaMessageReceiver) route(eventType string) (Route, bool) {
+	if route, ok := r.Routes[eventType]; ok {
+		return route, true
+	}
+	if eventType != event.TypeNewInstance || r.InstanceHandler == nil {
+		return Route{}, false
 	}
 
-	log.Info(ctx, "new instance event successfully processed", logData)
+	var unmarshaller Unmarshaller = schema.NewInstanceSchema
+	if r.Unmarshaller != nil {
+		unmarshaller = r.Unmarshaller
+	}
+	return NewRoute("InstanceHandler", unmarshaller, r.InstanceHandler, func(e event.NewInstance) string {
+		return e.InstanceID
+	}), true
 }
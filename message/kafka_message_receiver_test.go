@@ -3,9 +3,13 @@ package message_test
 import (
 	"context"
 	"errors"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/ONSdigital/dp-dimension-importer/event"
+	"github.com/ONSdigital/dp-dimension-importer/idempotency"
 	"github.com/ONSdigital/dp-dimension-importer/message"
 	"github.com/ONSdigital/dp-dimension-importer/message/mock"
 	"github.com/ONSdigital/dp-dimension-importer/schema"
@@ -111,6 +115,276 @@ func TestKafkaMessageHandler_Handle_InstanceHandlerError(t *testing.T) {
 	})
 }
 
+func TestKafkaMessageHandler_Handle_Unmarshaller(t *testing.T) {
+	newInstanceEvent := event.NewInstance{
+		FileURL:    "/A/B/C/D",
+		InstanceID: "1234567890",
+	}
+	codec := schema.NewRegistryCodec(schema.NewInstanceSchema, schema.NewFileRegistry(filepath.Join(t.TempDir(), "registry.json")), "dimensions-extracted-value")
+	messageBytes, err := codec.Marshal(newInstanceEvent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fix := newFixture(messageBytes, func(e event.NewInstance) error {
+		return nil
+	})
+
+	Convey("Given KafkaMessageReceiver has been configured with a schema registry unmarshaller", t, func() {
+		handler := message.KafkaMessageReceiver{
+			InstanceHandler: fix.instanceHandler,
+			ErrorReporter:   fix.errorReporter,
+			Unmarshaller:    codec,
+		}
+
+		Convey("When OnMessage is called with a message framed with its schema ID", func() {
+			handler.OnMessage(fix.message)
+
+			Convey("Then InstanceHandler.OnMessage is called 1 time with the decoded event", func() {
+				So(len(fix.instanceHdlrCalls), ShouldEqual, 1)
+				So(fix.instanceHdlrCalls[0], ShouldResemble, newInstanceEvent)
+			})
+		})
+	})
+}
+
+func TestKafkaMessageReceiver_OnMessage_Routes(t *testing.T) {
+	instanceDeletedEvent := event.InstanceDeleted{
+		InstanceID: "1234567890",
+		TraceID:    "trace1",
+	}
+	avroBytes, _ := schema.InstanceDeletedSchema.Marshal(instanceDeletedEvent)
+	typeHeader := func(eventType string) kafkatest.TestHeader {
+		return kafkatest.TestHeader{message.EventTypeHeader: eventType}
+	}
+
+	Convey("Given KafkaMessageReceiver with a route for instance deleted events", t, func() {
+		fix := newFixture(nil, func(e event.NewInstance) error { return nil })
+		deletedHandler := &mock.InstanceDeletedEventHandlerMock{
+			HandleFunc: func(ctx context.Context, e event.InstanceDeleted) error {
+				return nil
+			},
+		}
+		receiver := message.KafkaMessageReceiver{
+			InstanceHandler: fix.instanceHandler,
+			ErrorReporter:   fix.errorReporter,
+			Routes: map[string]message.Route{
+				event.TypeInstanceDeleted: message.NewRoute("InstanceDeletedHandler", schema.InstanceDeletedSchema, deletedHandler,
+					func(e event.InstanceDeleted) string { return e.InstanceID }),
+			},
+		}
+
+		Convey("When OnMessage is called with a message of the instance deleted event type", func() {
+			receiver.OnMessage(kafkatest.NewMessage(avroBytes, 0, typeHeader(event.TypeInstanceDeleted)))
+
+			Convey("Then the route handler is called 1 time with the decoded event, and the instance handler is never called", func() {
+				So(deletedHandler.HandleCalls(), ShouldHaveLength, 1)
+				So(deletedHandler.HandleCalls()[0].E, ShouldResemble, instanceDeletedEvent)
+				So(fix.instanceHandler.HandleCalls(), ShouldHaveLength, 0)
+				So(fix.errorReporter.NotifyCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When OnMessage is called with a message of an unknown event type", func() {
+			receiver.OnMessage(kafkatest.NewMessage(avroBytes, 0, typeHeader("unknown")))
+
+			Convey("Then the message is rejected without calling any handler or the ErrorReporter", func() {
+				So(deletedHandler.HandleCalls(), ShouldHaveLength, 0)
+				So(fix.instanceHandler.HandleCalls(), ShouldHaveLength, 0)
+				So(fix.errorReporter.NotifyCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When OnMessage is called with a message without event type", func() {
+			newInstanceBytes, _ := schema.NewInstanceSchema.Marshal(event.NewInstance{InstanceID: "1234567890", FileURL: "/A/B/C/D"})
+			receiver.OnMessage(kafkatest.NewMessage(newInstanceBytes, 0))
+
+			Convey("Then the message is handled as a new instance event", func() {
+				So(fix.instanceHandler.HandleCalls(), ShouldHaveLength, 1)
+				So(deletedHandler.HandleCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When the route handler returns an error", func() {
+			deletedHandler.HandleFunc = func(ctx context.Context, e event.InstanceDeleted) error {
+				return errors.New("boom!")
+			}
+			receiver.OnMessage(kafkatest.NewMessage(avroBytes, 0, typeHeader(event.TypeInstanceDeleted)))
+
+			Convey("Then ErrorReporter.Notify is called 1 time with the expected parameters", func() {
+				So(fix.errorReporter.NotifyCalls(), ShouldHaveLength, 1)
+				So(fix.errorReporter.NotifyCalls()[0].ID, ShouldEqual, "1234567890")
+				So(fix.errorReporter.NotifyCalls()[0].ErrContext, ShouldEqual, "InstanceDeletedHandler.Handle returned an unexpected error")
+			})
+		})
+	})
+
+	Convey("Given KafkaMessageReceiver with instance deleted as default event type and no instance handler", t, func() {
+		deletedHandler := &mock.InstanceDeletedEventHandlerMock{
+			HandleFunc: func(ctx context.Context, e event.InstanceDeleted) error {
+				return nil
+			},
+		}
+		errorReporter := reportertest.NewImportErrorReporterMock(nil)
+		receiver := message.KafkaMessageReceiver{
+			ErrorReporter: errorReporter,
+			Routes: map[string]message.Route{
+				event.TypeInstanceDeleted: message.NewRoute("InstanceDeletedHandler", schema.InstanceDeletedSchema, deletedHandler,
+					func(e event.InstanceDeleted) string { return e.InstanceID }),
+			},
+			DefaultEventType: event.TypeInstanceDeleted,
+		}
+
+		Convey("When OnMessage is called with a message without event type", func() {
+			receiver.OnMessage(kafkatest.NewMessage(avroBytes, 0))
+
+			Convey("Then the message is handled as an instance deleted event", func() {
+				So(deletedHandler.HandleCalls(), ShouldHaveLength, 1)
+				So(deletedHandler.HandleCalls()[0].E, ShouldResemble, instanceDeletedEvent)
+			})
+		})
+
+		Convey("When OnMessage is called with a message of the new instance event type", func() {
+			receiver.OnMessage(kafkatest.NewMessage(avroBytes, 0, typeHeader(event.TypeNewInstance)))
+
+			Convey("Then the message is rejected", func() {
+				So(deletedHandler.HandleCalls(), ShouldHaveLength, 0)
+				So(errorReporter.NotifyCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+}
+
+func TestKafkaMessageReceiver_OnMessage_Idempotency(t *testing.T) {
+	newInstanceEvent := event.NewInstance{
+		FileURL:    "/A/B/C/D",
+		InstanceID: "1234567890",
+	}
+	avroBytes, _ := schema.NewInstanceSchema.Marshal(newInstanceEvent)
+
+	Convey("Given KafkaMessageReceiver with an idempotency store", t, func() {
+		handleErr := errors.New("boom!")
+		var result error
+		fix := newFixture(avroBytes, func(e event.NewInstance) error { return result })
+		processed := idempotency.NewMemoryStore(10, time.Hour)
+		receiver := message.KafkaMessageReceiver{
+			InstanceHandler: fix.instanceHandler,
+			ErrorReporter:   fix.errorReporter,
+			Processed:       processed,
+		}
+
+		Convey("When the same message is received twice", func() {
+			receiver.OnMessage(fix.message)
+			receiver.OnMessage(kafkatest.NewMessage(avroBytes, 1))
+
+			Convey("Then the event is only handled once, and its result is recorded", func() {
+				So(fix.instanceHandler.HandleCalls(), ShouldHaveLength, 1)
+				records := processed.Records()
+				So(records, ShouldHaveLength, 1)
+				So(records[0].Key, ShouldStartWith, "dimensions-extracted:1234567890:")
+				So(records[0].Result, ShouldEqual, idempotency.ResultSucceeded)
+			})
+		})
+
+		Convey("When the same message is received twice, and the first one fails", func() {
+			result = handleErr
+			receiver.OnMessage(fix.message)
+			result = nil
+			receiver.OnMessage(kafkatest.NewMessage(avroBytes, 1))
+
+			Convey("Then the event is handled again, and the successful result replaces the failure", func() {
+				So(fix.instanceHandler.HandleCalls(), ShouldHaveLength, 2)
+				So(fix.errorReporter.NotifyCalls(), ShouldHaveLength, 1)
+				records := processed.Records()
+				So(records, ShouldHaveLength, 1)
+				So(records[0].Result, ShouldEqual, idempotency.ResultSucceeded)
+			})
+		})
+
+		Convey("When a failed message is received", func() {
+			result = handleErr
+			receiver.OnMessage(fix.message)
+
+			Convey("Then the failure is recorded with its error", func() {
+				records := processed.Records()
+				So(records, ShouldHaveLength, 1)
+				So(records[0].Result, ShouldEqual, idempotency.ResultFailed)
+				So(records[0].Error, ShouldEqual, "boom!")
+			})
+		})
+
+		Convey("When two different events are received", func() {
+			otherBytes, _ := schema.NewInstanceSchema.Marshal(event.NewInstance{FileURL: "/A/B/C/E", InstanceID: "1234567890"})
+			receiver.OnMessage(fix.message)
+			receiver.OnMessage(kafkatest.NewMessage(otherBytes, 1))
+
+			Convey("Then both events are handled", func() {
+				So(fix.instanceHandler.HandleCalls(), ShouldHaveLength, 2)
+				So(processed.Records(), ShouldHaveLength, 2)
+			})
+		})
+
+		Convey("When the same forced or incremental event is received twice", func() {
+			forcedBytes, _ := schema.NewInstanceSchema.Marshal(event.NewInstance{FileURL: "/A/B/C/D", InstanceID: "1234567890", Force: true})
+			incrementalBytes, _ := schema.NewInstanceSchema.Marshal(event.NewInstance{FileURL: "/A/B/C/D", InstanceID: "1234567890", Incremental: true})
+			receiver.OnMessage(kafkatest.NewMessage(forcedBytes, 0))
+			receiver.OnMessage(kafkatest.NewMessage(forcedBytes, 1))
+			receiver.OnMessage(kafkatest.NewMessage(incrementalBytes, 2))
+			receiver.OnMessage(kafkatest.NewMessage(incrementalBytes, 3))
+
+			Convey("Then every event is handled, and none is recorded", func() {
+				So(fix.instanceHandler.HandleCalls(), ShouldHaveLength, 4)
+				So(processed.Records(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the records of the instance are deleted after the event is processed, and the same event is received again", func() {
+			receiver.OnMessage(fix.message)
+			So(processed.DeleteInstance("1234567890"), ShouldBeNil)
+			receiver.OnMessage(kafkatest.NewMessage(avroBytes, 1))
+
+			Convey("Then the event is handled again", func() {
+				So(fix.instanceHandler.HandleCalls(), ShouldHaveLength, 2)
+			})
+		})
+	})
+
+	Convey("Given KafkaMessageReceiver with an idempotency store, and a handler that waits to be released", t, func() {
+		release := make(chan struct{})
+		started := make(chan struct{}, 2)
+		instanceHandler := &mock.InstanceEventHandlerMock{
+			HandleFunc: func(ctx context.Context, e event.NewInstance) error {
+				started <- struct{}{}
+				<-release
+				return nil
+			},
+		}
+		receiver := message.KafkaMessageReceiver{
+			InstanceHandler: instanceHandler,
+			ErrorReporter:   reportertest.NewImportErrorReporterMock(nil),
+			Processed:       idempotency.NewMemoryStore(10, time.Hour),
+		}
+
+		Convey("When the same message is received by two workers at the same time", func() {
+			wg := &sync.WaitGroup{}
+			for i := 0; i < 2; i++ {
+				wg.Add(1)
+				go func(offset int64) {
+					defer wg.Done()
+					receiver.OnMessage(kafkatest.NewMessage(avroBytes, offset))
+				}(int64(i))
+			}
+			<-started
+			close(release)
+			wg.Wait()
+
+			Convey("Then the event is only handled once", func() {
+				So(instanceHandler.HandleCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+}
+
 type fixture struct {
 	instanceHdlrCalls []event.NewInstance
 	instanceHandler   *mock.InstanceEventHandlerMock
@@ -81,3 +81,75 @@ func (mock *InstanceEventHandlerMock) HandleCalls() []struct {
 	mock.lockHandle.RUnlock()
 	return calls
 }
+
+// Ensure, that InstanceDeletedEventHandlerMock does implement message.InstanceDeletedEventHandler.
+// If this is not the case, regenerate this file with moq.
+var _ message.InstanceDeletedEventHandler = &InstanceDeletedEventHandlerMock{}
+
+// InstanceDeletedEventHandlerMock is a mock implementation of message.InstanceDeletedEventHandler.
+//
+//	func TestSomethingThatUsesInstanceDeletedEventHandler(t *testing.T) {
+//
+//		// make and configure a mocked message.InstanceDeletedEventHandler
+//		mockedInstanceDeletedEventHandler := &InstanceDeletedEventHandlerMock{
+//			HandleFunc: func(ctx context.Context, e event.InstanceDeleted) error {
+//				panic("mock out the Handle method")
+//			},
+//		}
+//
+//		// use mockedInstanceDeletedEventHandler in code that requires message.InstanceDeletedEventHandler
+//		// and then make assertions.
+//
+//	}
+type InstanceDeletedEventHandlerMock struct {
+	// HandleFunc mocks the Handle method.
+	HandleFunc func(ctx context.Context, e event.InstanceDeleted) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Handle holds details about calls to the Handle method.
+		Handle []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// E is the e argument value.
+			E event.InstanceDeleted
+		}
+	}
+	lockHandle sync.RWMutex
+}
+
+// Handle calls HandleFunc.
+func (mock *InstanceDeletedEventHandlerMock) Handle(ctx context.Context, e event.InstanceDeleted) error {
+	if mock.HandleFunc == nil {
+		panic("InstanceDeletedEventHandlerMock.HandleFunc: method is nil but InstanceDeletedEventHandler.Handle was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		E   event.InstanceDeleted
+	}{
+		Ctx: ctx,
+		E:   e,
+	}
+	mock.lockHandle.Lock()
+	mock.calls.Handle = append(mock.calls.Handle, callInfo)
+	mock.lockHandle.Unlock()
+	return mock.HandleFunc(ctx, e)
+}
+
+// HandleCalls gets all the calls that were made to Handle.
+// Check the length with:
+//
+//	len(mockedInstanceDeletedEventHandler.HandleCalls())
+func (mock *InstanceDeletedEventHandlerMock) HandleCalls() []struct {
+	Ctx context.Context
+	E   event.InstanceDeleted
+} {
+	var calls []struct {
+		Ctx context.Context
+		E   event.InstanceDeleted
+	}
+	mock.lockHandle.RLock()
+	calls = mock.calls.Handle
+	mock.lockHandle.RUnlock()
+	return calls
+}
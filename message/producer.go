@@ -16,6 +16,19 @@ type Marshaller interface {
 	Marshal(s interface{}) ([]byte, error)
 }
 
+// ContextMarshaller is a Marshaller that can use the context of the event, e.g. to call a schema registry
+type ContextMarshaller interface {
+	MarshalContext(ctx context.Context, s interface{}) ([]byte, error)
+}
+
+// marshal marshals the event with the provided context if the Marshaller supports it
+func marshal(ctx context.Context, marshaller Marshaller, s interface{}) ([]byte, error) {
+	if m, ok := marshaller.(ContextMarshaller); ok {
+		return m.MarshalContext(ctx, s)
+	}
+	return marshaller.Marshal(s)
+}
+
 // InstanceCompletedProducer produces kafka messages for instances which have been successfully processed.
 type InstanceCompletedProducer struct {
 	Marshaller Marshaller
@@ -24,7 +37,7 @@ type InstanceCompletedProducer struct {
 
 // Completed produce a kafka message for an instance which has been successfully processed.
 func (p InstanceCompletedProducer) Completed(ctx context.Context, e event.InstanceCompleted) error {
-	bytes, avroError := p.Marshaller.Marshal(e)
+	bytes, avroError := marshal(ctx, p.Marshaller, e)
 	if avroError != nil {
 		return fmt.Errorf(fmt.Sprintf("Marshaller.Marshal returned an error: event=%v: %%w", e), avroError)
 	}
@@ -32,3 +45,54 @@ func (p InstanceCompletedProducer) Completed(ctx context.Context, e event.Instan
 	log.Info(ctx, "completed successfully", log.Data{"event": e, "package": "message.InstanceCompletedProducer"})
 	return nil
 }
+
+// ImportReportProducer produces kafka messages containing the data-quality report of an import.
+type ImportReportProducer struct {
+	Marshaller Marshaller
+	Producer   kafka.IProducer
+}
+
+// Report produce a kafka message with the data-quality report for an instance which has been processed.
+func (p ImportReportProducer) Report(ctx context.Context, e event.ImportReport) error {
+	bytes, avroError := marshal(ctx, p.Marshaller, e)
+	if avroError != nil {
+		return fmt.Errorf("Marshaller.Marshal returned an error: instance_id=%s: %w", e.InstanceID, avroError)
+	}
+	p.Producer.Channels().Output <- bytes
+	log.Info(ctx, "import report sent", log.Data{"instance_id": e.InstanceID, "status": e.Status, "package": "message.ImportReportProducer"})
+	return nil
+}
+
+// ImportFailedProducer produces kafka messages describing the failed imports of instances.
+type ImportFailedProducer struct {
+	Marshaller Marshaller
+	Producer   kafka.IProducer
+}
+
+// Failed produce a kafka message describing the failed import of an instance.
+func (p ImportFailedProducer) Failed(ctx context.Context, e event.ImportFailed) error {
+	bytes, avroError := marshal(ctx, p.Marshaller, e)
+	if avroError != nil {
+		return fmt.Errorf("Marshaller.Marshal returned an error: instance_id=%s: %w", e.InstanceID, avroError)
+	}
+	p.Producer.Channels().Output <- bytes
+	log.Info(ctx, "import failed event sent", log.Data{"instance_id": e.InstanceID, "stage": e.Stage, "error_code": e.ErrorCode, "package": "message.ImportFailedProducer"})
+	return nil
+}
+
+// DimensionsDeletedProducer produces kafka messages confirming that the graph data of deleted instances has been removed.
+type DimensionsDeletedProducer struct {
+	Marshaller Marshaller
+	Producer   kafka.IProducer
+}
+
+// Deleted produce a kafka message confirming that the graph data of a deleted instance has been removed.
+func (p DimensionsDeletedProducer) Deleted(ctx context.Context, e event.DimensionsDeleted) error {
+	bytes, avroError := marshal(ctx, p.Marshaller, e)
+	if avroError != nil {
+		return fmt.Errorf("Marshaller.Marshal returned an error: instance_id=%s: %w", e.InstanceID, avroError)
+	}
+	p.Producer.Channels().Output <- bytes
+	log.Info(ctx, "dimensions deleted event sent", log.Data{"instance_id": e.InstanceID, "existed": e.Existed, "package": "message.DimensionsDeletedProducer"})
+	return nil
+}
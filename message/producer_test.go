@@ -14,12 +14,18 @@ import (
 	kafka "github.com/ONSdigital/dp-kafka/v2"
 	"github.com/ONSdigital/dp-kafka/v2/kafkatest"
 	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/go-avro/avro"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
 var completedEvent = event.InstanceCompleted{
-	InstanceID: "1234567890",
-	FileURL:    "/cmd/my.csv",
+	InstanceID:   "1234567890",
+	FileURL:      "/cmd/my.csv",
+	DatasetID:    "cpih01",
+	Edition:      "2021",
+	Version:      1,
+	InstanceType: "v4",
+	CodeLists:    []string{},
 }
 
 var ctx = context.Background()
@@ -109,3 +115,224 @@ func TestInstanceCompletedProducer_Completed_MarshalErr(t *testing.T) {
 		})
 	})
 }
+
+func TestImportReportProducer_Report(t *testing.T) {
+	reportEvent := event.ImportReport{
+		InstanceID:               "1234567890",
+		Status:                   "completed",
+		Dimensions:               []event.DimensionReport{{Name: "geography", OptionCount: 3}},
+		OptionsWithoutOrder:      []string{"geography:Wales"},
+		OrderFallbacks:           []event.OrderFallbackReport{{Name: "geography", Fallback: "natural"}},
+		SkippedCodeRelationships: []string{},
+		DuplicateOptions:         []string{},
+		Stages:                   []event.StageReport{{Name: "insert_dimensions", DurationMS: 12}},
+		GraphCalls:               7,
+	}
+
+	Convey("Given ImportReportProducer has been configured correctly", t, func() {
+		pChannels := &kafka.ProducerChannels{
+			Output: make(chan []byte, 1),
+		}
+		kafkaProducerMock := &kafkatest.IProducerMock{
+			ChannelsFunc: func() *kafka.ProducerChannels {
+				return pChannels
+			},
+		}
+		reportProducer := message.ImportReportProducer{
+			Producer:   kafkaProducerMock,
+			Marshaller: schema.ImportReportSchema,
+		}
+
+		Convey("When given a valid import report event", func() {
+			err := reportProducer.Report(ctx, reportEvent)
+			So(err, ShouldBeNil)
+
+			Convey("Then the expected bytes are sent to producer.output", func() {
+				avroBytes := <-pChannels.Output
+				reader := avro.NewSpecificDatumReader()
+				reader.SetSchema(avro.MustParseSchema(schema.ImportReportSchema.Latest().Definition))
+				var actual event.ImportReport
+				So(reader.Read(&actual, avro.NewBinaryDecoder(avroBytes)), ShouldBeNil)
+				So(actual, ShouldResemble, reportEvent)
+			})
+		})
+	})
+
+	Convey("Given ImportReportProducer with a marshaller that fails", t, func() {
+		kafkaProducerMock := &kafkatest.IProducerMock{}
+		reportProducer := message.ImportReportProducer{
+			Producer: kafkaProducerMock,
+			Marshaller: &mock.MarshallerMock{
+				MarshalFunc: func(s interface{}) ([]byte, error) {
+					return nil, errors.New("mock error")
+				},
+			},
+		}
+
+		Convey("When Report is called", func() {
+			err := reportProducer.Report(ctx, reportEvent)
+
+			Convey("Then the expected error is returned and nothing is sent to kafka", func() {
+				So(err.Error(), ShouldEqual, "Marshaller.Marshal returned an error: instance_id=1234567890: mock error")
+				So(kafkaProducerMock.ChannelsCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+}
+
+func TestImportFailedProducer_Failed(t *testing.T) {
+	failedEvent := event.ImportFailed{
+		InstanceID: "1234567890",
+		Stage:      "insert_dimensions",
+		ErrorCode:  "invalid_options",
+		Error:      "dimension options validation error",
+		Dimension:  "geography",
+		Option:     "",
+		Attempt:    2,
+		TraceID:    "trace1",
+	}
+
+	Convey("Given ImportFailedProducer has been configured correctly", t, func() {
+		pChannels := &kafka.ProducerChannels{
+			Output: make(chan []byte, 1),
+		}
+		kafkaProducerMock := &kafkatest.IProducerMock{
+			ChannelsFunc: func() *kafka.ProducerChannels {
+				return pChannels
+			},
+		}
+		failedProducer := message.ImportFailedProducer{
+			Producer:   kafkaProducerMock,
+			Marshaller: schema.ImportFailedSchema,
+		}
+
+		Convey("When given a valid import failed event", func() {
+			err := failedProducer.Failed(ctx, failedEvent)
+			So(err, ShouldBeNil)
+
+			Convey("Then the expected bytes are sent to producer.output", func() {
+				var actual event.ImportFailed
+				So(schema.ImportFailedSchema.Unmarshal(<-pChannels.Output, &actual), ShouldBeNil)
+				So(actual, ShouldResemble, failedEvent)
+			})
+		})
+	})
+
+	Convey("Given ImportFailedProducer with a marshaller that fails", t, func() {
+		kafkaProducerMock := &kafkatest.IProducerMock{}
+		failedProducer := message.ImportFailedProducer{
+			Producer: kafkaProducerMock,
+			Marshaller: &mock.MarshallerMock{
+				MarshalFunc: func(s interface{}) ([]byte, error) {
+					return nil, errors.New("mock error")
+				},
+			},
+		}
+
+		Convey("When Failed is called", func() {
+			err := failedProducer.Failed(ctx, failedEvent)
+
+			Convey("Then the expected error is returned and nothing is sent to kafka", func() {
+				So(err.Error(), ShouldEqual, "Marshaller.Marshal returned an error: instance_id=1234567890: mock error")
+				So(kafkaProducerMock.ChannelsCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+}
+
+func TestDimensionsDeletedProducer_Deleted(t *testing.T) {
+	deletedEvent := event.DimensionsDeleted{
+		InstanceID: "1234567890",
+		Existed:    true,
+		TraceID:    "trace1",
+	}
+
+	Convey("Given DimensionsDeletedProducer has been configured correctly", t, func() {
+		pChannels := &kafka.ProducerChannels{
+			Output: make(chan []byte, 1),
+		}
+		kafkaProducerMock := &kafkatest.IProducerMock{
+			ChannelsFunc: func() *kafka.ProducerChannels {
+				return pChannels
+			},
+		}
+		deletedProducer := message.DimensionsDeletedProducer{
+			Producer:   kafkaProducerMock,
+			Marshaller: schema.DimensionsDeletedSchema,
+		}
+
+		Convey("When given a valid dimensions deleted event", func() {
+			err := deletedProducer.Deleted(ctx, deletedEvent)
+			So(err, ShouldBeNil)
+
+			Convey("Then the expected bytes are sent to producer.output", func() {
+				var actual event.DimensionsDeleted
+				So(schema.DimensionsDeletedSchema.Unmarshal(<-pChannels.Output, &actual), ShouldBeNil)
+				So(actual, ShouldResemble, deletedEvent)
+			})
+		})
+	})
+
+	Convey("Given DimensionsDeletedProducer with a marshaller that fails", t, func() {
+		kafkaProducerMock := &kafkatest.IProducerMock{}
+		deletedProducer := message.DimensionsDeletedProducer{
+			Producer: kafkaProducerMock,
+			Marshaller: &mock.MarshallerMock{
+				MarshalFunc: func(s interface{}) ([]byte, error) {
+					return nil, errors.New("mock error")
+				},
+			},
+		}
+
+		Convey("When Deleted is called", func() {
+			err := deletedProducer.Deleted(ctx, deletedEvent)
+
+			Convey("Then the expected error is returned and nothing is sent to kafka", func() {
+				So(err.Error(), ShouldEqual, "Marshaller.Marshal returned an error: instance_id=1234567890: mock error")
+				So(kafkaProducerMock.ChannelsCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+}
+
+// contextMarshaller is a ContextMarshaller recording the context it is called with
+type contextMarshaller struct {
+	ctx context.Context
+}
+
+func (m *contextMarshaller) Marshal(s interface{}) ([]byte, error) {
+	return m.MarshalContext(context.Background(), s)
+}
+
+func (m *contextMarshaller) MarshalContext(ctx context.Context, s interface{}) ([]byte, error) {
+	m.ctx = ctx
+	return schema.DimensionsDeletedSchema.Marshal(s)
+}
+
+func TestDimensionsDeletedProducer_Deleted_Context(t *testing.T) {
+	Convey("Given DimensionsDeletedProducer with a marshaller that uses the context of the event", t, func() {
+		pChannels := &kafka.ProducerChannels{
+			Output: make(chan []byte, 1),
+		}
+		marshaller := &contextMarshaller{}
+		deletedProducer := message.DimensionsDeletedProducer{
+			Producer: &kafkatest.IProducerMock{
+				ChannelsFunc: func() *kafka.ProducerChannels {
+					return pChannels
+				},
+			},
+			Marshaller: marshaller,
+		}
+		type ctxKey struct{}
+		eventCtx := context.WithValue(ctx, ctxKey{}, "event")
+
+		Convey("When Deleted is called", func() {
+			err := deletedProducer.Deleted(eventCtx, event.DimensionsDeleted{InstanceID: "1234567890"})
+
+			Convey("Then the event is marshalled with the context", func() {
+				So(err, ShouldBeNil)
+				So(marshaller.ctx, ShouldEqual, eventCtx)
+			})
+		})
+	})
+}
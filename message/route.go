@@ -0,0 +1,96 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ONSdigital/dp-dimension-importer/idempotency"
+	"github.com/ONSdigital/dp-reporter-client/reporter"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// EventTypeHeader is the kafka header giving the event type of a message
+const EventTypeHeader = "event-type"
+
+// ErrUnknownEventType is returned when a kafka message has an event type without handler
+var ErrUnknownEventType = errors.New("no handler is registered for the event type of the message")
+
+// EventHandler handles the events of type T
+type EventHandler[T any] interface {
+	Handle(ctx context.Context, e T) error
+}
+
+// Route passes the kafka messages of an event type to the handler of their events
+type Route struct {
+	dispatch func(ctx context.Context, eventType string, data []byte, errorReporter reporter.ErrorReporter, processed idempotency.Store, logData log.Data)
+}
+
+// NewRoute returns the Route of the events of type T, which are unmarshalled with the provided Unmarshaller and passed to the handler.
+// The errors returned by the handler are sent to the ErrorReporter as handlerName.Handle errors, with the instance ID of the event.
+// If an idempotency store is given, the events that have already been processed successfully are skipped, and the result of the others is recorded,
+// unless they are idempotency.Repeatable. Identical events processed concurrently by different workers are handled one after the other.
+func NewRoute[T any](handlerName string, unmarshaller Unmarshaller, handler EventHandler[T], instanceID func(e T) string) Route {
+	return Route{
+		dispatch: func(ctx context.Context, eventType string, data []byte, errorReporter reporter.ErrorReporter, processed idempotency.Store, logData log.Data) {
+			var e T
+			if err := unmarshal(ctx, unmarshaller, data, &e); err != nil {
+				log.Error(ctx, "error while attempting to unmarshal kafka message into event", err, logData)
+				return
+			}
+
+			logData["event"] = e
+			log.Info(ctx, "successfully unmarshalled kafka message into event", logData)
+
+			key := ""
+			if processed != nil && !repeatable(e) {
+				var err error
+				if key, err = idempotency.Key(eventType, instanceID(e), e); err != nil {
+					log.Error(ctx, "failed to get the idempotency key of the event, it will be processed", err, logData)
+				} else {
+					unlock := processed.Lock(key)
+					defer unlock()
+					if r, found := processed.Get(key); found && r.Succeeded() {
+						logData["processed_at"] = r.ProcessedAt
+						log.Info(ctx, "skipping event that has already been processed", logData)
+						return
+					}
+				}
+			}
+
+			// handle event by the provided handler
+			err := handler.Handle(ctx, e)
+			if key != "" {
+				record(ctx, processed, key, err, logData)
+			}
+			if err != nil {
+				log.Error(ctx, "event handler handle returned an error", err, logData)
+				if err := errorReporter.Notify(instanceID(e), handlerName+".Handle returned an unexpected error", err); err != nil {
+					log.Error(ctx, "error reporter notify returned an error", err, logData)
+				}
+				return
+			}
+
+			log.Info(ctx, "event successfully processed", logData)
+		},
+	}
+}
+
+// repeatable returns true if the provided event must be processed even if an identical event has already been processed
+func repeatable(e interface{}) bool {
+	r, ok := e.(idempotency.Repeatable)
+	return ok && r.Repeatable()
+}
+
+// record adds the result of the processing of the event with the provided key to the idempotency store.
+// Failing to record it is only logged, as the event has been processed.
+func record(ctx context.Context, processed idempotency.Store, key string, handleErr error, logData log.Data) {
+	r := idempotency.Record{Key: key, Result: idempotency.ResultSucceeded, ProcessedAt: time.Now().UTC()}
+	if handleErr != nil {
+		r.Result = idempotency.ResultFailed
+		r.Error = handleErr.Error()
+	}
+	if err := processed.Put(r); err != nil {
+		log.Error(ctx, "failed to record the result of the event in the idempotency store", err, logData)
+	}
+}
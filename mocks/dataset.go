@@ -24,8 +24,11 @@ var _ client.IClient = &IClientMock{}
 //			CheckerFunc: func(ctx context.Context, state *healthcheck.CheckState) error {
 //				panic("mock out the Checker method")
 //			},
-//			GetInstanceFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) (dataset.Instance, string, error) {
-//				panic("mock out the GetInstance method")
+//			GetInstanceBytesFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) ([]byte, string, error) {
+//				panic("mock out the GetInstanceBytes method")
+//			},
+//			GetInstanceDimensionsBytesFunc: func(ctx context.Context, serviceAuthToken string, instanceID string, q *dataset.QueryParams, ifMatch string) ([]byte, string, error) {
+//				panic("mock out the GetInstanceDimensionsBytes method")
 //			},
 //			GetInstanceDimensionsInBatchesFunc: func(ctx context.Context, serviceAuthToken string, instanceID string, batchSize int, maxWorkers int) (dataset.Dimensions, string, error) {
 //				panic("mock out the GetInstanceDimensionsInBatches method")
@@ -43,8 +46,11 @@ type IClientMock struct {
 	// CheckerFunc mocks the Checker method.
 	CheckerFunc func(ctx context.Context, state *healthcheck.CheckState) error
 
-	// GetInstanceFunc mocks the GetInstance method.
-	GetInstanceFunc func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) (dataset.Instance, string, error)
+	// GetInstanceBytesFunc mocks the GetInstanceBytes method.
+	GetInstanceBytesFunc func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) ([]byte, string, error)
+
+	// GetInstanceDimensionsBytesFunc mocks the GetInstanceDimensionsBytes method.
+	GetInstanceDimensionsBytesFunc func(ctx context.Context, serviceAuthToken string, instanceID string, q *dataset.QueryParams, ifMatch string) ([]byte, string, error)
 
 	// GetInstanceDimensionsInBatchesFunc mocks the GetInstanceDimensionsInBatches method.
 	GetInstanceDimensionsInBatchesFunc func(ctx context.Context, serviceAuthToken string, instanceID string, batchSize int, maxWorkers int) (dataset.Dimensions, string, error)
@@ -61,8 +67,8 @@ type IClientMock struct {
 			// State is the state argument value.
 			State *healthcheck.CheckState
 		}
-		// GetInstance holds details about calls to the GetInstance method.
-		GetInstance []struct {
+		// GetInstanceBytes holds details about calls to the GetInstanceBytes method.
+		GetInstanceBytes []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 			// UserAuthToken is the userAuthToken argument value.
@@ -76,6 +82,19 @@ type IClientMock struct {
 			// IfMatch is the ifMatch argument value.
 			IfMatch string
 		}
+		// GetInstanceDimensionsBytes holds details about calls to the GetInstanceDimensionsBytes method.
+		GetInstanceDimensionsBytes []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ServiceAuthToken is the serviceAuthToken argument value.
+			ServiceAuthToken string
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// Q is the q argument value.
+			Q *dataset.QueryParams
+			// IfMatch is the ifMatch argument value.
+			IfMatch string
+		}
 		// GetInstanceDimensionsInBatches holds details about calls to the GetInstanceDimensionsInBatches method.
 		GetInstanceDimensionsInBatches []struct {
 			// Ctx is the ctx argument value.
@@ -106,7 +125,8 @@ type IClientMock struct {
 		}
 	}
 	lockChecker                        sync.RWMutex
-	lockGetInstance                    sync.RWMutex
+	lockGetInstanceBytes               sync.RWMutex
+	lockGetInstanceDimensionsBytes     sync.RWMutex
 	lockGetInstanceDimensionsInBatches sync.RWMutex
 	lockPatchInstanceDimensions        sync.RWMutex
 }
@@ -147,10 +167,10 @@ func (mock *IClientMock) CheckerCalls() []struct {
 	return calls
 }
 
-// GetInstance calls GetInstanceFunc.
-func (mock *IClientMock) GetInstance(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) (dataset.Instance, string, error) {
-	if mock.GetInstanceFunc == nil {
-		panic("IClientMock.GetInstanceFunc: method is nil but IClient.GetInstance was just called")
+// GetInstanceBytes calls GetInstanceBytesFunc.
+func (mock *IClientMock) GetInstanceBytes(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) ([]byte, string, error) {
+	if mock.GetInstanceBytesFunc == nil {
+		panic("IClientMock.GetInstanceBytesFunc: method is nil but IClient.GetInstanceBytes was just called")
 	}
 	callInfo := struct {
 		Ctx              context.Context
@@ -167,17 +187,17 @@ func (mock *IClientMock) GetInstance(ctx context.Context, userAuthToken string,
 		InstanceID:       instanceID,
 		IfMatch:          ifMatch,
 	}
-	mock.lockGetInstance.Lock()
-	mock.calls.GetInstance = append(mock.calls.GetInstance, callInfo)
-	mock.lockGetInstance.Unlock()
-	return mock.GetInstanceFunc(ctx, userAuthToken, serviceAuthToken, collectionID, instanceID, ifMatch)
+	mock.lockGetInstanceBytes.Lock()
+	mock.calls.GetInstanceBytes = append(mock.calls.GetInstanceBytes, callInfo)
+	mock.lockGetInstanceBytes.Unlock()
+	return mock.GetInstanceBytesFunc(ctx, userAuthToken, serviceAuthToken, collectionID, instanceID, ifMatch)
 }
 
-// GetInstanceCalls gets all the calls that were made to GetInstance.
+// GetInstanceBytesCalls gets all the calls that were made to GetInstanceBytes.
 // Check the length with:
 //
-//	len(mockedIClient.GetInstanceCalls())
-func (mock *IClientMock) GetInstanceCalls() []struct {
+//	len(mockedIClient.GetInstanceBytesCalls())
+func (mock *IClientMock) GetInstanceBytesCalls() []struct {
 	Ctx              context.Context
 	UserAuthToken    string
 	ServiceAuthToken string
@@ -193,9 +213,57 @@ func (mock *IClientMock) GetInstanceCalls() []struct {
 		InstanceID       string
 		IfMatch          string
 	}
-	mock.lockGetInstance.RLock()
-	calls = mock.calls.GetInstance
-	mock.lockGetInstance.RUnlock()
+	mock.lockGetInstanceBytes.RLock()
+	calls = mock.calls.GetInstanceBytes
+	mock.lockGetInstanceBytes.RUnlock()
+	return calls
+}
+
+// GetInstanceDimensionsBytes calls GetInstanceDimensionsBytesFunc.
+func (mock *IClientMock) GetInstanceDimensionsBytes(ctx context.Context, serviceAuthToken string, instanceID string, q *dataset.QueryParams, ifMatch string) ([]byte, string, error) {
+	if mock.GetInstanceDimensionsBytesFunc == nil {
+		panic("IClientMock.GetInstanceDimensionsBytesFunc: method is nil but IClient.GetInstanceDimensionsBytes was just called")
+	}
+	callInfo := struct {
+		Ctx              context.Context
+		ServiceAuthToken string
+		InstanceID       string
+		Q                *dataset.QueryParams
+		IfMatch          string
+	}{
+		Ctx:              ctx,
+		ServiceAuthToken: serviceAuthToken,
+		InstanceID:       instanceID,
+		Q:                q,
+		IfMatch:          ifMatch,
+	}
+	mock.lockGetInstanceDimensionsBytes.Lock()
+	mock.calls.GetInstanceDimensionsBytes = append(mock.calls.GetInstanceDimensionsBytes, callInfo)
+	mock.lockGetInstanceDimensionsBytes.Unlock()
+	return mock.GetInstanceDimensionsBytesFunc(ctx, serviceAuthToken, instanceID, q, ifMatch)
+}
+
+// GetInstanceDimensionsBytesCalls gets all the calls that were made to GetInstanceDimensionsBytes.
+// Check the length with:
+//
+//	len(mockedIClient.GetInstanceDimensionsBytesCalls())
+func (mock *IClientMock) GetInstanceDimensionsBytesCalls() []struct {
+	Ctx              context.Context
+	ServiceAuthToken string
+	InstanceID       string
+	Q                *dataset.QueryParams
+	IfMatch          string
+} {
+	var calls []struct {
+		Ctx              context.Context
+		ServiceAuthToken string
+		InstanceID       string
+		Q                *dataset.QueryParams
+		IfMatch          string
+	}
+	mock.lockGetInstanceDimensionsBytes.RLock()
+	calls = mock.calls.GetInstanceDimensionsBytes
+	mock.lockGetInstanceDimensionsBytes.RUnlock()
 	return calls
 }
 
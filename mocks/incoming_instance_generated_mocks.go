@@ -81,3 +81,219 @@ func (mock *CompletedProducerMock) CompletedCalls() []struct {
 	mock.lockCompleted.RUnlock()
 	return calls
 }
+
+// Ensure, that ReportProducerMock does implement handler.ReportProducer.
+// If this is not the case, regenerate this file with moq.
+var _ handler.ReportProducer = &ReportProducerMock{}
+
+// ReportProducerMock is a mock implementation of handler.ReportProducer.
+//
+//	func TestSomethingThatUsesReportProducer(t *testing.T) {
+//
+//		// make and configure a mocked handler.ReportProducer
+//		mockedReportProducer := &ReportProducerMock{
+//			ReportFunc: func(ctx context.Context, e event.ImportReport) error {
+//				panic("mock out the Report method")
+//			},
+//		}
+//
+//		// use mockedReportProducer in code that requires handler.ReportProducer
+//		// and then make assertions.
+//
+//	}
+type ReportProducerMock struct {
+	// ReportFunc mocks the Report method.
+	ReportFunc func(ctx context.Context, e event.ImportReport) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Report holds details about calls to the Report method.
+		Report []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// E is the e argument value.
+			E event.ImportReport
+		}
+	}
+	lockReport sync.RWMutex
+}
+
+// Report calls ReportFunc.
+func (mock *ReportProducerMock) Report(ctx context.Context, e event.ImportReport) error {
+	if mock.ReportFunc == nil {
+		panic("ReportProducerMock.ReportFunc: method is nil but ReportProducer.Report was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		E   event.ImportReport
+	}{
+		Ctx: ctx,
+		E:   e,
+	}
+	mock.lockReport.Lock()
+	mock.calls.Report = append(mock.calls.Report, callInfo)
+	mock.lockReport.Unlock()
+	return mock.ReportFunc(ctx, e)
+}
+
+// ReportCalls gets all the calls that were made to Report.
+// Check the length with:
+//
+//	len(mockedReportProducer.ReportCalls())
+func (mock *ReportProducerMock) ReportCalls() []struct {
+	Ctx context.Context
+	E   event.ImportReport
+} {
+	var calls []struct {
+		Ctx context.Context
+		E   event.ImportReport
+	}
+	mock.lockReport.RLock()
+	calls = mock.calls.Report
+	mock.lockReport.RUnlock()
+	return calls
+}
+
+// Ensure, that FailureProducerMock does implement handler.FailureProducer.
+// If this is not the case, regenerate this file with moq.
+var _ handler.FailureProducer = &FailureProducerMock{}
+
+// FailureProducerMock is a mock implementation of handler.FailureProducer.
+//
+//	func TestSomethingThatUsesFailureProducer(t *testing.T) {
+//
+//		// make and configure a mocked handler.FailureProducer
+//		mockedFailureProducer := &FailureProducerMock{
+//			FailedFunc: func(ctx context.Context, e event.ImportFailed) error {
+//				panic("mock out the Failed method")
+//			},
+//		}
+//
+//		// use mockedFailureProducer in code that requires handler.FailureProducer
+//		// and then make assertions.
+//
+//	}
+type FailureProducerMock struct {
+	// FailedFunc mocks the Failed method.
+	FailedFunc func(ctx context.Context, e event.ImportFailed) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Failed holds details about calls to the Failed method.
+		Failed []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// E is the e argument value.
+			E event.ImportFailed
+		}
+	}
+	lockFailed sync.RWMutex
+}
+
+// Failed calls FailedFunc.
+func (mock *FailureProducerMock) Failed(ctx context.Context, e event.ImportFailed) error {
+	if mock.FailedFunc == nil {
+		panic("FailureProducerMock.FailedFunc: method is nil but FailureProducer.Failed was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		E   event.ImportFailed
+	}{
+		Ctx: ctx,
+		E:   e,
+	}
+	mock.lockFailed.Lock()
+	mock.calls.Failed = append(mock.calls.Failed, callInfo)
+	mock.lockFailed.Unlock()
+	return mock.FailedFunc(ctx, e)
+}
+
+// FailedCalls gets all the calls that were made to Failed.
+// Check the length with:
+//
+//	len(mockedFailureProducer.FailedCalls())
+func (mock *FailureProducerMock) FailedCalls() []struct {
+	Ctx context.Context
+	E   event.ImportFailed
+} {
+	var calls []struct {
+		Ctx context.Context
+		E   event.ImportFailed
+	}
+	mock.lockFailed.RLock()
+	calls = mock.calls.Failed
+	mock.lockFailed.RUnlock()
+	return calls
+}
+
+// Ensure, that DeletedProducerMock does implement handler.DeletedProducer.
+// If this is not the case, regenerate this file with moq.
+var _ handler.DeletedProducer = &DeletedProducerMock{}
+
+// DeletedProducerMock is a mock implementation of handler.DeletedProducer.
+//
+//	func TestSomethingThatUsesDeletedProducer(t *testing.T) {
+//
+//		// make and configure a mocked handler.DeletedProducer
+//		mockedDeletedProducer := &DeletedProducerMock{
+//			DeletedFunc: func(ctx context.Context, e event.DimensionsDeleted) error {
+//				panic("mock out the Deleted method")
+//			},
+//		}
+//
+//		// use mockedDeletedProducer in code that requires handler.DeletedProducer
+//		// and then make assertions.
+//
+//	}
+type DeletedProducerMock struct {
+	// DeletedFunc mocks the Deleted method.
+	DeletedFunc func(ctx context.Context, e event.DimensionsDeleted) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Deleted holds details about calls to the Deleted method.
+		Deleted []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// E is the e argument value.
+			E event.DimensionsDeleted
+		}
+	}
+	lockDeleted sync.RWMutex
+}
+
+// Deleted calls DeletedFunc.
+func (mock *DeletedProducerMock) Deleted(ctx context.Context, e event.DimensionsDeleted) error {
+	if mock.DeletedFunc == nil {
+		panic("DeletedProducerMock.DeletedFunc: method is nil but DeletedProducer.Deleted was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		E   event.DimensionsDeleted
+	}{
+		Ctx: ctx,
+		E:   e,
+	}
+	mock.lockDeleted.Lock()
+	mock.calls.Deleted = append(mock.calls.Deleted, callInfo)
+	mock.lockDeleted.Unlock()
+	return mock.DeletedFunc(ctx, e)
+}
+
+// DeletedCalls gets all the calls that were made to Deleted.
+// Check the length with:
+//
+//	len(mockedDeletedProducer.DeletedCalls())
+func (mock *DeletedProducerMock) DeletedCalls() []struct {
+	Ctx context.Context
+	E   event.DimensionsDeleted
+} {
+	var calls []struct {
+		Ctx context.Context
+		E   event.DimensionsDeleted
+	}
+	mock.lockDeleted.RLock()
+	calls = mock.calls.Deleted
+	mock.lockDeleted.RUnlock()
+	return calls
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// v4Prefix is the prefix of the first column of a V4 file header, followed by the number of data marking columns
+const v4Prefix = "V4_"
+
+// ErrInvalidV4Header is returned when a CSV header does not follow the V4 format
+var ErrInvalidV4Header = errors.New("invalid v4 header")
+
+// DimensionNamesFromV4Header parses a V4 CSV header and returns the lower case names of the dimensions it contains.
+// A V4 header starts with a 'V4_N' column, where N is the number of data marking columns that follow the observation column,
+// followed by a (code, label) pair of columns for each dimension. The name of a dimension is the header of its label column.
+func DimensionNamesFromV4Header(header []string) ([]string, error) {
+	if len(header) == 0 {
+		return nil, fmt.Errorf("%w: header is empty", ErrInvalidV4Header)
+	}
+
+	first := strings.TrimSpace(header[0])
+	if !strings.HasPrefix(strings.ToUpper(first), v4Prefix) {
+		return nil, fmt.Errorf("%w: first column %q does not start with %s", ErrInvalidV4Header, first, v4Prefix)
+	}
+	numDataMarkings, err := strconv.Atoi(first[len(v4Prefix):])
+	if err != nil || numDataMarkings < 0 {
+		return nil, fmt.Errorf("%w: first column %q does not define a valid number of data marking columns", ErrInvalidV4Header, first)
+	}
+
+	if len(header) < 1+numDataMarkings || (len(header)-1-numDataMarkings)%2 != 0 {
+		return nil, fmt.Errorf("%w: expected a code and a label column for each dimension after column %d", ErrInvalidV4Header, numDataMarkings)
+	}
+
+	dimensionColumns := header[1+numDataMarkings:]
+	names := make([]string, 0, len(dimensionColumns)/2)
+	for i := 1; i < len(dimensionColumns); i += 2 {
+		names = append(names, strings.ToLower(strings.TrimSpace(dimensionColumns[i])))
+	}
+	return names, nil
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDimensionNamesFromV4Header(t *testing.T) {
+	Convey("Given a V4 header without data markings", t, func() {
+		header := []string{"V4_0", "mmm-yy", "Time", "uk-only", "Geography"}
+
+		Convey("Then the lower case label column names are returned", func() {
+			names, err := DimensionNamesFromV4Header(header)
+			So(err, ShouldBeNil)
+			So(names, ShouldResemble, []string{"time", "geography"})
+		})
+	})
+
+	Convey("Given a V4 header with data markings", t, func() {
+		header := []string{"v4_2", "Data Marking", "Confidence", "sex", "Sex"}
+
+		Convey("Then the data marking columns are skipped", func() {
+			names, err := DimensionNamesFromV4Header(header)
+			So(err, ShouldBeNil)
+			So(names, ShouldResemble, []string{"sex"})
+		})
+	})
+
+	Convey("Given invalid V4 headers", t, func() {
+		for _, header := range [][]string{
+			{},
+			{"the", "CSV", "header"},
+			{"V4_x", "time", "Time"},
+			{"V4_0", "time", "Time", "geography"},
+			{"V4_3", "time"},
+		} {
+			Convey("Then ErrInvalidV4Header is returned for "+"["+strings.Join(header, ",")+"]", func() {
+				names, err := DimensionNamesFromV4Header(header)
+				So(names, ShouldBeNil)
+				So(errors.Is(err, ErrInvalidV4Header), ShouldBeTrue)
+			})
+		}
+	})
+}
@@ -7,6 +7,7 @@ import (
 
 	dataset "github.com/ONSdigital/dp-api-clients-go/v2/dataset"
 	db "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/log.go/v2/log"
 )
 
 // Dimension struct wraps the Dimension dataset API model defined in dp-api-clients, for extra functionality
@@ -68,21 +69,65 @@ func (d *Dimension) Validate() error {
 	return nil
 }
 
-// Instance struct to hold instance information by wrapping DB Instance model
+// Instance struct to hold instance information by wrapping DB Instance model,
+// along with the dataset, edition and version metadata of the instance
 type Instance struct {
-	dbInstance *db.Instance
+	dbInstance   *db.Instance
+	datasetID    string
+	edition      string
+	version      int
+	instanceType string
 }
 
 // NewInstance creates a new Instance struct from an API Instance model
 func NewInstance(instance *dataset.Instance) *Instance {
 	if instance == nil {
-		return &Instance{&db.Instance{}}
+		return &Instance{dbInstance: &db.Instance{}}
 	}
 	return &Instance{
 		dbInstance: &db.Instance{
 			InstanceID: instance.ID,
 			CSVHeader:  instance.CSVHeader,
 		},
+		datasetID: instance.Links.Dataset.ID,
+		edition:   instance.Edition,
+		version:   instance.Version.Version,
+	}
+}
+
+// DatasetID returns the ID of the dataset that the instance belongs to
+func (i *Instance) DatasetID() string {
+	return i.datasetID
+}
+
+// Edition returns the edition of the dataset that the instance belongs to
+func (i *Instance) Edition() string {
+	return i.edition
+}
+
+// Version returns the version of the dataset that the instance belongs to
+func (i *Instance) Version() int {
+	return i.version
+}
+
+// Type returns the type of the instance, e.g. 'v4' or 'cantabular_table'
+func (i *Instance) Type() string {
+	return i.instanceType
+}
+
+// SetType sets the type of the instance, which is not part of the API Instance model
+func (i *Instance) SetType(instanceType string) {
+	i.instanceType = instanceType
+}
+
+// LogData returns the identifying metadata of the instance, to be included in log events
+func (i *Instance) LogData() log.Data {
+	return log.Data{
+		"instance_id":   i.dbInstance.InstanceID,
+		"dataset_id":    i.datasetID,
+		"edition":       i.edition,
+		"version":       i.version,
+		"instance_type": i.instanceType,
 	}
 }
 
@@ -6,6 +6,7 @@ import (
 
 	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
 	db "github.com/ONSdigital/dp-graph/v2/models"
+	"github.com/ONSdigital/log.go/v2/log"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -171,7 +172,7 @@ func TestDimension_New(t *testing.T) {
 }
 
 func TestInstance_New(t *testing.T) {
-	emptyInstance := &Instance{&db.Instance{}}
+	emptyInstance := &Instance{dbInstance: &db.Instance{}}
 
 	Convey("Given a new instance with a nil pointer dataset API model", t, func() {
 		inst := NewInstance(nil)
@@ -205,4 +206,36 @@ func TestInstance_New(t *testing.T) {
 			So(inst.DBModel(), ShouldResemble, expected)
 		})
 	})
+	Convey("Given a new instance with dataset, edition and version metadata", t, func() {
+		apiInst := &dataset.Instance{
+			Version: dataset.Version{
+				ID:        "id",
+				Edition:   "2021",
+				Version:   3,
+				CSVHeader: []string{"V4_0", "time", "Time"},
+				Links: dataset.Links{
+					Dataset: dataset.Link{ID: "cpih01", URL: "http://localhost:22000/datasets/cpih01"},
+				},
+			},
+		}
+		inst := NewInstance(apiInst)
+		inst.SetType("v4")
+
+		Convey("The metadata is kept by the instance", func() {
+			So(inst.DatasetID(), ShouldEqual, "cpih01")
+			So(inst.Edition(), ShouldEqual, "2021")
+			So(inst.Version(), ShouldEqual, 3)
+			So(inst.Type(), ShouldEqual, "v4")
+		})
+
+		Convey("The metadata is included in the log data", func() {
+			So(inst.LogData(), ShouldResemble, log.Data{
+				"instance_id":   "id",
+				"dataset_id":    "cpih01",
+				"edition":       "2021",
+				"version":       3,
+				"instance_type": "v4",
+			})
+		})
+	})
 }
@@ -0,0 +1,242 @@
+package order
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Names of the available fallback orderers
+const (
+	FallbackExplicit      = "explicit"
+	FallbackChronological = "chronological"
+	FallbackNatural       = "natural"
+)
+
+// Fallback assigns an order to the codes of a code list that do not have one
+type Fallback interface {
+	// Name returns the name of the fallback, as recorded in the import report
+	Name() string
+	// Order returns the order of each of the provided codes, which are all the codes of the code list used by an instance without a code list order,
+	// or false if this fallback cannot order them
+	Order(codeListID string, codes []string) (map[string]int, bool)
+}
+
+// Fallbacks is a list of fallback orderers, tried in order until one of them can order the codes of a code list
+type Fallbacks []Fallback
+
+// NewFallbacks creates the fallback orderers with the provided names, in the same order.
+// The explicit fallback loads its ordering files from the provided directory.
+func NewFallbacks(names []string, explicitDir string) (Fallbacks, error) {
+	fallbacks := make(Fallbacks, 0, len(names))
+	for _, name := range names {
+		switch name {
+		case FallbackExplicit:
+			if explicitDir == "" {
+				return nil, errors.New("no directory of ordering files given for the explicit fallback orderer")
+			}
+			explicit, err := LoadExplicit(explicitDir)
+			if err != nil {
+				return nil, err
+			}
+			fallbacks = append(fallbacks, explicit)
+		case FallbackChronological:
+			fallbacks = append(fallbacks, Chronological{})
+		case FallbackNatural:
+			fallbacks = append(fallbacks, Natural{})
+		default:
+			return nil, fmt.Errorf("unknown fallback orderer: %s", name)
+		}
+	}
+	return fallbacks, nil
+}
+
+// Order returns the order of each of the provided codes according to the first fallback that can order them,
+// along with the name of that fallback. It returns a nil map if none of the fallbacks can order the codes.
+func (f Fallbacks) Order(codeListID string, codes []string) (map[string]int, string) {
+	for _, fallback := range f {
+		if orders, ok := fallback.Order(codeListID, codes); ok {
+			return orders, fallback.Name()
+		}
+	}
+	return nil, ""
+}
+
+// positions returns the position of each code in the provided sorted list
+func positions(sorted []string) map[string]int {
+	orders := make(map[string]int, len(sorted))
+	for i, code := range sorted {
+		orders[code] = i
+	}
+	return orders
+}
+
+// Explicit is a Fallback that orders the codes of a code list as listed in its ordering file.
+// It can only order the codes of code lists that have an ordering file listing all of them.
+type Explicit struct {
+	local *Local
+}
+
+// LoadExplicit creates an Explicit fallback from the ordering files in the provided directory.
+// Each file is named after its code list ID with a .json extension, and contains the ordered list of codes, e.g. mmm-yy.json: ["Jan-20", "Feb-20"]
+func LoadExplicit(dir string) (*Explicit, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ordering files: %w", err)
+	}
+	codesByCodeListID := make(map[string][]string, len(paths))
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ordering file: %w", err)
+		}
+		codes := []string{}
+		if err := json.Unmarshal(b, &codes); err != nil {
+			return nil, fmt.Errorf("failed to parse ordering file %s: %w", filepath.Base(path), err)
+		}
+		codesByCodeListID[strings.TrimSuffix(filepath.Base(path), ".json")] = codes
+	}
+	return &Explicit{local: NewLocal(codesByCodeListID)}, nil
+}
+
+// Name returns FallbackExplicit
+func (e *Explicit) Name() string {
+	return FallbackExplicit
+}
+
+// Order returns the position of each code in the ordering file of the code list,
+// or false if there is no ordering file for the code list or if any of the codes is not listed
+func (e *Explicit) Order(codeListID string, codes []string) (map[string]int, bool) {
+	listed := e.local.orders[codeListID]
+	orders := make(map[string]int, len(codes))
+	for _, code := range codes {
+		o, found := listed[code]
+		if !found {
+			return nil, false
+		}
+		orders[code] = o
+	}
+	return orders, true
+}
+
+// chronologicalLayouts are the time layouts of the codes that can be ordered by the Chronological fallback
+var chronologicalLayouts = []string{
+	"2006",
+	"2006-01",
+	"2006-01-02",
+	"Jan-06",
+	"Jan-2006",
+	"January 2006",
+	"2006 Jan",
+	"02-01-2006",
+}
+
+// Chronological is a Fallback that orders time-like codes chronologically.
+// It can only order the codes of a code list if all of them are times with the same layout.
+type Chronological struct{}
+
+// Name returns FallbackChronological
+func (Chronological) Name() string {
+	return FallbackChronological
+}
+
+// Order returns the chronological position of each code, or false if the codes are not all times with the same layout
+func (Chronological) Order(codeListID string, codes []string) (map[string]int, bool) {
+	if len(codes) == 0 {
+		return nil, false
+	}
+	for _, layout := range chronologicalLayouts {
+		if times, ok := parseTimes(layout, codes); ok {
+			sorted := append([]string{}, codes...)
+			sort.SliceStable(sorted, func(i, j int) bool {
+				if !times[sorted[i]].Equal(times[sorted[j]]) {
+					return times[sorted[i]].Before(times[sorted[j]])
+				}
+				return sorted[i] < sorted[j]
+			})
+			return positions(sorted), true
+		}
+	}
+	return nil, false
+}
+
+// parseTimes parses every code with the provided layout, returning false if any of them cannot be parsed
+func parseTimes(layout string, codes []string) (map[string]time.Time, bool) {
+	times := make(map[string]time.Time, len(codes))
+	for _, code := range codes {
+		t, err := time.Parse(layout, code)
+		if err != nil {
+			return nil, false
+		}
+		times[code] = t
+	}
+	return times, true
+}
+
+// Natural is a Fallback that orders codes lexically, except for runs of digits which are compared numerically,
+// so that "item2" comes before "item10". It can order the codes of any code list.
+type Natural struct{}
+
+// Name returns FallbackNatural
+func (Natural) Name() string {
+	return FallbackNatural
+}
+
+// Order returns the position of each code in natural order
+func (Natural) Order(codeListID string, codes []string) (map[string]int, bool) {
+	sorted := append([]string{}, codes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if c := compareNatural(sorted[i], sorted[j]); c != 0 {
+			return c < 0
+		}
+		return sorted[i] < sorted[j]
+	})
+	return positions(sorted), true
+}
+
+// compareNatural compares a and b in natural order, returning a negative number if a comes first, a positive number if b comes first, and 0 if they are equivalent
+func compareNatural(a, b string) int {
+	for a != "" && b != "" {
+		chunkA, restA := nextChunk(a)
+		chunkB, restB := nextChunk(b)
+		if c := compareChunks(chunkA, chunkB); c != 0 {
+			return c
+		}
+		a, b = restA, restB
+	}
+	return len(a) - len(b)
+}
+
+// nextChunk splits s after its first run of digits or non-digits
+func nextChunk(s string) (chunk, rest string) {
+	digits := isDigit(rune(s[0]))
+	for i, r := range s {
+		if isDigit(r) != digits {
+			return s[:i], s[i:]
+		}
+	}
+	return s, ""
+}
+
+// compareChunks compares two runs of digits numerically, and any other runs lexically
+func compareChunks(a, b string) int {
+	if !isDigit(rune(a[0])) || !isDigit(rune(b[0])) {
+		return strings.Compare(a, b)
+	}
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
+
+// isDigit returns true if r is an ASCII digit
+func isDigit(r rune) bool {
+	return r < unicode.MaxASCII && unicode.IsDigit(r)
+}
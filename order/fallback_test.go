@@ -0,0 +1,149 @@
+package order_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ONSdigital/dp-dimension-importer/order"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNatural_Order(t *testing.T) {
+	Convey("Given codes with numbers of different lengths", t, func() {
+		codes := []string{"item10", "item2", "Item1", "item02", "item1b", "10", "9"}
+
+		Convey("When they are ordered by the natural fallback", func() {
+			orders, ok := order.Natural{}.Order("items", codes)
+
+			Convey("Then runs of digits are compared numerically and other characters lexically", func() {
+				So(ok, ShouldBeTrue)
+				So(orders, ShouldResemble, map[string]int{
+					"9": 0, "10": 1, "Item1": 2, "item1b": 3, "item02": 4, "item2": 5, "item10": 6,
+				})
+			})
+		})
+	})
+}
+
+func TestChronological_Order(t *testing.T) {
+	Convey("Given month codes with the same layout", t, func() {
+		codes := []string{"Mar-20", "Jan-21", "Dec-19"}
+
+		Convey("When they are ordered by the chronological fallback", func() {
+			orders, ok := order.Chronological{}.Order("mmm-yy", codes)
+
+			Convey("Then they are ordered chronologically", func() {
+				So(ok, ShouldBeTrue)
+				So(orders, ShouldResemble, map[string]int{"Dec-19": 0, "Mar-20": 1, "Jan-21": 2})
+			})
+		})
+	})
+
+	Convey("Given codes that are not all times with the same layout", t, func() {
+		codes := []string{"2020", "Jan-21"}
+
+		Convey("When they are ordered by the chronological fallback", func() {
+			_, ok := order.Chronological{}.Order("time", codes)
+
+			Convey("Then they cannot be ordered", func() {
+				So(ok, ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestExplicit_Order(t *testing.T) {
+	Convey("Given a directory with the ordering file of a code list", t, func() {
+		dir := t.TempDir()
+		So(os.WriteFile(filepath.Join(dir, "sex.json"), []byte(`["all", "male", "female"]`), 0o600), ShouldBeNil)
+		explicit, err := order.LoadExplicit(dir)
+		So(err, ShouldBeNil)
+
+		Convey("When codes that are all listed are ordered", func() {
+			orders, ok := explicit.Order("sex", []string{"female", "all"})
+
+			Convey("Then their positions in the file are returned", func() {
+				So(ok, ShouldBeTrue)
+				So(orders, ShouldResemble, map[string]int{"all": 0, "female": 2})
+			})
+		})
+
+		Convey("When codes that are not all listed are ordered", func() {
+			_, ok := explicit.Order("sex", []string{"female", "unknown"})
+
+			Convey("Then they cannot be ordered", func() {
+				So(ok, ShouldBeFalse)
+			})
+		})
+
+		Convey("When the codes of a code list without ordering file are ordered", func() {
+			_, ok := explicit.Order("geography", []string{"K02000001"})
+
+			Convey("Then they cannot be ordered", func() {
+				So(ok, ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("Given a directory with an invalid ordering file", t, func() {
+		dir := t.TempDir()
+		So(os.WriteFile(filepath.Join(dir, "sex.json"), []byte(`{"all": 0}`), 0o600), ShouldBeNil)
+
+		Convey("When the explicit fallback is loaded", func() {
+			_, err := order.LoadExplicit(dir)
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestFallbacks_Order(t *testing.T) {
+	Convey("Given the chronological and natural fallbacks", t, func() {
+		fallbacks, err := order.NewFallbacks([]string{order.FallbackChronological, order.FallbackNatural}, "")
+		So(err, ShouldBeNil)
+
+		Convey("When time-like codes are ordered", func() {
+			orders, name := fallbacks.Order("yyyy", []string{"2021", "2019"})
+
+			Convey("Then the chronological fallback is used", func() {
+				So(name, ShouldEqual, order.FallbackChronological)
+				So(orders, ShouldResemble, map[string]int{"2019": 0, "2021": 1})
+			})
+		})
+
+		Convey("When other codes are ordered", func() {
+			orders, name := fallbacks.Order("items", []string{"b", "a"})
+
+			Convey("Then the natural fallback is used", func() {
+				So(name, ShouldEqual, order.FallbackNatural)
+				So(orders, ShouldResemble, map[string]int{"a": 0, "b": 1})
+			})
+		})
+	})
+
+	Convey("Given no fallbacks", t, func() {
+		var fallbacks order.Fallbacks
+
+		Convey("When codes are ordered", func() {
+			orders, name := fallbacks.Order("items", []string{"a"})
+
+			Convey("Then they are not ordered", func() {
+				So(orders, ShouldBeNil)
+				So(name, ShouldEqual, "")
+			})
+		})
+	})
+
+	Convey("When fallbacks are created with an unknown name or the explicit fallback without directory", t, func() {
+		_, unknownErr := order.NewFallbacks([]string{"random"}, "")
+		_, explicitErr := order.NewFallbacks([]string{order.FallbackExplicit}, "")
+
+		Convey("Then an error is returned", func() {
+			So(unknownErr, ShouldNotBeNil)
+			So(explicitErr, ShouldNotBeNil)
+		})
+	})
+}
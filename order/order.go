@@ -0,0 +1,58 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Source provides the order of the codes of a code list.
+// The returned map contains an entry for each code with a known order, codes without an order are omitted or nil.
+type Source interface {
+	GetCodesOrder(ctx context.Context, codeListID string, codes []string) (map[string]*int, error)
+}
+
+// Local is a Source that keeps the order of the codes of each code list in memory
+type Local struct {
+	orders map[string]map[string]int
+}
+
+// NewLocal creates a Local source from the ordered list of codes of each code list
+func NewLocal(codesByCodeListID map[string][]string) *Local {
+	l := &Local{orders: make(map[string]map[string]int, len(codesByCodeListID))}
+	for codeListID, codes := range codesByCodeListID {
+		orders := make(map[string]int, len(codes))
+		for i, code := range codes {
+			orders[code] = i
+		}
+		l.orders[codeListID] = orders
+	}
+	return l
+}
+
+// LoadLocal creates a Local source from a JSON file that maps each code list ID to its ordered list of codes, e.g.
+// {"mmm-yy": ["Jan-20", "Feb-20"], "uk-only": ["K02000001"]}
+func LoadLocal(path string) (*Local, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local order file: %w", err)
+	}
+	codesByCodeListID := map[string][]string{}
+	if err := json.Unmarshal(b, &codesByCodeListID); err != nil {
+		return nil, fmt.Errorf("failed to parse local order file: %w", err)
+	}
+	return NewLocal(codesByCodeListID), nil
+}
+
+// GetCodesOrder returns the order of the provided codes of a code list. Codes that are not known are omitted.
+func (l *Local) GetCodesOrder(ctx context.Context, codeListID string, codes []string) (map[string]*int, error) {
+	orders := l.orders[codeListID]
+	codeOrders := make(map[string]*int, len(codes))
+	for _, code := range codes {
+		if o, found := orders[code]; found {
+			codeOrders[code] = &o
+		}
+	}
+	return codeOrders, nil
+}
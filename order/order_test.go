@@ -0,0 +1,75 @@
+package order_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ONSdigital/dp-dimension-importer/order"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var ctx = context.Background()
+
+func TestLocal_GetCodesOrder(t *testing.T) {
+	Convey("Given a local source with the ordered codes of a code list", t, func() {
+		l := order.NewLocal(map[string][]string{"mmm-yy": {"Jan-20", "Feb-20", "Mar-20"}})
+
+		Convey("When the order of known and unknown codes is requested", func() {
+			orders, err := l.GetCodesOrder(ctx, "mmm-yy", []string{"Mar-20", "Jan-20", "Dec-19"})
+
+			Convey("Then the order of the known codes is returned", func() {
+				So(err, ShouldBeNil)
+				So(orders, ShouldHaveLength, 2)
+				So(*orders["Jan-20"], ShouldEqual, 0)
+				So(*orders["Mar-20"], ShouldEqual, 2)
+			})
+		})
+
+		Convey("When the order of the codes of an unknown code list is requested", func() {
+			orders, err := l.GetCodesOrder(ctx, "uk-only", []string{"K02000001"})
+
+			Convey("Then no orders are returned", func() {
+				So(err, ShouldBeNil)
+				So(orders, ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestLoadLocal(t *testing.T) {
+	dir := t.TempDir()
+
+	Convey("Given a valid local order file", t, func() {
+		path := filepath.Join(dir, "valid.json")
+		So(os.WriteFile(path, []byte(`{"uk-only": ["K02000001", "K03000001"]}`), 0o600), ShouldBeNil)
+
+		Convey("Then it is loaded into a local source", func() {
+			l, err := order.LoadLocal(path)
+			So(err, ShouldBeNil)
+			orders, err := l.GetCodesOrder(ctx, "uk-only", []string{"K03000001"})
+			So(err, ShouldBeNil)
+			So(*orders["K03000001"], ShouldEqual, 1)
+		})
+	})
+
+	Convey("Given a local order file that is not valid JSON", t, func() {
+		path := filepath.Join(dir, "invalid.json")
+		So(os.WriteFile(path, []byte(`not json`), 0o600), ShouldBeNil)
+
+		Convey("Then an error is returned", func() {
+			l, err := order.LoadLocal(path)
+			So(l, ShouldBeNil)
+			So(err.Error(), ShouldStartWith, "failed to parse local order file: ")
+		})
+	})
+
+	Convey("Given a local order file that does not exist", t, func() {
+		Convey("Then an error is returned", func() {
+			l, err := order.LoadLocal(filepath.Join(dir, "missing.json"))
+			So(l, ShouldBeNil)
+			So(err.Error(), ShouldStartWith, "failed to read local order file: ")
+		})
+	})
+}
@@ -0,0 +1,164 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-dimension-importer/report"
+	"github.com/ONSdigital/dp-reporter-client/reporter"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// driftErrorContext is the context sent to the error reporter with a DriftError
+const driftErrorContext = "background reconciliation found that the graph database has drifted from dataset API"
+
+// minIdlePoll is the minimum time between two checks of whether the Handler is still handling instance events
+const minIdlePoll = time.Second
+
+// DriftError is reported for an instance whose graph database content no longer matches dataset API
+type DriftError struct {
+	Discrepancies []Discrepancy
+}
+
+func (e *DriftError) Error() string {
+	descriptions := make([]string, len(e.Discrepancies))
+	for i, d := range e.Discrepancies {
+		descriptions[i] = d.String()
+	}
+	return fmt.Sprintf("graph database has drifted from dataset API: %d discrepancies: [%s]", len(e.Discrepancies), strings.Join(descriptions, "; "))
+}
+
+// Background periodically checks the instances imported recently by the service against the graph database,
+// and reports the instances whose graph database content has drifted from dataset API to the ErrorReporter.
+// Checks are rate limited so that they do not compete with live imports: there are at least CheckDelay between two checks,
+// and no check is started while the Reconciler's Handler is handling instance events.
+type Background struct {
+	Reconciler    *Reconciler
+	Reports       *report.Store // the instances whose report was completed within Window are checked
+	ErrorReporter reporter.ErrorReporter
+	Window        time.Duration
+	Interval      time.Duration // time between two passes
+	CheckDelay    time.Duration // minimum time between two instance checks, also used to poll the Handler while it is busy (at least every second)
+
+	notified map[string]struct{} // instances whose drift has already been reported
+	cancel   context.CancelFunc
+	done     chan struct{}
+	mutex    sync.Mutex
+}
+
+// Start runs a reconciliation pass every Interval in a new go-routine, until Close is called
+func (b *Background) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	b.cancel = cancel
+	b.done = make(chan struct{})
+
+	go func() {
+		defer close(b.done)
+		ticker := time.NewTicker(b.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				b.RunOnce(ctx)
+			}
+		}
+	}()
+	log.Info(ctx, "background reconciliation started", log.Data{"window": b.Window.String(), "interval": b.Interval.String()})
+}
+
+// Close stops the background reconciliation, waiting for the check in progress, if any, until the provided context is done
+func (b *Background) Close(ctx context.Context) error {
+	if b.cancel == nil {
+		return nil
+	}
+	b.cancel()
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RunOnce checks every instance whose import completed within Window, one at a time, and returns the results.
+// The drift of an instance is only reported the first time it is found.
+func (b *Background) RunOnce(ctx context.Context) []*Result {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	instanceIDs := b.Reports.CompletedSince(time.Now().Add(-b.Window))
+	results := make([]*Result, 0, len(instanceIDs))
+	for i, instanceID := range instanceIDs {
+		if i > 0 && !wait(ctx, b.CheckDelay) {
+			break
+		}
+		if !b.waitForIdle(ctx) {
+			break
+		}
+		result := b.Reconciler.Check(ctx, instanceID)
+		b.report(ctx, result)
+		results = append(results, result)
+	}
+	return results
+}
+
+// waitForIdle waits until the Handler is not handling any instance events, returning false if the context is done first
+func (b *Background) waitForIdle(ctx context.Context) bool {
+	if b.Reconciler.Handler == nil {
+		return true
+	}
+	for b.Reconciler.Handler.ImportsInProgress() > 0 {
+		if !wait(ctx, max(b.CheckDelay, minIdlePoll)) {
+			return false
+		}
+	}
+	return true
+}
+
+// report logs the outcome of a check, and reports the drift of the instance if it has not been reported already
+func (b *Background) report(ctx context.Context, result *Result) {
+	logData := log.Data{"instance_id": result.InstanceID}
+	if result.Error != "" {
+		logData["error"] = result.Error
+		log.Warn(ctx, "background reconciliation failed to check instance", logData)
+		return
+	}
+	if len(result.Discrepancies) == 0 {
+		delete(b.notified, result.InstanceID)
+		return
+	}
+
+	err := &DriftError{Discrepancies: result.Discrepancies}
+	log.Error(ctx, driftErrorContext, err, logData)
+	if _, found := b.notified[result.InstanceID]; found || b.ErrorReporter == nil {
+		return
+	}
+	if err := b.ErrorReporter.Notify(result.InstanceID, driftErrorContext, err); err != nil {
+		log.Error(ctx, "failed to report instance drift", err, logData)
+		return
+	}
+	if b.notified == nil {
+		b.notified = map[string]struct{}{}
+	}
+	b.notified[result.InstanceID] = struct{}{}
+}
+
+// wait waits for the provided duration, returning false if the context is done first
+func wait(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,172 @@
+package reconcile_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
+	"github.com/ONSdigital/dp-dimension-importer/client"
+	"github.com/ONSdigital/dp-dimension-importer/event"
+	"github.com/ONSdigital/dp-dimension-importer/handler"
+	"github.com/ONSdigital/dp-dimension-importer/reconcile"
+	"github.com/ONSdigital/dp-dimension-importer/report"
+	"github.com/ONSdigital/dp-reporter-client/reporter/reportertest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// completedReports returns a report store where the imports of the provided instances have been completed
+func completedReports(instanceIDs ...string) *report.Store {
+	reports := report.NewStore(10)
+	for _, id := range instanceIDs {
+		r := report.New(id)
+		r.Complete()
+		reports.Put(r)
+	}
+	return reports
+}
+
+func TestBackground_RunOnce(t *testing.T) {
+	Convey("Given a background reconciler and a recently imported instance whose graph database has drifted", t, func() {
+		g := newFakeGraph(wales)
+		errorReporter := reportertest.NewImportErrorReporterMock(nil)
+		b := &reconcile.Background{
+			Reconciler:    newReconciler(g, datasetAPIMock(england, wales)),
+			Reports:       completedReports(testInstanceID),
+			ErrorReporter: errorReporter,
+			Window:        time.Hour,
+		}
+
+		Convey("When a reconciliation pass is run", func() {
+			results := b.RunOnce(ctx)
+
+			Convey("Then the instance is checked and its drift is reported", func() {
+				So(results, ShouldHaveLength, 1)
+				So(results[0].Discrepancies, ShouldHaveLength, 2)
+				So(errorReporter.NotifyCalls(), ShouldHaveLength, 1)
+				So(errorReporter.NotifyCalls()[0].ID, ShouldEqual, testInstanceID)
+				var driftErr *reconcile.DriftError
+				So(errors.As(errorReporter.NotifyCalls()[0].Err, &driftErr), ShouldBeTrue)
+				So(driftErr.Error(), ShouldEqual, `graph database has drifted from dataset API: 2 discrepancies: [`+
+					`dimension_node geography:"E92000001" is "missing" instead of "present"; `+
+					`code_relationship geography:"E92000001" is "missing" instead of "present"]`)
+			})
+
+			Convey("Then the drift is not reported again by the next pass", func() {
+				results = b.RunOnce(ctx)
+				So(results, ShouldHaveLength, 1)
+				So(errorReporter.NotifyCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+
+	Convey("Given a background reconciler and an instance imported before the window", t, func() {
+		clientMock := datasetAPIMock(england)
+		b := &reconcile.Background{
+			Reconciler:    newReconciler(newFakeGraph(), clientMock),
+			Reports:       completedReports(testInstanceID),
+			ErrorReporter: reportertest.NewImportErrorReporterMock(nil),
+			Window:        time.Nanosecond,
+		}
+		time.Sleep(time.Millisecond)
+
+		Convey("When a reconciliation pass is run", func() {
+			results := b.RunOnce(ctx)
+
+			Convey("Then the instance is not checked", func() {
+				So(results, ShouldBeEmpty)
+				So(clientMock.GetInstanceBytesCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a background reconciler whose handler is importing an instance", t, func() {
+		release := make(chan struct{})
+		blockingClient := datasetAPIMock(england)
+		blockingClient.GetInstanceDimensionsInBatchesFunc = func(ctx context.Context, serviceAuthToken string, instanceID string, batchSize int, maxWorkers int) (dataset.Dimensions, string, error) {
+			<-release
+			return dataset.Dimensions{}, "", nil
+		}
+		g := newFakeGraph(england)
+		clientMock := datasetAPIMock(england)
+		r := newReconciler(g, clientMock)
+		r.Handler = &handler.InstanceEventHandler{Store: g.storer(), DatasetAPICli: &client.DatasetAPI{Client: blockingClient}}
+		b := &reconcile.Background{
+			Reconciler: r,
+			Reports:    completedReports(testInstanceID),
+			Window:     time.Hour,
+		}
+
+		handled := make(chan struct{})
+		go func() {
+			defer close(handled)
+			_ = r.Handler.Handle(ctx, event.NewInstance{InstanceID: "other"}) // the import only keeps the handler busy
+		}()
+		for r.Handler.ImportsInProgress() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		Convey("When a reconciliation pass is run until the import is done or a timeout", func() {
+			timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			defer cancel()
+			results := b.RunOnce(timeoutCtx)
+			close(release)
+			<-handled
+
+			Convey("Then no instance is checked while the handler is busy", func() {
+				So(results, ShouldBeEmpty)
+				So(clientMock.GetInstanceBytesCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+// chanReporter is an error reporter that sends every reported error to the channel
+type chanReporter chan error
+
+func (c chanReporter) Notify(id string, errContext string, err error) error {
+	c <- err
+	return nil
+}
+
+func TestBackground_StartClose(t *testing.T) {
+	Convey("Given a background reconciler with a short interval and a drifted instance", t, func() {
+		errorReporter := make(chanReporter, 1)
+		b := &reconcile.Background{
+			Reconciler:    newReconciler(newFakeGraph(), datasetAPIMock(england)),
+			Reports:       completedReports(testInstanceID),
+			ErrorReporter: errorReporter,
+			Window:        time.Hour,
+			Interval:      time.Millisecond,
+		}
+
+		Convey("When it is started and closed once the drift has been reported", func() {
+			b.Start(ctx)
+			var reported error
+			select {
+			case reported = <-errorReporter:
+			case <-time.After(time.Second):
+			}
+			err := b.Close(ctx)
+
+			Convey("Then the drift has been reported and it stops without error", func() {
+				var driftErr *reconcile.DriftError
+				So(errors.As(reported, &driftErr), ShouldBeTrue)
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a background reconciler that has not been started", t, func() {
+		b := &reconcile.Background{}
+
+		Convey("When it is closed", func() {
+			err := b.Close(ctx)
+
+			Convey("Then no error is returned", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
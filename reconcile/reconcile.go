@@ -0,0 +1,335 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
+	"github.com/ONSdigital/dp-api-clients-go/v2/headers"
+	"github.com/ONSdigital/dp-dimension-importer/client"
+	"github.com/ONSdigital/dp-dimension-importer/handler"
+	"github.com/ONSdigital/dp-dimension-importer/model"
+	"github.com/ONSdigital/dp-dimension-importer/report"
+	"github.com/ONSdigital/dp-dimension-importer/store"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Checks performed for each instance
+const (
+	CheckInstanceNode          = "instance_node"
+	CheckDimensionNode         = "dimension_node"
+	CheckNodeID                = "node_id"
+	CheckCodeRelationship      = "code_relationship"
+	CheckObservationConstraint = "observation_constraint"
+)
+
+// Values of Expected and Actual for the checks that compare the presence of something in the graph database
+const (
+	Present = "present"
+	Missing = "missing"
+)
+
+// ErrNoHandler is returned when discrepancies need to be fixed but the Reconciler has no Handler
+var ErrNoHandler = errors.New("no instance event handler to fix the discrepancies")
+
+// Discrepancy describes something that the graph database does not hold as dataset API expects.
+// Expected is the value according to dataset API, and Actual is the value in the graph database.
+type Discrepancy struct {
+	Check       string `json:"check"`
+	DimensionID string `json:"dimension_id,omitempty"`
+	Option      string `json:"option,omitempty"`
+	Expected    string `json:"expected"`
+	Actual      string `json:"actual"`
+}
+
+func (d Discrepancy) String() string {
+	if d.DimensionID == "" {
+		return fmt.Sprintf("%s is %s instead of %s", d.Check, d.Actual, d.Expected)
+	}
+	return fmt.Sprintf("%s %s:%q is %q instead of %q", d.Check, d.DimensionID, d.Option, d.Actual, d.Expected)
+}
+
+// Result is the outcome of reconciling an instance.
+// If the discrepancies have been fixed, Remaining lists the ones found when the instance was checked again after fixing them.
+type Result struct {
+	InstanceID    string        `json:"instance_id"`
+	Discrepancies []Discrepancy `json:"discrepancies"`
+	Fixed         bool          `json:"fixed"`
+	Remaining     []Discrepancy `json:"remaining,omitempty"`
+	Skipped       string        `json:"skipped,omitempty"` // reason why the instance has not been checked
+	Error         string        `json:"error,omitempty"`
+}
+
+// setError records the provided error in the result, as the reason why it was skipped if it is a skippedError
+func (r *Result) setError(err error) {
+	var skipped skippedError
+	if errors.As(err, &skipped) {
+		r.Skipped = skipped.Error()
+		return
+	}
+	r.Error = err.Error()
+}
+
+// skippedError is returned by check for instances that are not imported to the graph database, so there is nothing to compare
+type skippedError struct {
+	profile handler.Profile
+}
+
+func (e skippedError) Error() string {
+	return fmt.Sprintf("instance type is imported with the %s pipeline profile, which does not write to the graph database", e.profile)
+}
+
+// Consistent returns true if the instance has been checked successfully, or skipped, and no discrepancies are left
+func (r *Result) Consistent() bool {
+	if r.Error != "" {
+		return false
+	}
+	if r.Fixed {
+		return len(r.Remaining) == 0
+	}
+	return len(r.Discrepancies) == 0
+}
+
+// Reconciler checks that the graph database holds the nodes and relationships that dataset API expects for an instance
+type Reconciler struct {
+	DatasetAPICli *client.DatasetAPI
+	Store         store.Storer
+	Reader        store.GraphReader
+	Handler       *handler.InstanceEventHandler // used to fix the discrepancies, only required by Fix. Instance types that it does not import to the graph database are skipped
+	CheckNodeIDs  bool                          // whether the node IDs of the dimension options in dataset API are compared with the graph database
+}
+
+// Check compares the graph database with the instance and dimension options in dataset API, returning the discrepancies found
+func (r *Reconciler) Check(ctx context.Context, instanceID string) *Result {
+	result := &Result{InstanceID: instanceID, Discrepancies: []Discrepancy{}}
+	_, _, discrepancies, err := r.check(ctx, instanceID)
+	if err != nil {
+		result.setError(err)
+		return result
+	}
+	result.Discrepancies = discrepancies
+	return result
+}
+
+// Fix checks the instance like Check and, if any discrepancies are found, imports again the parts of the instance that differ
+// using the Handler, checking the instance once more afterwards.
+// Dimension nodes that exist in the graph database for options that do not exist in dataset API cannot be removed, so they remain.
+func (r *Reconciler) Fix(ctx context.Context, instanceID string) *Result {
+	result := &Result{InstanceID: instanceID, Discrepancies: []Discrepancy{}}
+	instance, dimensions, discrepancies, err := r.check(ctx, instanceID)
+	if err != nil {
+		result.setError(err)
+		return result
+	}
+	result.Discrepancies = discrepancies
+
+	plan := repairPlan(discrepancies, dimensions)
+	if plan.IsEmpty() {
+		return result
+	}
+	if r.Handler == nil {
+		result.Error = ErrNoHandler.Error()
+		return result
+	}
+
+	if err := r.Handler.Repair(ctx, instance, plan); err != nil {
+		result.Error = fmt.Sprintf("failed to fix discrepancies: %s", err)
+		return result
+	}
+	result.Fixed = true
+
+	if _, _, result.Remaining, err = r.check(ctx, instanceID); err != nil {
+		result.setError(err)
+	}
+	return result
+}
+
+// check runs all the checks for the instance, returning the instance and dimension options obtained from dataset API
+// along with the discrepancies found. If the Handler does not import the type of the instance to the graph database, a skippedError is returned.
+func (r *Reconciler) check(ctx context.Context, instanceID string) (*model.Instance, []*model.Dimension, []Discrepancy, error) {
+	instance, _, err := r.DatasetAPICli.GetInstance(ctx, instanceID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("dataset api client get instance returned an error: %w", err)
+	}
+	if err := handler.ValidateInstance(instance); err != nil {
+		return nil, nil, nil, err
+	}
+	if r.Handler != nil {
+		if profile := r.Handler.ProfileFor(instance.Type()); profile != handler.ProfileGraph {
+			return nil, nil, nil, skippedError{profile: profile}
+		}
+	}
+	dimensions, _, err := r.DatasetAPICli.GetDimensions(ctx, instanceID, headers.IfMatchAnyETag)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("DatasetAPICli.GetDimensions returned an error: %w", err)
+	}
+
+	discrepancies := []Discrepancy{}
+
+	exists, err := r.Store.InstanceExists(ctx, instanceID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("instance exists check returned an error: %w", err)
+	}
+	if !exists {
+		discrepancies = append(discrepancies, Discrepancy{Check: CheckInstanceNode, Expected: Present, Actual: Missing})
+	}
+
+	dimensionDiscrepancies, err := r.checkDimensionNodes(ctx, instanceID, dimensions)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	discrepancies = append(discrepancies, dimensionDiscrepancies...)
+
+	codeDiscrepancies, err := r.checkCodeRelationships(ctx, instanceID, dimensions)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	discrepancies = append(discrepancies, codeDiscrepancies...)
+
+	constraintExists, err := r.Reader.InstanceConstraintExists(ctx, instanceID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("instance constraint exists check returned an error: %w", err)
+	}
+	if !constraintExists {
+		discrepancies = append(discrepancies, Discrepancy{Check: CheckObservationConstraint, Expected: Present, Actual: Missing})
+	}
+
+	log.Info(ctx, "instance checked against the graph database", log.Data{
+		"instance_id":   instanceID,
+		"options":       len(dimensions),
+		"discrepancies": len(discrepancies),
+	})
+	return instance, dimensions, discrepancies, nil
+}
+
+// checkDimensionNodes checks that every dimension option has a dimension node with the node ID known by dataset API,
+// and that there are no dimension nodes for options that do not exist in dataset API
+func (r *Reconciler) checkDimensionNodes(ctx context.Context, instanceID string, dimensions []*model.Dimension) ([]Discrepancy, error) {
+	discrepancies := []Discrepancy{}
+	ids, optionsByDimension := groupByDimension(dimensions)
+	for _, dimensionID := range ids {
+		nodeIDs, err := r.Reader.GetDimensionNodeIDs(ctx, instanceID, dimensionID)
+		if err != nil {
+			return nil, fmt.Errorf("get dimension node ids returned an error: %w", err)
+		}
+
+		for _, d := range optionsByDimension[dimensionID] {
+			option := d.DBModel().Option
+			nodeID, found := nodeIDs[option]
+			delete(nodeIDs, option)
+			if !found {
+				discrepancies = append(discrepancies, Discrepancy{Check: CheckDimensionNode, DimensionID: dimensionID, Option: option, Expected: Present, Actual: Missing})
+				continue
+			}
+			if r.CheckNodeIDs && d.DBModel().NodeID != nodeID {
+				discrepancies = append(discrepancies, Discrepancy{Check: CheckNodeID, DimensionID: dimensionID, Option: option, Expected: d.DBModel().NodeID, Actual: nodeID})
+			}
+		}
+
+		// any remaining nodes do not correspond to an option in dataset API
+		extra := make([]string, 0, len(nodeIDs))
+		for option := range nodeIDs {
+			extra = append(extra, option)
+		}
+		sort.Strings(extra)
+		for _, option := range extra {
+			discrepancies = append(discrepancies, Discrepancy{Check: CheckDimensionNode, DimensionID: dimensionID, Option: option, Expected: Missing, Actual: Present})
+		}
+	}
+	return discrepancies, nil
+}
+
+// checkCodeRelationships checks that the code of each dimension option has a relationship to the instance node.
+// Options of the time dimension are not checked, as the importer does not create their code relationships.
+func (r *Reconciler) checkCodeRelationships(ctx context.Context, instanceID string, dimensions []*model.Dimension) ([]Discrepancy, error) {
+	codesByCodeList := map[string]map[string]struct{}{}
+	discrepancies := []Discrepancy{}
+	for _, d := range dimensions {
+		codeListID := d.CodeListID()
+		if d.DBModel().DimensionID == "time" || codeListID == "" {
+			continue
+		}
+
+		codes, ok := codesByCodeList[codeListID]
+		if !ok {
+			list, err := r.Reader.GetInstanceCodes(ctx, instanceID, codeListID)
+			if err != nil {
+				return nil, fmt.Errorf("get instance codes returned an error: %w", err)
+			}
+			codes = make(map[string]struct{}, len(list))
+			for _, c := range list {
+				codes[c] = struct{}{}
+			}
+			codesByCodeList[codeListID] = codes
+		}
+
+		if _, found := codes[d.DBModel().Option]; !found {
+			discrepancies = append(discrepancies, Discrepancy{
+				Check:       CheckCodeRelationship,
+				DimensionID: d.DBModel().DimensionID,
+				Option:      d.DBModel().Option,
+				Expected:    Present,
+				Actual:      Missing,
+			})
+		}
+	}
+	return discrepancies, nil
+}
+
+// repairPlan returns the plan to fix the provided discrepancies: the dimension options without dimension node are imported again,
+// while the options whose dimension node exists only get their missing code relationship created, and the node ID of their node patched in dataset API.
+func repairPlan(discrepancies []Discrepancy, dimensions []*model.Dimension) handler.RepairPlan {
+	plan := handler.RepairPlan{}
+	missingNodes := map[string]bool{}
+	missingCodes := map[string]bool{}
+	nodeIDs := map[string]string{}
+	for _, d := range discrepancies {
+		key := report.OptionKey(d.DimensionID, d.Option)
+		switch d.Check {
+		case CheckInstanceNode:
+			plan.InstanceNode = true
+		case CheckObservationConstraint:
+			plan.Constraint = true
+		case CheckDimensionNode:
+			if d.Expected == Present {
+				missingNodes[key] = true
+			} // nodes without an option in dataset API cannot be removed
+		case CheckCodeRelationship:
+			missingCodes[key] = true
+		case CheckNodeID:
+			nodeIDs[key] = d.Actual
+		}
+	}
+
+	for _, d := range dimensions {
+		dimensionID, option := d.DBModel().DimensionID, d.DBModel().Option
+		key := report.OptionKey(dimensionID, option)
+		if missingNodes[key] {
+			plan.Dimensions = append(plan.Dimensions, d)
+			continue
+		}
+		if missingCodes[key] {
+			plan.CodeRelationships = append(plan.CodeRelationships, d)
+		}
+		if nodeID, found := nodeIDs[key]; found {
+			plan.NodeIDs = append(plan.NodeIDs, &dataset.OptionUpdate{Name: dimensionID, Option: option, NodeID: nodeID})
+		}
+	}
+	return plan
+}
+
+// groupByDimension returns the distinct dimension IDs of the provided dimension options in order of appearance, and the options of each dimension
+func groupByDimension(dimensions []*model.Dimension) ([]string, map[string][]*model.Dimension) {
+	ids := []string{}
+	byDimension := map[string][]*model.Dimension{}
+	for _, d := range dimensions {
+		id := d.DBModel().DimensionID
+		if _, found := byDimension[id]; !found {
+			ids = append(ids, id)
+		}
+		byDimension[id] = append(byDimension[id], d)
+	}
+	return ids, byDimension
+}
@@ -0,0 +1,334 @@
+package reconcile_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
+	"github.com/ONSdigital/dp-dimension-importer/client"
+	"github.com/ONSdigital/dp-dimension-importer/handler"
+	"github.com/ONSdigital/dp-dimension-importer/mocks"
+	"github.com/ONSdigital/dp-dimension-importer/reconcile"
+	"github.com/ONSdigital/dp-dimension-importer/store/storertest"
+	"github.com/ONSdigital/dp-graph/v2/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const (
+	testInstanceID = "instance1"
+	testCodeListID = "geography-codes"
+	geography      = "geography"
+)
+
+var (
+	ctx     = context.Background()
+	errMock = errors.New("mock error")
+
+	england = dataset.Dimension{DimensionID: geography, Option: "E92000001", NodeID: "_instance1_geography_E92000001", Links: dataset.Links{CodeList: dataset.Link{ID: testCodeListID}}}
+	wales   = dataset.Dimension{DimensionID: geography, Option: "W92000004", NodeID: "_instance1_geography_W92000004", Links: dataset.Links{CodeList: dataset.Link{ID: testCodeListID}}}
+	year    = dataset.Dimension{DimensionID: "time", Option: "2021", NodeID: "_instance1_time_2021", Links: dataset.Links{CodeList: dataset.Link{ID: "time-codes"}}}
+
+	instanceBytes, _ = json.Marshal(dataset.Instance{Version: dataset.Version{ID: testInstanceID, CSVHeader: []string{"V4_0", "geography_code", "geography", "time_code", "time"}}})
+)
+
+// fakeGraph is an in-memory graph database, holding the dimension nodes and code relationships of the instance
+type fakeGraph struct {
+	mutex    sync.Mutex
+	instance bool
+	nodeIDs  map[string]map[string]string // node IDs by dimension and option
+	codes    map[string][]string          // codes related to the instance by code list
+}
+
+// newFakeGraph returns a fake graph database where every provided option has been imported
+func newFakeGraph(options ...dataset.Dimension) *fakeGraph {
+	g := &fakeGraph{instance: true, nodeIDs: map[string]map[string]string{}, codes: map[string][]string{}}
+	for _, o := range options {
+		g.addNode(o.DimensionID, o.Option, o.NodeID)
+		if o.DimensionID != "time" {
+			g.codes[o.Links.CodeList.ID] = append(g.codes[o.Links.CodeList.ID], o.Option)
+		}
+	}
+	return g
+}
+
+func (g *fakeGraph) addNode(dimensionID, option, nodeID string) {
+	if g.nodeIDs[dimensionID] == nil {
+		g.nodeIDs[dimensionID] = map[string]string{}
+	}
+	g.nodeIDs[dimensionID][option] = nodeID
+}
+
+// storer returns a storer mock that writes to the fake graph database
+func (g *fakeGraph) storer() *storertest.StorerMock {
+	return &storertest.StorerMock{
+		InstanceExistsFunc: func(ctx context.Context, instanceID string) (bool, error) {
+			g.mutex.Lock()
+			defer g.mutex.Unlock()
+			return g.instance, nil
+		},
+		CreateInstanceFunc: func(ctx context.Context, instanceID string, csvHeaders []string) error {
+			g.mutex.Lock()
+			defer g.mutex.Unlock()
+			g.instance = true
+			return nil
+		},
+		AddDimensionsFunc: func(ctx context.Context, instanceID string, dimensions []interface{}) error {
+			return nil
+		},
+		InsertDimensionFunc: func(ctx context.Context, cache map[string]string, cacheMutex *sync.Mutex, instanceID string, dimension *models.Dimension) (*models.Dimension, error) {
+			g.mutex.Lock()
+			defer g.mutex.Unlock()
+			dimension.NodeID = "_" + instanceID + "_" + dimension.DimensionID + "_" + dimension.Option
+			g.addNode(dimension.DimensionID, dimension.Option, dimension.NodeID)
+			return dimension, nil
+		},
+		CreateCodeRelationshipFunc: func(ctx context.Context, instanceID string, codeListID string, code string) error {
+			g.mutex.Lock()
+			defer g.mutex.Unlock()
+			g.codes[codeListID] = append(g.codes[codeListID], code)
+			return nil
+		},
+		CreateInstanceConstraintFunc: func(ctx context.Context, instanceID string) error {
+			return nil
+		},
+		GetCodesOrderFunc: func(ctx context.Context, codeListID string, codes []string) (map[string]*int, error) {
+			return map[string]*int{}, nil
+		},
+	}
+}
+
+// reader returns a graph reader mock that reads from the fake graph database
+func (g *fakeGraph) reader() *storertest.GraphReaderMock {
+	return &storertest.GraphReaderMock{
+		GetDimensionNodeIDsFunc: func(ctx context.Context, instanceID string, dimensionID string) (map[string]string, error) {
+			g.mutex.Lock()
+			defer g.mutex.Unlock()
+			nodeIDs := map[string]string{}
+			for option, nodeID := range g.nodeIDs[dimensionID] {
+				nodeIDs[option] = nodeID
+			}
+			return nodeIDs, nil
+		},
+		GetInstanceCodesFunc: func(ctx context.Context, instanceID string, codeListID string) ([]string, error) {
+			g.mutex.Lock()
+			defer g.mutex.Unlock()
+			return append([]string{}, g.codes[codeListID]...), nil
+		},
+		InstanceConstraintExistsFunc: func(ctx context.Context, instanceID string) (bool, error) {
+			return true, nil
+		},
+	}
+}
+
+// datasetAPIMock returns a dataset API client mock that returns the test instance with the provided options,
+// and applies the patched node IDs to them
+func datasetAPIMock(options ...dataset.Dimension) *mocks.IClientMock {
+	options = append([]dataset.Dimension{}, options...)
+	mutex := &sync.Mutex{}
+	return &mocks.IClientMock{
+		GetInstanceBytesFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) ([]byte, string, error) {
+			return instanceBytes, "", nil
+		},
+		GetInstanceDimensionsInBatchesFunc: func(ctx context.Context, serviceAuthToken string, instanceID string, batchSize int, maxWorkers int) (dataset.Dimensions, string, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return dataset.Dimensions{Items: append([]dataset.Dimension{}, options...)}, "", nil
+		},
+		PatchInstanceDimensionsFunc: func(ctx context.Context, serviceAuthToken string, instanceID string, upserts []*dataset.OptionPost, updates []*dataset.OptionUpdate, ifMatch string) (string, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			for _, u := range updates {
+				for i := range options {
+					if options[i].DimensionID == u.Name && options[i].Option == u.Option && u.NodeID != "" {
+						options[i].NodeID = u.NodeID
+					}
+				}
+			}
+			return "", nil
+		},
+	}
+}
+
+func newReconciler(g *fakeGraph, clientMock *mocks.IClientMock) *reconcile.Reconciler {
+	datasetAPICli := &client.DatasetAPI{Client: clientMock, BatchSize: 10}
+	storer := g.storer()
+	return &reconcile.Reconciler{
+		DatasetAPICli: datasetAPICli,
+		Store:         storer,
+		Reader:        g.reader(),
+		CheckNodeIDs:  true,
+		Handler: &handler.InstanceEventHandler{
+			Store:             storer,
+			DatasetAPICli:     datasetAPICli,
+			BatchSize:         10,
+			EnablePatchNodeID: true,
+		},
+	}
+}
+
+func TestReconciler_Check(t *testing.T) {
+	Convey("Given a graph database that holds every dimension option of the instance", t, func() {
+		r := newReconciler(newFakeGraph(england, wales, year), datasetAPIMock(england, wales, year))
+
+		Convey("When the instance is checked", func() {
+			result := r.Check(ctx, testInstanceID)
+
+			Convey("Then no discrepancies are found", func() {
+				So(result.Error, ShouldBeEmpty)
+				So(result.Discrepancies, ShouldBeEmpty)
+				So(result.Consistent(), ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given a graph database without the instance node, a dimension node and a code relationship, and with a different node ID and an extra node", t, func() {
+		g := newFakeGraph(wales, year)
+		g.instance = false
+		g.nodeIDs[geography][wales.Option] = "other"
+		g.addNode(geography, "S92000003", "_instance1_geography_S92000003")
+		g.codes[testCodeListID] = nil
+		r := newReconciler(g, datasetAPIMock(england, wales, year))
+
+		Convey("When the instance is checked", func() {
+			result := r.Check(ctx, testInstanceID)
+
+			Convey("Then every discrepancy is returned", func() {
+				So(result.Error, ShouldBeEmpty)
+				So(result.Discrepancies, ShouldResemble, []reconcile.Discrepancy{
+					{Check: reconcile.CheckInstanceNode, Expected: reconcile.Present, Actual: reconcile.Missing},
+					{Check: reconcile.CheckDimensionNode, DimensionID: geography, Option: england.Option, Expected: reconcile.Present, Actual: reconcile.Missing},
+					{Check: reconcile.CheckNodeID, DimensionID: geography, Option: wales.Option, Expected: wales.NodeID, Actual: "other"},
+					{Check: reconcile.CheckDimensionNode, DimensionID: geography, Option: "S92000003", Expected: reconcile.Missing, Actual: reconcile.Present},
+					{Check: reconcile.CheckCodeRelationship, DimensionID: geography, Option: england.Option, Expected: reconcile.Present, Actual: reconcile.Missing},
+					{Check: reconcile.CheckCodeRelationship, DimensionID: geography, Option: wales.Option, Expected: reconcile.Present, Actual: reconcile.Missing},
+				})
+				So(result.Consistent(), ShouldBeFalse)
+			})
+
+			Convey("Then nothing is written to the graph database", func() {
+				So(r.Store.(*storertest.StorerMock).InsertDimensionCalls(), ShouldBeEmpty)
+				So(r.Store.(*storertest.StorerMock).CreateInstanceCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a handler that does not import the instance type to the graph database", t, func() {
+		g := newFakeGraph()
+		g.instance = false
+		clientMock := datasetAPIMock(england)
+		r := newReconciler(g, clientMock)
+		r.Handler.DefaultProfile = handler.ProfileOrderOnly
+
+		Convey("When the instance is checked", func() {
+			result := r.Check(ctx, testInstanceID)
+
+			Convey("Then the instance is skipped without any discrepancies", func() {
+				So(result.Skipped, ShouldEqual, "instance type is imported with the order_only pipeline profile, which does not write to the graph database")
+				So(result.Discrepancies, ShouldBeEmpty)
+				So(result.Consistent(), ShouldBeTrue)
+				So(clientMock.GetInstanceDimensionsInBatchesCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a dataset API that fails to return the instance", t, func() {
+		clientMock := datasetAPIMock(england)
+		clientMock.GetInstanceBytesFunc = func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, ifMatch string) ([]byte, string, error) {
+			return nil, "", errMock
+		}
+		r := newReconciler(newFakeGraph(england), clientMock)
+
+		Convey("When the instance is checked", func() {
+			result := r.Check(ctx, testInstanceID)
+
+			Convey("Then the error is returned in the result", func() {
+				So(result.Error, ShouldEqual, "dataset api client get instance returned an error: mock error")
+				So(result.Consistent(), ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestReconciler_Fix(t *testing.T) {
+	Convey("Given a graph database without the instance node, a dimension node and a code relationship, and with a different node ID and an extra node", t, func() {
+		g := newFakeGraph(wales, year)
+		g.instance = false
+		g.nodeIDs[geography][wales.Option] = "other"
+		g.addNode(geography, "S92000003", "_instance1_geography_S92000003")
+		g.codes[testCodeListID] = nil
+		clientMock := datasetAPIMock(england, wales, year)
+		r := newReconciler(g, clientMock)
+
+		Convey("When the instance is fixed", func() {
+			result := r.Fix(ctx, testInstanceID)
+
+			Convey("Then the instance node is created and only the option without dimension node is imported again", func() {
+				So(result.Error, ShouldBeEmpty)
+				So(result.Fixed, ShouldBeTrue)
+				storer := r.Store.(*storertest.StorerMock)
+				So(storer.CreateInstanceCalls(), ShouldHaveLength, 1)
+				So(storer.InsertDimensionCalls(), ShouldHaveLength, 1)
+				So(storer.InsertDimensionCalls()[0].Dimension.Option, ShouldEqual, england.Option)
+			})
+
+			Convey("Then the missing code relationship of the existing dimension node is created without inserting it again", func() {
+				storer := r.Store.(*storertest.StorerMock)
+				So(storer.CreateCodeRelationshipCalls(), ShouldHaveLength, 2)
+				So(storer.CreateCodeRelationshipCalls()[0].Code, ShouldEqual, england.Option)
+				So(storer.CreateCodeRelationshipCalls()[1].Code, ShouldEqual, wales.Option)
+				So(g.nodeIDs[geography][wales.Option], ShouldEqual, "other")
+			})
+
+			Convey("Then the node ID of the existing dimension node is patched in dataset API", func() {
+				So(clientMock.PatchInstanceDimensionsCalls(), ShouldHaveLength, 2)
+				So(clientMock.PatchInstanceDimensionsCalls()[0].Updates, ShouldHaveLength, 1)
+				So(clientMock.PatchInstanceDimensionsCalls()[0].Updates[0].Option, ShouldEqual, england.Option)
+				So(clientMock.PatchInstanceDimensionsCalls()[1].Updates, ShouldResemble, []*dataset.OptionUpdate{
+					{Name: geography, Option: wales.Option, NodeID: "other"},
+				})
+			})
+
+			Convey("Then only the extra node, which cannot be removed, remains", func() {
+				So(result.Discrepancies, ShouldHaveLength, 6)
+				So(result.Remaining, ShouldResemble, []reconcile.Discrepancy{
+					{Check: reconcile.CheckDimensionNode, DimensionID: geography, Option: "S92000003", Expected: reconcile.Missing, Actual: reconcile.Present},
+				})
+				So(result.Consistent(), ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("Given a graph database that holds every dimension option of the instance", t, func() {
+		g := newFakeGraph(england, wales)
+		r := newReconciler(g, datasetAPIMock(england, wales))
+
+		Convey("When the instance is fixed", func() {
+			result := r.Fix(ctx, testInstanceID)
+
+			Convey("Then nothing is repaired", func() {
+				So(result.Fixed, ShouldBeFalse)
+				So(result.Consistent(), ShouldBeTrue)
+				So(r.Store.(*storertest.StorerMock).InsertDimensionCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a reconciler without a handler and a graph database without the instance node", t, func() {
+		g := newFakeGraph(england)
+		g.instance = false
+		r := newReconciler(g, datasetAPIMock(england))
+		r.Handler = nil
+
+		Convey("When the instance is fixed", func() {
+			result := r.Fix(ctx, testInstanceID)
+
+			Convey("Then the no handler error is returned in the result", func() {
+				So(result.Error, ShouldEqual, reconcile.ErrNoHandler.Error())
+				So(result.Fixed, ShouldBeFalse)
+			})
+		})
+	})
+}
@@ -0,0 +1,433 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-dimension-importer/event"
+)
+
+// Possible values of a report status
+const (
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+)
+
+// Stage names used to record the time taken by each part of the import
+const (
+	StageGetDimensions    = "get_dimensions"
+	StageGetInstance      = "get_instance"
+	StageCreateInstance   = "create_instance"
+	StageInsertDimensions = "insert_dimensions"
+	StageCreateConstraint = "create_constraint"
+	StagePatchOrders      = "patch_orders"
+	StageVerifyPatches    = "verify_patches"
+	StageProduceCompleted = "produce_completed"
+)
+
+// Report holds the data-quality information gathered while importing the dimensions of an instance.
+// All methods are concurrency safe and can be called on a nil Report, in which case nothing is recorded.
+type Report struct {
+	mutex                    sync.RWMutex
+	instanceID               string
+	status                   string
+	errorMessage             string
+	optionsPerDimension      map[string]int
+	seenOptions              map[string]struct{}
+	codeLists                map[string]struct{}
+	optionsWithoutOrder      []string
+	orderFallbacks           map[string]string
+	skippedCodeRelationships []string
+	duplicateOptions         []string
+	stageDurations           map[string]time.Duration
+	stageOrder               []string
+	currentStage             string
+	graphCalls               int64
+	attempt                  int
+	rebuild                  bool
+	incremental              bool
+	insertedOptions          int
+	completedAt              time.Time
+}
+
+// Stage represents the time taken by a single stage of the import
+type Stage struct {
+	Name       string `json:"name"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// Summary is an immutable snapshot of a Report, suitable for serialising
+type Summary struct {
+	InstanceID               string            `json:"instance_id"`
+	Status                   string            `json:"status"`
+	Error                    string            `json:"error,omitempty"`
+	OptionsPerDimension      map[string]int    `json:"options_per_dimension"`
+	OptionsWithoutOrder      []string          `json:"options_without_order"`
+	OrderFallbacks           map[string]string `json:"order_fallbacks"` // fallback orderer used by dimension, for dimensions with options without a code list order
+	SkippedCodeRelationships []string          `json:"skipped_code_relationships"`
+	DuplicateOptions         []string          `json:"duplicate_options"`
+	Stages                   []Stage           `json:"stages"`
+	GraphCalls               int64             `json:"graph_calls"`
+	Attempt                  int               `json:"attempt"`          // number of consecutive imports of the instance, including this one
+	Rebuild                  bool              `json:"rebuild"`          // whether the existing nodes of the instance have been deleted to import it again
+	Incremental              bool              `json:"incremental"`      // whether only the options missing from the existing instance have been imported
+	InsertedOptions          int               `json:"inserted_options"` // number of options inserted by an incremental import
+}
+
+// New creates a new in progress Report for the provided instanceID
+func New(instanceID string) *Report {
+	return &Report{
+		instanceID:          instanceID,
+		status:              StatusInProgress,
+		attempt:             1,
+		optionsPerDimension: map[string]int{},
+		orderFallbacks:      map[string]string{},
+		seenOptions:         map[string]struct{}{},
+		codeLists:           map[string]struct{}{},
+		stageDurations:      map[string]time.Duration{},
+	}
+}
+
+// OptionKey returns the key used to identify a dimension option in a report
+func OptionKey(dimensionID, option string) string {
+	return fmt.Sprintf("%s:%s", dimensionID, option)
+}
+
+// InstanceID returns the ID of the instance this report refers to
+func (r *Report) InstanceID() string {
+	if r == nil {
+		return ""
+	}
+	return r.instanceID
+}
+
+// AddOption records an option for the provided dimension.
+// If the same (dimension, option) pair has already been added, it is recorded as a duplicate.
+func (r *Report) AddOption(dimensionID, option string) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := OptionKey(dimensionID, option)
+	if _, found := r.seenOptions[key]; found {
+		r.duplicateOptions = append(r.duplicateOptions, key)
+		return
+	}
+	r.seenOptions[key] = struct{}{}
+	r.optionsPerDimension[dimensionID]++
+}
+
+// AddCodeList records a code list used by the dimensions of the instance, ignoring empty code list IDs
+func (r *Report) AddCodeList(codeListID string) {
+	if r == nil || codeListID == "" {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.codeLists[codeListID] = struct{}{}
+}
+
+// CodeLists returns the sorted IDs of the code lists that have been recorded
+func (r *Report) CodeLists() []string {
+	if r == nil {
+		return []string{}
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	codeLists := make([]string, 0, len(r.codeLists))
+	for id := range r.codeLists {
+		codeLists = append(codeLists, id)
+	}
+	sort.Strings(codeLists)
+	return codeLists
+}
+
+// OptionsHash returns the hex encoded SHA-256 hash of the distinct options that have been added, identified by their OptionKey,
+// sorted and each followed by a new line, so that it does not depend on the order in which they have been added
+func (r *Report) OptionsHash() string {
+	if r == nil {
+		return ""
+	}
+	r.mutex.RLock()
+	keys := make([]string, 0, len(r.seenOptions))
+	for key := range r.seenOptions {
+		keys = append(keys, key)
+	}
+	r.mutex.RUnlock()
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key + "\n"))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// OptionWithoutOrder records an option for which no order could be found
+func (r *Report) OptionWithoutOrder(dimensionID, option string) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.optionsWithoutOrder = append(r.optionsWithoutOrder, OptionKey(dimensionID, option))
+}
+
+// OrderFallback records the fallback orderer used to order the options of the provided dimension that have no code list order
+func (r *Report) OrderFallback(dimensionID, fallback string) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.orderFallbacks[dimensionID] = fallback
+}
+
+// SkippedCodeRelationship records an option for which the code relationship was not created
+func (r *Report) SkippedCodeRelationship(dimensionID, option string) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.skippedCodeRelationships = append(r.skippedCodeRelationships, OptionKey(dimensionID, option))
+}
+
+// GraphCall records that a call has been made to the graph database
+func (r *Report) GraphCall() {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.graphCalls++
+}
+
+// StageCompleted adds the provided duration to the total time taken by the named stage
+func (r *Report) StageCompleted(stage string, d time.Duration) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, found := r.stageDurations[stage]; !found {
+		r.stageOrder = append(r.stageOrder, stage)
+	}
+	r.stageDurations[stage] += d
+}
+
+// StartStage starts timing the named stage and returns a func that must be called once the stage is done
+func (r *Report) StartStage(stage string) func() {
+	if r != nil {
+		r.mutex.Lock()
+		r.currentStage = stage
+		r.mutex.Unlock()
+	}
+	start := time.Now()
+	return func() {
+		r.StageCompleted(stage, time.Since(start))
+	}
+}
+
+// CurrentStage returns the name of the last stage that has been started, or an empty string if none has
+func (r *Report) CurrentStage() string {
+	if r == nil {
+		return ""
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.currentStage
+}
+
+// SetAttempt records the number of consecutive imports of the instance, including the one of this report
+func (r *Report) SetAttempt(attempt int) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.attempt = attempt
+}
+
+// Attempt returns the number of consecutive imports of the instance, including the one of this report
+func (r *Report) Attempt() int {
+	if r == nil {
+		return 0
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.attempt
+}
+
+// NextAttempt returns the attempt number of an import following the one of this report: the next consecutive attempt if this import failed, 1 otherwise
+func (r *Report) NextAttempt() int {
+	if r == nil {
+		return 1
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if r.status != StatusFailed {
+		return 1
+	}
+	return r.attempt + 1
+}
+
+// Rebuilt records that the existing nodes of the instance have been deleted to import it again
+func (r *Report) Rebuilt() {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.rebuild = true
+}
+
+// IsRebuild returns whether the existing nodes of the instance have been deleted to import it again
+func (r *Report) IsRebuild() bool {
+	if r == nil {
+		return false
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.rebuild
+}
+
+// Incremental records that only the options missing from the existing instance are imported
+func (r *Report) Incremental() {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.incremental = true
+}
+
+// InsertedOptions adds the provided number of options to the options inserted by an incremental import
+func (r *Report) InsertedOptions(n int) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.insertedOptions += n
+}
+
+// Complete marks the report as completed
+func (r *Report) Complete() {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.status = StatusCompleted
+	r.completedAt = time.Now()
+}
+
+// CompletedAt returns the time when the report was marked as completed, or the zero time if it has not been completed
+func (r *Report) CompletedAt() time.Time {
+	if r == nil {
+		return time.Time{}
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.completedAt
+}
+
+// Fail marks the report as failed with the provided error
+func (r *Report) Fail(err error) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.status = StatusFailed
+	if err != nil {
+		r.errorMessage = err.Error()
+	}
+}
+
+// Summary returns a snapshot of the current state of the report
+func (r *Report) Summary() Summary {
+	if r == nil {
+		return Summary{}
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	s := Summary{
+		InstanceID:               r.instanceID,
+		Status:                   r.status,
+		Error:                    r.errorMessage,
+		OptionsPerDimension:      make(map[string]int, len(r.optionsPerDimension)),
+		OptionsWithoutOrder:      append([]string{}, r.optionsWithoutOrder...),
+		OrderFallbacks:           make(map[string]string, len(r.orderFallbacks)),
+		SkippedCodeRelationships: append([]string{}, r.skippedCodeRelationships...),
+		DuplicateOptions:         append([]string{}, r.duplicateOptions...),
+		Stages:                   make([]Stage, 0, len(r.stageOrder)),
+		GraphCalls:               r.graphCalls,
+		Attempt:                  r.attempt,
+		Rebuild:                  r.rebuild,
+		Incremental:              r.incremental,
+		InsertedOptions:          r.insertedOptions,
+	}
+	for k, v := range r.optionsPerDimension {
+		s.OptionsPerDimension[k] = v
+	}
+	for k, v := range r.orderFallbacks {
+		s.OrderFallbacks[k] = v
+	}
+	for _, name := range r.stageOrder {
+		s.Stages = append(s.Stages, Stage{Name: name, DurationMS: r.stageDurations[name].Milliseconds()})
+	}
+
+	// options are recorded concurrently, so they are sorted to get a deterministic output
+	sort.Strings(s.OptionsWithoutOrder)
+	sort.Strings(s.SkippedCodeRelationships)
+	sort.Strings(s.DuplicateOptions)
+	return s
+}
+
+// Event returns the kafka event representation of the report
+func (r *Report) Event() event.ImportReport {
+	s := r.Summary()
+
+	dimensionNames := make([]string, 0, len(s.OptionsPerDimension))
+	for name := range s.OptionsPerDimension {
+		dimensionNames = append(dimensionNames, name)
+	}
+	sort.Strings(dimensionNames)
+
+	e := event.ImportReport{
+		InstanceID:               s.InstanceID,
+		Status:                   s.Status,
+		Error:                    s.Error,
+		Dimensions:               make([]event.DimensionReport, 0, len(dimensionNames)),
+		OptionsWithoutOrder:      s.OptionsWithoutOrder,
+		OrderFallbacks:           make([]event.OrderFallbackReport, 0, len(s.OrderFallbacks)),
+		SkippedCodeRelationships: s.SkippedCodeRelationships,
+		DuplicateOptions:         s.DuplicateOptions,
+		Stages:                   make([]event.StageReport, 0, len(s.Stages)),
+		GraphCalls:               s.GraphCalls,
+		Attempt:                  int32(s.Attempt),
+		Rebuild:                  s.Rebuild,
+		Incremental:              s.Incremental,
+	}
+	for _, name := range dimensionNames {
+		e.Dimensions = append(e.Dimensions, event.DimensionReport{Name: name, OptionCount: int64(s.OptionsPerDimension[name])})
+		if fallback, found := s.OrderFallbacks[name]; found {
+			e.OrderFallbacks = append(e.OrderFallbacks, event.OrderFallbackReport{Name: name, Fallback: fallback})
+		}
+	}
+	for _, stage := range s.Stages {
+		e.Stages = append(e.Stages, event.StageReport{Name: stage.Name, DurationMS: stage.DurationMS})
+	}
+	return e
+}
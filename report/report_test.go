@@ -0,0 +1,233 @@
+package report
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-dimension-importer/event"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReport(t *testing.T) {
+	Convey("Given a new report", t, func() {
+		r := New("instance1")
+
+		Convey("When options are recorded concurrently, including a duplicate", func() {
+			wg := &sync.WaitGroup{}
+			for _, o := range []string{"England", "Wales", "Scotland", "Wales"} {
+				wg.Add(1)
+				go func(option string) {
+					defer wg.Done()
+					r.AddOption("geography", option)
+					r.GraphCall()
+				}(o)
+			}
+			wg.Wait()
+			r.AddOption("time", "2021")
+			r.OptionWithoutOrder("geography", "Wales")
+			r.SkippedCodeRelationship("time", "2021")
+			r.OrderFallback("time", "chronological")
+			r.StageCompleted(StageGetDimensions, 2*time.Millisecond)
+			r.StageCompleted(StageInsertDimensions, 3*time.Millisecond)
+			r.StageCompleted(StageGetDimensions, 5*time.Millisecond)
+			r.Complete()
+
+			Convey("Then the summary contains the expected values", func() {
+				s := r.Summary()
+				So(s.InstanceID, ShouldEqual, "instance1")
+				So(s.Status, ShouldEqual, StatusCompleted)
+				So(s.OptionsPerDimension, ShouldResemble, map[string]int{"geography": 3, "time": 1})
+				So(s.DuplicateOptions, ShouldResemble, []string{"geography:Wales"})
+				So(s.OptionsWithoutOrder, ShouldResemble, []string{"geography:Wales"})
+				So(s.SkippedCodeRelationships, ShouldResemble, []string{"time:2021"})
+				So(s.OrderFallbacks, ShouldResemble, map[string]string{"time": "chronological"})
+				So(s.GraphCalls, ShouldEqual, 4)
+				So(s.Stages, ShouldResemble, []Stage{
+					{Name: StageGetDimensions, DurationMS: 7},
+					{Name: StageInsertDimensions, DurationMS: 3},
+				})
+			})
+
+			Convey("Then the event contains the expected values, with dimensions sorted by name", func() {
+				e := r.Event()
+				So(e.InstanceID, ShouldEqual, "instance1")
+				So(e.Status, ShouldEqual, StatusCompleted)
+				So(e.Dimensions, ShouldResemble, []event.DimensionReport{
+					{Name: "geography", OptionCount: 3},
+					{Name: "time", OptionCount: 1},
+				})
+				So(e.OrderFallbacks, ShouldResemble, []event.OrderFallbackReport{{Name: "time", Fallback: "chronological"}})
+				So(e.GraphCalls, ShouldEqual, 4)
+				So(e.Stages, ShouldHaveLength, 2)
+				So(e.Attempt, ShouldEqual, 1)
+				So(e.Rebuild, ShouldBeFalse)
+				So(e.Incremental, ShouldBeFalse)
+			})
+		})
+
+		Convey("When a later attempt of a forced or incremental import is recorded", func() {
+			r.SetAttempt(3)
+			r.Rebuilt()
+			r.Incremental()
+
+			Convey("Then the event contains the same attempt, rebuild and incremental values as the summary", func() {
+				s, e := r.Summary(), r.Event()
+				So(e.Attempt, ShouldEqual, 3)
+				So(e.Rebuild, ShouldEqual, s.Rebuild)
+				So(e.Rebuild, ShouldBeTrue)
+				So(e.Incremental, ShouldEqual, s.Incremental)
+				So(e.Incremental, ShouldBeTrue)
+			})
+		})
+
+		Convey("When options and code lists are recorded in different orders", func() {
+			other := New("instance1")
+			for _, o := range []string{"Wales", "England", "Wales"} {
+				r.AddOption("geography", o)
+				other.AddOption("geography", o)
+			}
+			r.AddOption("time", "2021")
+			r.AddCodeList("time-list")
+			r.AddCodeList("geography-list")
+			other.AddCodeList("")
+			other.AddCodeList("geography-list")
+			other.AddCodeList("time-list")
+			other.AddOption("time", "2021")
+
+			Convey("Then the code lists are sorted and empty code list IDs are ignored", func() {
+				So(r.CodeLists(), ShouldResemble, []string{"geography-list", "time-list"})
+				So(other.CodeLists(), ShouldResemble, r.CodeLists())
+			})
+
+			Convey("Then the options hash only depends on the distinct options", func() {
+				So(r.OptionsHash(), ShouldEqual, "86cb1cc5dabb977cd31365b1cd6f21561026e508e2fbf9e7677bff3cae5256fd")
+				So(other.OptionsHash(), ShouldEqual, r.OptionsHash())
+				other.AddOption("time", "2022")
+				So(other.OptionsHash(), ShouldNotEqual, r.OptionsHash())
+			})
+		})
+
+		Convey("When the report is failed", func() {
+			r.Fail(errors.New("boom"))
+
+			Convey("Then the summary contains the failed status and error message", func() {
+				s := r.Summary()
+				So(s.Status, ShouldEqual, StatusFailed)
+				So(s.Error, ShouldEqual, "boom")
+			})
+		})
+	})
+
+	Convey("Given a nil report", t, func() {
+		var r *Report
+
+		Convey("Then recording values does not panic and the summary is empty", func() {
+			r.AddOption("geography", "England")
+			r.GraphCall()
+			r.StartStage(StageGetInstance)()
+			r.AddCodeList("geography-list")
+			r.Fail(errors.New("boom"))
+			So(r.Summary(), ShouldResemble, Summary{})
+			So(r.CodeLists(), ShouldBeEmpty)
+			So(r.OptionsHash(), ShouldEqual, "")
+		})
+	})
+}
+
+func TestStore(t *testing.T) {
+	Convey("Given a store with size 2", t, func() {
+		s := NewStore(2)
+		r1, r2, r3 := New("1"), New("2"), New("3")
+		s.Put(r1)
+		s.Put(r2)
+
+		Convey("When a report is retrieved", func() {
+			r, found := s.Get("1")
+
+			Convey("Then the expected report is returned", func() {
+				So(found, ShouldBeTrue)
+				So(r, ShouldEqual, r1)
+			})
+		})
+
+		Convey("When a third report is added", func() {
+			s.Put(r3)
+
+			Convey("Then the oldest report is evicted", func() {
+				_, found := s.Get("1")
+				So(found, ShouldBeFalse)
+				_, found = s.Get("2")
+				So(found, ShouldBeTrue)
+				_, found = s.Get("3")
+				So(found, ShouldBeTrue)
+			})
+		})
+
+		Convey("When a report for an existing instance is added", func() {
+			r1b := New("1")
+			s.Put(r1b)
+			s.Put(r3)
+
+			Convey("Then it replaces the existing report and becomes the newest one", func() {
+				r, found := s.Get("1")
+				So(found, ShouldBeTrue)
+				So(r, ShouldEqual, r1b)
+				_, found = s.Get("2")
+				So(found, ShouldBeFalse)
+			})
+		})
+
+		Convey("When a report for an existing instance is added and then restored", func() {
+			r1b := New("1")
+			s.Put(r1b)
+			s.Restore(r1b, r1)
+
+			Convey("Then the previous report is kept", func() {
+				r, found := s.Get("1")
+				So(found, ShouldBeTrue)
+				So(r, ShouldEqual, r1)
+			})
+		})
+
+		Convey("When a report for a new instance is added and then restored without a previous report", func() {
+			s.Put(r3)
+			s.Restore(r3, nil)
+
+			Convey("Then it is removed", func() {
+				_, found := s.Get("3")
+				So(found, ShouldBeFalse)
+				_, found = s.Get("2")
+				So(found, ShouldBeTrue)
+			})
+		})
+
+		Convey("When a report that has already been replaced is restored", func() {
+			r1b, r1c := New("1"), New("1")
+			s.Put(r1b)
+			s.Put(r1c)
+			s.Restore(r1b, r1)
+
+			Convey("Then the newer report is kept", func() {
+				r, found := s.Get("1")
+				So(found, ShouldBeTrue)
+				So(r, ShouldEqual, r1c)
+			})
+		})
+
+		Convey("When only the second report has been completed", func() {
+			before := time.Now()
+			r2.Complete()
+
+			Convey("Then only its instance is returned as completed since before it was completed", func() {
+				So(s.CompletedSince(before), ShouldResemble, []string{"2"})
+				So(r1.CompletedAt().IsZero(), ShouldBeTrue)
+			})
+
+			Convey("Then no instances are returned as completed since after it was completed", func() {
+				So(s.CompletedSince(time.Now().Add(time.Second)), ShouldBeEmpty)
+			})
+		})
+	})
+}
@@ -0,0 +1,100 @@
+package report
+
+import (
+	"sync"
+	"time"
+)
+
+// Store keeps the most recent reports in memory, so that they can be retrieved after an import has finished.
+// Once the store is full, the oldest report is evicted to make room for a new one.
+type Store struct {
+	mutex   sync.RWMutex
+	size    int
+	reports map[string]*Report
+	order   []string
+}
+
+// NewStore creates a new Store that keeps up to size reports
+func NewStore(size int) *Store {
+	if size < 1 {
+		size = 1
+	}
+	return &Store{
+		size:    size,
+		reports: make(map[string]*Report, size),
+	}
+}
+
+// Put adds the provided report to the store, replacing any existing report for the same instance
+func (s *Store) Put(r *Report) {
+	if r == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id := r.InstanceID()
+	if _, found := s.reports[id]; found {
+		s.remove(id)
+	}
+	for len(s.order) >= s.size {
+		delete(s.reports, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.reports[id] = r
+	s.order = append(s.order, id)
+}
+
+// Restore replaces the provided report with the previous report of the same instance, or removes it if previous is nil,
+// unless it has already been replaced by another report
+func (s *Store) Restore(r, previous *Report) {
+	if r == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id := r.InstanceID()
+	if s.reports[id] != r {
+		return
+	}
+	if previous == nil {
+		s.remove(id)
+		return
+	}
+	s.reports[id] = previous
+}
+
+// Get returns the report for the provided instanceID, if it is present in the store
+func (s *Store) Get(instanceID string) (*Report, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	r, found := s.reports[instanceID]
+	return r, found
+}
+
+// CompletedSince returns the instance IDs of the reports in the store that were completed at or after the provided time,
+// from the oldest to the most recently added report
+func (s *Store) CompletedSince(t time.Time) []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	ids := []string{}
+	for _, id := range s.order {
+		completedAt := s.reports[id].CompletedAt()
+		if !completedAt.IsZero() && !completedAt.Before(t) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// remove deletes the report for the provided instanceID. The caller must hold the write lock.
+func (s *Store) remove(instanceID string) {
+	delete(s.reports, instanceID)
+	for i, id := range s.order {
+		if id == instanceID {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// ErrIncompatibleChange is returned when the event struct of a schema cannot be described by appending a new version to it,
+// because its fields are not the fields of the latest version followed by new ones
+var ErrIncompatibleChange = errors.New("event struct is not compatible with the latest version of the schema")
+
+// recordDefinition is the definition of an avro record
+type recordDefinition struct {
+	Type      string            `json:"type"`
+	Name      string            `json:"name"`
+	Namespace *string           `json:"namespace,omitempty"`
+	Fields    []fieldDefinition `json:"fields"`
+}
+
+// fieldDefinition is the definition of a field of an avro record
+type fieldDefinition struct {
+	Name    string      `json:"name"`
+	Type    interface{} `json:"type"`
+	Default interface{} `json:"default,omitempty"` // nil if the field has no default
+}
+
+// arrayDefinition is the definition of an avro array
+type arrayDefinition struct {
+	Type  string      `json:"type"`
+	Items interface{} `json:"items"`
+}
+
+// Generate returns the definition of the schema generated from the avro tags of its Event struct.
+// The fields that are already defined by the latest version keep their default value, if any, and new fields get the zero value of their type as default,
+// so that the generated definition can be appended as a new version of the schema.
+func (v *Versioned) Generate() (string, error) {
+	if v.Event == nil {
+		return "", fmt.Errorf("schema %s has no event struct", v.Name)
+	}
+	latest, err := v.latestFields()
+	if err != nil {
+		return "", err
+	}
+
+	typ := reflect.TypeOf(v.Event)
+	if typ.Kind() != reflect.Struct {
+		return "", fmt.Errorf("event of schema %s is not a struct", v.Name)
+	}
+	record, err := generateRecord(v.Name, typ)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate schema %s: %w", v.Name, err)
+	}
+	namespace := ""
+	record.Namespace = &namespace
+	for i, f := range record.Fields {
+		if defaultValue, found := latest[f.Name]; found {
+			if len(defaultValue) > 0 {
+				record.Fields[i].Default = defaultValue
+			}
+			continue
+		}
+		if record.Fields[i].Default, err = zeroDefault(typ.Field(fieldIndex(typ, f.Name)).Type); err != nil {
+			return "", err
+		}
+	}
+
+	b, err := json.MarshalIndent(record, "", "\t")
+	if err != nil {
+		return "", err
+	}
+	return string(b) + "\n", nil
+}
+
+// Check returns an error if the latest version of the schema does not match the definition generated from its Event struct
+func (v *Versioned) Check() error {
+	generated, err := v.Generate()
+	if err != nil {
+		return err
+	}
+	if equalDefinitions(generated, v.Latest().Definition) {
+		return nil
+	}
+	return fmt.Errorf("version %d of schema %s does not match its event struct, run 'go generate ./schema' to generate a new version", len(v.Versions), v.Name)
+}
+
+// Next returns the definition of the next version of the schema if its Event struct has changed since the latest version, or an empty string if not.
+// ErrIncompatibleChange is returned if the fields of the latest version are not the first fields of the event struct, with the same definition.
+func (v *Versioned) Next() (string, error) {
+	generated, err := v.Generate()
+	if err != nil {
+		return "", err
+	}
+	if equalDefinitions(generated, v.Latest().Definition) {
+		return "", nil
+	}
+
+	var next, latest struct {
+		Fields []json.RawMessage `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(generated), &next); err != nil {
+		return "", err
+	}
+	if err := json.Unmarshal([]byte(v.Latest().Definition), &latest); err != nil {
+		return "", fmt.Errorf("invalid definition of schema %s: %w", v.Name, err)
+	}
+	if len(next.Fields) <= len(latest.Fields) {
+		return "", fmt.Errorf("%w: schema %s has fields that have been changed or removed", ErrIncompatibleChange, v.Name)
+	}
+	for i, f := range latest.Fields {
+		if !equalDefinitions(string(f), string(next.Fields[i])) {
+			return "", fmt.Errorf("%w: field %d of schema %s has been changed", ErrIncompatibleChange, i+1, v.Name)
+		}
+	}
+	return generated, nil
+}
+
+// latestFields returns the JSON default value of each field of the latest version, which is empty if the field has no default
+func (v *Versioned) latestFields() (map[string]json.RawMessage, error) {
+	var record struct {
+		Fields []struct {
+			Name    string          `json:"name"`
+			Default json.RawMessage `json:"default"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(v.Latest().Definition), &record); err != nil {
+		return nil, fmt.Errorf("invalid definition of schema %s: %w", v.Name, err)
+	}
+	fields := make(map[string]json.RawMessage, len(record.Fields))
+	for _, f := range record.Fields {
+		fields[f.Name] = f.Default
+	}
+	return fields, nil
+}
+
+// generateRecord returns the definition of the record with the provided name made of the avro tagged fields of the struct, without defaults
+func generateRecord(name string, typ reflect.Type) (*recordDefinition, error) {
+	record := &recordDefinition{Type: "record", Name: name, Fields: []fieldDefinition{}}
+	for i := 0; i < typ.NumField(); i++ {
+		tag := typ.Field(i).Tag.Get("avro")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		fieldType, err := generateType(typ.Field(i).Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", tag, err)
+		}
+		record.Fields = append(record.Fields, fieldDefinition{Name: tag, Type: fieldType})
+	}
+	return record, nil
+}
+
+// generateType returns the avro type of the provided go type
+func generateType(typ reflect.Type) (interface{}, error) {
+	switch typ.Kind() {
+	case reflect.String:
+		return "string", nil
+	case reflect.Bool:
+		return "boolean", nil
+	case reflect.Int32:
+		return "int", nil
+	case reflect.Int64:
+		return "long", nil
+	case reflect.Float32:
+		return "float", nil
+	case reflect.Float64:
+		return "double", nil
+	case reflect.Slice:
+		items, err := generateType(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return arrayDefinition{Type: "array", Items: items}, nil
+	case reflect.Struct:
+		return generateRecord(recordName(typ), typ)
+	default:
+		return nil, fmt.Errorf("unsupported type %s", typ)
+	}
+}
+
+// zeroDefault returns the avro default value for the zero value of the provided go type
+func zeroDefault(typ reflect.Type) (interface{}, error) {
+	switch typ.Kind() {
+	case reflect.String:
+		return "", nil
+	case reflect.Bool:
+		return false, nil
+	case reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64:
+		return 0, nil
+	case reflect.Slice:
+		return []interface{}{}, nil
+	default:
+		return nil, fmt.Errorf("no default value for type %s", typ)
+	}
+}
+
+// recordName returns the name of the record of a nested struct: its type name in snake case, without the 'Report' suffix
+func recordName(typ reflect.Type) string {
+	var b strings.Builder
+	for i, r := range strings.TrimSuffix(typ.Name(), "Report") {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// fieldIndex returns the index of the struct field with the provided avro tag
+func fieldIndex(typ reflect.Type, tag string) int {
+	for i := 0; i < typ.NumField(); i++ {
+		if typ.Field(i).Tag.Get("avro") == tag {
+			return i
+		}
+	}
+	return -1
+}
+
+// equalDefinitions returns true if the provided JSON definitions are equal, regardless of formatting
+func equalDefinitions(a, b string) bool {
+	var va, vb interface{}
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
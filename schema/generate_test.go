@@ -0,0 +1,131 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ONSdigital/dp-dimension-importer/schema"
+	"github.com/ONSdigital/dp-kafka/v2/avro"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// unmarshalDefinition returns the provided JSON schema definition as a generic value, so that definitions can be compared regardless of formatting
+func unmarshalDefinition(definition string) interface{} {
+	var v interface{}
+	So(json.Unmarshal([]byte(definition), &v), ShouldBeNil)
+	return v
+}
+
+func TestSchemasMatchEvents(t *testing.T) {
+	Convey("Given every versioned schema of the service", t, func() {
+		for _, v := range schema.All {
+			Convey("Then the latest version of "+v.Name+" matches its event struct", func() {
+				So(v.Check(), ShouldBeNil)
+			})
+		}
+	})
+}
+
+func TestVersioned_Next(t *testing.T) {
+	Convey("Given a schema whose event struct has fields that are not defined by its latest version", t, func() {
+		v := &schema.Versioned{
+			Name:     "dimensions-inserted",
+			Versions: []*avro.Schema{schema.InstanceCompletedSchema.Version(1), schema.InstanceCompletedSchema.Version(2)},
+			Event:    instanceCompletedV3{},
+		}
+
+		Convey("When it is checked", func() {
+			err := v.Check()
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "version 2 of schema dimensions-inserted does not match its event struct")
+			})
+		})
+
+		Convey("When its next version is generated", func() {
+			next, err := v.Next()
+
+			Convey("Then the new fields are appended to the latest version with their zero value as default", func() {
+				So(err, ShouldBeNil)
+				So(unmarshalDefinition(next), ShouldResemble, unmarshalDefinition(schema.InstanceCompletedSchema.Version(3).Definition))
+			})
+
+			Convey("Then messages written with it can be read by the previous versions", func() {
+				v.Versions = append(v.Versions, &avro.Schema{Definition: next})
+				b, err := v.Marshal(instanceCompletedV3{InstanceID: "instance1", DatasetID: "cpih01", OptionCount: 3})
+				So(err, ShouldBeNil)
+				var previous instanceCompletedV2
+				So(v.Version(2).Unmarshal(b, &previous), ShouldBeNil)
+				So(previous, ShouldResemble, instanceCompletedV2{InstanceID: "instance1", DatasetID: "cpih01"})
+			})
+		})
+	})
+
+	Convey("Given a schema whose event struct matches its latest version", t, func() {
+		Convey("When its next version is generated", func() {
+			next, err := schema.ImportReportSchema.Next()
+
+			Convey("Then no new version is returned", func() {
+				So(err, ShouldBeNil)
+				So(next, ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a schema whose event struct no longer has a field of its latest version", t, func() {
+		v := &schema.Versioned{
+			Name:     "dimensions-extracted",
+			Versions: []*avro.Schema{schema.NewInstanceSchema.Version(2)},
+			Event:    newInstanceV1{},
+		}
+
+		Convey("When its next version is generated", func() {
+			_, err := v.Next()
+
+			Convey("Then ErrIncompatibleChange is returned", func() {
+				So(errors.Is(err, schema.ErrIncompatibleChange), ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given a schema whose event struct has changed the type of a field of its latest version", t, func() {
+		v := &schema.Versioned{
+			Name:     "dimensions-extracted",
+			Versions: []*avro.Schema{schema.NewInstanceSchema.Version(1)},
+			Event: struct {
+				FileURL    string `avro:"file_url"`
+				InstanceID int64  `avro:"instance_id"`
+				DatasetID  string `avro:"dataset_id"`
+			}{},
+		}
+
+		Convey("When its next version is generated", func() {
+			_, err := v.Next()
+
+			Convey("Then ErrIncompatibleChange is returned", func() {
+				So(errors.Is(err, schema.ErrIncompatibleChange), ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given a schema whose event struct has a field of an unsupported type", t, func() {
+		v := &schema.Versioned{
+			Name:     "dimensions-extracted",
+			Versions: []*avro.Schema{schema.NewInstanceSchema.Version(1)},
+			Event: struct {
+				FileURL string            `avro:"file_url"`
+				Labels  map[string]string `avro:"labels"`
+			}{},
+		}
+
+		Convey("When it is generated", func() {
+			_, err := v.Generate()
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
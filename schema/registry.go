@@ -0,0 +1,295 @@
+package schema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	dphttp "github.com/ONSdigital/dp-net/v2/http"
+)
+
+// registryContentType is the content type of the requests to a schema registry
+const registryContentType = "application/vnd.schemaregistry.v1+json"
+
+// ErrSchemaNotFound is returned by a Registry that does not have a schema with the requested ID
+var ErrSchemaNotFound = errors.New("schema not found in registry")
+
+// Registry stores the avro schema definitions of the kafka messages, identified by a numeric ID shared by every producer and consumer
+type Registry interface {
+	// Register returns the ID of the provided schema definition under the subject, registering it first if it is not known yet
+	Register(ctx context.Context, subject, definition string) (int, error)
+	// Definition returns the schema definition with the provided ID
+	Definition(ctx context.Context, id int) (string, error)
+}
+
+// Subject returns the registry subject of the values of the provided kafka topic
+func Subject(topic string) string {
+	return topic + "-value"
+}
+
+// HTTPRegistry is a client of a schema registry implementing the confluent schema registry REST API
+type HTTPRegistry struct {
+	URL    string
+	Client dphttp.Clienter
+}
+
+// NewHTTPRegistry returns a client of the schema registry at the provided URL, whose requests time out after the provided timeout
+func NewHTTPRegistry(registryURL string, timeout time.Duration) *HTTPRegistry {
+	return &HTTPRegistry{URL: strings.TrimSuffix(registryURL, "/"), Client: dphttp.ClientWithTimeout(dphttp.NewClient(), timeout)}
+}
+
+// registrySchema is the body of the registry requests and responses
+type registrySchema struct {
+	ID     int    `json:"id,omitempty"`
+	Schema string `json:"schema,omitempty"`
+}
+
+// Register registers the provided schema definition under the subject, which returns the existing ID if it is already registered
+func (r *HTTPRegistry) Register(ctx context.Context, subject, definition string) (int, error) {
+	body, err := json.Marshal(registrySchema{Schema: definition})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL+"/subjects/"+url.PathEscape(subject)+"/versions", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", registryContentType)
+
+	var resp registrySchema
+	if err := r.do(req, &resp); err != nil {
+		return 0, fmt.Errorf("failed to register schema for subject %s: %w", subject, err)
+	}
+	return resp.ID, nil
+}
+
+// Definition returns the schema definition with the provided ID
+func (r *HTTPRegistry) Definition(ctx context.Context, id int) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/schemas/ids/%d", r.URL, id), http.NoBody)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", registryContentType)
+
+	var resp registrySchema
+	if err := r.do(req, &resp); err != nil {
+		return "", fmt.Errorf("failed to get schema %d: %w", id, err)
+	}
+	return resp.Schema, nil
+}
+
+// do sends the request to the registry and decodes the JSON response body into v
+func (r *HTTPRegistry) do(req *http.Request, v interface{}) error {
+	resp, err := r.Client.Do(req.Context(), req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrSchemaNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status code %d from schema registry: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// BackoffRegistry is a Registry that remembers the failures of another registry, so that a registry that is down or slow is not called again for every message:
+// after a failure, the same request fails with the same error without calling the registry until the backoff has elapsed,
+// and the backoff doubles after each consecutive failure of the request, up to MaxBackoff. Failures caused by the context of the caller are not remembered.
+type BackoffRegistry struct {
+	Registry   Registry
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	mutex    sync.Mutex
+	failures map[string]*registryFailure // failures by request
+}
+
+// registryFailure is the last failure of a registry request
+type registryFailure struct {
+	err     error
+	backoff time.Duration
+	until   time.Time
+}
+
+// NewBackoffRegistry returns a registry that remembers the failures of the provided registry for the provided backoff, up to maxBackoff
+func NewBackoffRegistry(registry Registry, backoff, maxBackoff time.Duration) *BackoffRegistry {
+	return &BackoffRegistry{Registry: registry, Backoff: backoff, MaxBackoff: maxBackoff}
+}
+
+// Register returns the ID of the provided schema definition under the subject, unless registering it has failed within the backoff
+func (r *BackoffRegistry) Register(ctx context.Context, subject, definition string) (int, error) {
+	key := "register:" + subject + ":" + definition
+	if err := r.failure(key); err != nil {
+		return 0, err
+	}
+	id, err := r.Registry.Register(ctx, subject, definition)
+	r.record(ctx, key, err)
+	return id, err
+}
+
+// Definition returns the schema definition with the provided ID, unless getting it has failed within the backoff
+func (r *BackoffRegistry) Definition(ctx context.Context, id int) (string, error) {
+	key := fmt.Sprintf("definition:%d", id)
+	if err := r.failure(key); err != nil {
+		return "", err
+	}
+	definition, err := r.Registry.Definition(ctx, id)
+	r.record(ctx, key, err)
+	return definition, err
+}
+
+// failure returns the error of the last failure of the request, if its backoff has not elapsed
+func (r *BackoffRegistry) failure(key string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if f, found := r.failures[key]; found && time.Now().Before(f.until) {
+		return f.err
+	}
+	return nil
+}
+
+// record remembers the failure of the request, doubling its backoff if it had already failed, or forgets its failures if it succeeded
+func (r *BackoffRegistry) record(ctx context.Context, key string, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if err == nil {
+		delete(r.failures, key)
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	backoff := r.Backoff
+	if f, found := r.failures[key]; found {
+		backoff = min(2*f.backoff, max(r.MaxBackoff, r.Backoff))
+	}
+	if r.failures == nil {
+		r.failures = map[string]*registryFailure{}
+	}
+	r.failures[key] = &registryFailure{err: err, backoff: backoff, until: time.Now().Add(backoff)}
+}
+
+// fileRegistryEntry is a schema stored in the file of a FileRegistry
+type fileRegistryEntry struct {
+	ID      int    `json:"id"`
+	Subject string `json:"subject"`
+	Schema  string `json:"schema"`
+}
+
+// FileRegistry is a Registry stored in a local JSON file, standing in for a schema registry during development and tests.
+// Schemas are shared with the other processes using the same file, as long as they do not register schemas at the same time.
+type FileRegistry struct {
+	path  string
+	mutex sync.Mutex
+}
+
+// NewFileRegistry returns a registry stored in the provided file, which is created when the first schema is registered
+func NewFileRegistry(path string) *FileRegistry {
+	return &FileRegistry{path: path}
+}
+
+// Register returns the ID of the provided schema definition under the subject, adding it to the file if it is not known yet.
+// Definitions are compared after removing insignificant whitespace.
+func (r *FileRegistry) Register(ctx context.Context, subject, definition string) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	compact, err := compactDefinition(definition)
+	if err != nil {
+		return 0, err
+	}
+	entries, err := r.load()
+	if err != nil {
+		return 0, err
+	}
+	maxID := 0
+	for _, e := range entries {
+		if e.Subject == subject && e.Schema == compact {
+			return e.ID, nil
+		}
+		maxID = max(maxID, e.ID)
+	}
+
+	entry := fileRegistryEntry{ID: maxID + 1, Subject: subject, Schema: compact}
+	if err := r.save(append(entries, entry)); err != nil {
+		return 0, err
+	}
+	return entry.ID, nil
+}
+
+// Definition returns the schema definition with the provided ID from the file
+func (r *FileRegistry) Definition(ctx context.Context, id int) (string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entries, err := r.load()
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		if e.ID == id {
+			return e.Schema, nil
+		}
+	}
+	return "", fmt.Errorf("failed to get schema %d: %w", id, ErrSchemaNotFound)
+}
+
+// load reads the entries of the file, which is empty if it does not exist
+func (r *FileRegistry) load() ([]fileRegistryEntry, error) {
+	b, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []fileRegistryEntry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema registry file: %w", err)
+	}
+	var entries []fileRegistryEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, fmt.Errorf("invalid schema registry file %s: %w", r.path, err)
+	}
+	return entries, nil
+}
+
+// save replaces the content of the file with the provided entries
+func (r *FileRegistry) save(entries []fileRegistryEntry) error {
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write schema registry file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write schema registry file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write schema registry file: %w", err)
+	}
+	return os.Rename(tmp.Name(), r.path)
+}
+
+// compactDefinition returns the provided JSON schema definition without insignificant whitespace
+func compactDefinition(definition string) (string, error) {
+	var b bytes.Buffer
+	if err := json.Compact(&b, []byte(definition)); err != nil {
+		return "", fmt.Errorf("invalid schema definition: %w", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,160 @@
+package schema
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/ONSdigital/dp-kafka/v2/avro"
+)
+
+// magicByte is the first byte of a message framed with the ID of its writer schema, followed by the ID as a 4 bytes big-endian integer
+const magicByte = 0
+
+// headerSize is the size of the frame header preceding the avro payload
+const headerSize = 5
+
+// errRegistryUnavailable is returned when the writer schema of a framed message cannot be got from the registry for another reason than being unknown
+var errRegistryUnavailable = errors.New("schema registry unavailable")
+
+// RegistryCodec marshals and unmarshals the events of a versioned schema using a schema Registry.
+// Marshalled messages carry the registry ID of the latest version of the schema, and framed messages are unmarshalled with the writer
+// schema whose ID they carry, fetched from the registry, so that producers and consumers do not need to share the same schema versions.
+// Messages without the frame, written by producers not using the registry, are still unmarshalled with the versioned schema.
+// The registry is called with the context given to MarshalContext and UnmarshalContext, and with the background context by Marshal and Unmarshal.
+type RegistryCodec struct {
+	Schema   *Versioned
+	Registry Registry
+	Subject  string
+
+	id      int                  // registry ID of the latest version of the schema, 0 until registered
+	writers map[int]*avro.Schema // writer schemas by registry ID
+	mutex   sync.Mutex
+}
+
+// NewRegistryCodec returns a codec of the provided schema, registered in the registry under the subject
+func NewRegistryCodec(schema *Versioned, registry Registry, subject string) *RegistryCodec {
+	return &RegistryCodec{Schema: schema, Registry: registry, Subject: subject}
+}
+
+// Marshal avro encodes the provided event with the latest version of the schema, preceded by its registry ID.
+// The schema is registered by the first call.
+func (c *RegistryCodec) Marshal(s interface{}) ([]byte, error) {
+	return c.MarshalContext(context.Background(), s)
+}
+
+// MarshalContext is Marshal, registering the schema with the provided context
+func (c *RegistryCodec) MarshalContext(ctx context.Context, s interface{}) ([]byte, error) {
+	id, err := c.schemaID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := c.Schema.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	message := make([]byte, headerSize, headerSize+len(payload))
+	message[0] = magicByte
+	binary.BigEndian.PutUint32(message[1:headerSize], uint32(id))
+	return append(message, payload...), nil
+}
+
+// Unmarshal decodes the provided message into the value pointed to by s, which must be a pointer to a struct.
+// A framed message is decoded with its writer schema, only setting the fields of s that the writer schema defines.
+// As an unframed message whose payload starts with a zero byte (e.g. an empty first string field) looks like a framed one,
+// a message whose frame ID is not known by the registry, or that cannot be decoded with the writer schema of its frame,
+// is decoded with the versioned schema instead, and the error of the writer schema is returned if that fails too.
+// A message is not decoded with the versioned schema if the registry cannot be reached, as its frame cannot be confirmed.
+func (c *RegistryCodec) Unmarshal(message []byte, s interface{}) error {
+	return c.UnmarshalContext(context.Background(), message, s)
+}
+
+// UnmarshalContext is Unmarshal, getting the writer schema from the registry with the provided context
+func (c *RegistryCodec) UnmarshalContext(ctx context.Context, message []byte, s interface{}) error {
+	target := reflect.ValueOf(s)
+	if target.Kind() != reflect.Ptr || target.Elem().Kind() != reflect.Struct {
+		return avro.ErrUnsupportedType(target.Kind())
+	}
+	if len(message) < headerSize || message[0] != magicByte {
+		return c.Schema.Unmarshal(message, s)
+	}
+
+	err := c.unmarshalFramed(ctx, message, target.Elem())
+	if err == nil || errors.Is(err, errRegistryUnavailable) {
+		return err
+	}
+	if c.Schema.Unmarshal(message, s) == nil {
+		return nil
+	}
+	return err
+}
+
+// unmarshalFramed decodes the payload of the provided framed message into target with the writer schema whose ID is given by the frame
+func (c *RegistryCodec) unmarshalFramed(ctx context.Context, message []byte, target reflect.Value) error {
+	id := int(binary.BigEndian.Uint32(message[1:headerSize]))
+	writer, err := c.writerSchema(ctx, id)
+	if errors.Is(err, ErrSchemaNotFound) {
+		return fmt.Errorf("failed to get writer schema of %s message: %w", c.Schema.Name, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get writer schema of %s message: %w: %w", c.Schema.Name, errRegistryUnavailable, err)
+	}
+	target.Set(reflect.Zero(target.Type()))
+	if err := unmarshalVersion(writer, message[headerSize:], target); err != nil {
+		return fmt.Errorf("failed to unmarshal %s message with writer schema %d: %w", c.Schema.Name, id, err)
+	}
+	return nil
+}
+
+// schemaID returns the registry ID of the latest version of the schema, registering it if it has not been registered yet.
+// The registry is called without holding the lock, so that a slow registration does not block the other messages.
+func (c *RegistryCodec) schemaID(ctx context.Context) (int, error) {
+	c.mutex.Lock()
+	id := c.id
+	c.mutex.Unlock()
+	if id != 0 {
+		return id, nil
+	}
+
+	id, err := c.Registry.Register(ctx, c.Subject, c.Schema.Latest().Definition)
+	if err != nil {
+		return 0, fmt.Errorf("failed to register %s schema: %w", c.Schema.Name, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.id == 0 {
+		c.id = id
+	}
+	return c.id, nil
+}
+
+// writerSchema returns the schema with the provided registry ID, fetching it from the registry the first time.
+// The registry is called without holding the lock, so that fetching an unknown schema does not block the messages of the cached schemas.
+func (c *RegistryCodec) writerSchema(ctx context.Context, id int) (*avro.Schema, error) {
+	c.mutex.Lock()
+	writer, found := c.writers[id]
+	c.mutex.Unlock()
+	if found {
+		return writer, nil
+	}
+
+	definition, err := c.Registry.Definition(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if writer, found := c.writers[id]; found {
+		return writer, nil
+	}
+	writer = &avro.Schema{Definition: definition}
+	if c.writers == nil {
+		c.writers = map[int]*avro.Schema{}
+	}
+	c.writers[id] = writer
+	return writer, nil
+}
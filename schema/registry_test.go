@@ -0,0 +1,394 @@
+package schema_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-dimension-importer/event"
+	"github.com/ONSdigital/dp-dimension-importer/schema"
+	"github.com/ONSdigital/dp-kafka/v2/avro"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var ctx = context.Background()
+
+// reorderedNewInstance is a NewInstance schema written by a producer that does not share the versions compiled into the service
+var reorderedNewInstance = &schema.Versioned{Name: "dimensions-extracted", Versions: []*avro.Schema{{Definition: `{
+	"type": "record",
+	"name": "dimensions-extracted",
+	"fields": [
+		{"name": "instance_id", "type": "string"},
+		{"name": "dataset_id", "type": "string", "default": ""},
+		{"name": "file_url", "type": "string"}
+	]
+}`}}}
+
+func TestFileRegistry(t *testing.T) {
+	Convey("Given a file registry", t, func() {
+		path := filepath.Join(t.TempDir(), "registry.json")
+		registry := schema.NewFileRegistry(path)
+
+		Convey("When schemas are registered", func() {
+			id1, err1 := registry.Register(ctx, "dimensions-extracted-value", schema.NewInstanceSchema.Version(1).Definition)
+			id2, err2 := registry.Register(ctx, "dimensions-extracted-value", schema.NewInstanceSchema.Version(2).Definition)
+			again, err3 := registry.Register(ctx, "dimensions-extracted-value", " "+schema.NewInstanceSchema.Version(1).Definition+"\n")
+
+			Convey("Then each distinct schema gets a new ID, and the ID of a known schema is returned", func() {
+				So(err1, ShouldBeNil)
+				So(err2, ShouldBeNil)
+				So(err3, ShouldBeNil)
+				So(id1, ShouldEqual, 1)
+				So(id2, ShouldEqual, 2)
+				So(again, ShouldEqual, 1)
+			})
+
+			Convey("Then their definitions can be read by another registry using the same file", func() {
+				definition, err := schema.NewFileRegistry(path).Definition(ctx, id2)
+				So(err, ShouldBeNil)
+				So(json.Valid([]byte(definition)), ShouldBeTrue)
+				So(definition, ShouldContainSubstring, `"name":"dataset_id"`)
+			})
+		})
+
+		Convey("When an unknown schema is requested", func() {
+			_, err := registry.Definition(ctx, 1)
+
+			Convey("Then ErrSchemaNotFound is returned", func() {
+				So(errors.Is(err, schema.ErrSchemaNotFound), ShouldBeTrue)
+			})
+		})
+
+		Convey("When an invalid schema is registered", func() {
+			_, err := registry.Register(ctx, "dimensions-extracted-value", "{")
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestHTTPRegistry(t *testing.T) {
+	Convey("Given a schema registry server", t, func() {
+		var registered string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodPost && r.URL.Path == "/subjects/dimensions-extracted-value/versions":
+				var body struct {
+					Schema string `json:"schema"`
+				}
+				_ = json.NewDecoder(r.Body).Decode(&body)
+				registered = body.Schema
+				_, _ = w.Write([]byte(`{"id":7}`))
+			case r.Method == http.MethodGet && r.URL.Path == "/schemas/ids/7":
+				_ = json.NewEncoder(w).Encode(map[string]string{"schema": registered})
+			case r.Method == http.MethodGet:
+				http.Error(w, `{"error_code":40403,"message":"Schema not found"}`, http.StatusNotFound)
+			default:
+				http.Error(w, `{"error_code":50001,"message":"Error in the backend data store"}`, http.StatusInternalServerError)
+			}
+		}))
+		defer server.Close()
+		registry := schema.NewHTTPRegistry(server.URL+"/", time.Second)
+
+		Convey("When a schema is registered and read back", func() {
+			id, err := registry.Register(ctx, "dimensions-extracted-value", schema.NewInstanceSchema.Latest().Definition)
+			So(err, ShouldBeNil)
+			definition, err := registry.Definition(ctx, id)
+
+			Convey("Then the ID given by the registry and the registered definition are returned", func() {
+				So(err, ShouldBeNil)
+				So(id, ShouldEqual, 7)
+				So(definition, ShouldEqual, schema.NewInstanceSchema.Latest().Definition)
+			})
+		})
+
+		Convey("When an unknown schema is requested", func() {
+			_, err := registry.Definition(ctx, 8)
+
+			Convey("Then ErrSchemaNotFound is returned", func() {
+				So(errors.Is(err, schema.ErrSchemaNotFound), ShouldBeTrue)
+			})
+		})
+
+		Convey("When the registry fails to register a schema", func() {
+			_, err := registry.Register(ctx, "other-value", schema.NewInstanceSchema.Latest().Definition)
+
+			Convey("Then the error of the registry is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "unexpected status code 500")
+			})
+		})
+	})
+}
+
+func TestHTTPRegistry_Timeout(t *testing.T) {
+	Convey("Given a schema registry server that does not respond in time", t, func() {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}))
+		defer server.Close()
+		defer close(release)
+		registry := schema.NewHTTPRegistry(server.URL, 10*time.Millisecond)
+
+		Convey("When a schema is requested", func() {
+			start := time.Now()
+			_, err := registry.Definition(ctx, 1)
+
+			Convey("Then the request times out", func() {
+				So(err, ShouldNotBeNil)
+				So(time.Since(start), ShouldBeLessThan, 5*time.Second)
+			})
+		})
+	})
+}
+
+// registryStub is a Registry counting its calls, which returns the provided error, or the NewInstance schema with ID 1
+type registryStub struct {
+	err   error
+	calls int
+	ctx   context.Context
+}
+
+func (r *registryStub) Register(ctx context.Context, subject, definition string) (int, error) {
+	r.calls++
+	r.ctx = ctx
+	return 1, r.err
+}
+
+func (r *registryStub) Definition(ctx context.Context, id int) (string, error) {
+	r.calls++
+	r.ctx = ctx
+	if r.err != nil {
+		return "", r.err
+	}
+	if id != 1 {
+		return "", schema.ErrSchemaNotFound
+	}
+	return schema.NewInstanceSchema.Latest().Definition, nil
+}
+
+// blockingRegistry is a Registry returning the NewInstance schema with ID 1, whose requests for any other schema block until released
+type blockingRegistry struct {
+	blocked  chan struct{}
+	released chan struct{}
+}
+
+func (r *blockingRegistry) Register(ctx context.Context, subject, definition string) (int, error) {
+	return 1, nil
+}
+
+func (r *blockingRegistry) Definition(ctx context.Context, id int) (string, error) {
+	if id == 1 {
+		return schema.NewInstanceSchema.Latest().Definition, nil
+	}
+	r.blocked <- struct{}{}
+	<-r.released
+	return "", schema.ErrSchemaNotFound
+}
+
+func TestBackoffRegistry(t *testing.T) {
+	errRegistry := errors.New("registry is down")
+
+	Convey("Given a backoff registry of a failing registry", t, func() {
+		stub := &registryStub{err: errRegistry}
+		registry := schema.NewBackoffRegistry(stub, 20*time.Millisecond, time.Minute)
+
+		Convey("When a schema is requested twice", func() {
+			_, err1 := registry.Definition(ctx, 1)
+			_, err2 := registry.Definition(ctx, 1)
+
+			Convey("Then the registry is only called once, and both calls return its error", func() {
+				So(stub.calls, ShouldEqual, 1)
+				So(errors.Is(err1, errRegistry), ShouldBeTrue)
+				So(errors.Is(err2, errRegistry), ShouldBeTrue)
+			})
+
+			Convey("Then another schema is still requested from the registry", func() {
+				registry.Definition(ctx, 2)
+				So(stub.calls, ShouldEqual, 2)
+			})
+		})
+
+		Convey("When the schema is requested again once the backoff has elapsed and the registry has recovered", func() {
+			registry.Definition(ctx, 1)
+			time.Sleep(30 * time.Millisecond)
+			stub.err = nil
+			definition, err := registry.Definition(ctx, 1)
+
+			Convey("Then the registry is called again", func() {
+				So(err, ShouldBeNil)
+				So(definition, ShouldEqual, schema.NewInstanceSchema.Latest().Definition)
+				So(stub.calls, ShouldEqual, 2)
+			})
+		})
+
+		Convey("When the schema is requested with a cancelled context", func() {
+			cancelled, cancel := context.WithCancel(ctx)
+			cancel()
+			registry.Definition(cancelled, 1)
+			registry.Definition(ctx, 1)
+
+			Convey("Then the failure is not remembered", func() {
+				So(stub.calls, ShouldEqual, 2)
+			})
+		})
+	})
+}
+
+func TestRegistryCodec(t *testing.T) {
+	written := event.NewInstance{FileURL: "/a/b.csv", InstanceID: "instance1", DatasetID: "cpih01"}
+
+	Convey("Given a codec of the NewInstance schema with a file registry", t, func() {
+		registry := schema.NewFileRegistry(filepath.Join(t.TempDir(), "registry.json"))
+		codec := schema.NewRegistryCodec(schema.NewInstanceSchema, registry, "dimensions-extracted-value")
+
+		Convey("When an event is marshalled", func() {
+			b, err := codec.Marshal(written)
+			So(err, ShouldBeNil)
+
+			Convey("Then the message is framed with the registry ID of the latest version of the schema", func() {
+				So(b[:5], ShouldResemble, []byte{0, 0, 0, 0, 1})
+				definition, err := registry.Definition(ctx, 1)
+				So(err, ShouldBeNil)
+				So(definition, ShouldContainSubstring, `"name":"force"`)
+			})
+
+			Convey("Then it is unmarshalled by the codec", func() {
+				var e event.NewInstance
+				So(codec.Unmarshal(b, &e), ShouldBeNil)
+				So(e, ShouldResemble, written)
+			})
+		})
+
+		Convey("When an event written by a producer with a schema unknown to the service is unmarshalled", func() {
+			b, err := schema.NewRegistryCodec(reorderedNewInstance, registry, "dimensions-extracted-value").Marshal(written)
+			So(err, ShouldBeNil)
+			e := event.NewInstance{TraceID: "previous"}
+			err = codec.Unmarshal(b, &e)
+
+			Convey("Then it is decoded with its writer schema, and the fields missing from it have their zero value", func() {
+				So(err, ShouldBeNil)
+				So(e, ShouldResemble, written)
+			})
+		})
+
+		Convey("When an unframed message is unmarshalled", func() {
+			b, err := schema.NewInstanceSchema.Marshal(written)
+			So(err, ShouldBeNil)
+			var e event.NewInstance
+			err = codec.Unmarshal(b, &e)
+
+			Convey("Then it is decoded with the versioned schema", func() {
+				So(err, ShouldBeNil)
+				So(e, ShouldResemble, written)
+			})
+		})
+
+		Convey("When an unframed message whose payload starts with a zero byte is unmarshalled", func() {
+			legacy := event.NewInstance{InstanceID: "instance1", DatasetID: "cpih01"}
+			b, err := schema.NewInstanceSchema.Marshal(legacy)
+			So(err, ShouldBeNil)
+			So(b[0], ShouldEqual, 0)
+			var e event.NewInstance
+			err = codec.Unmarshal(b, &e)
+
+			Convey("Then its unknown frame ID is not confirmed by the registry, and it is decoded with the versioned schema", func() {
+				So(err, ShouldBeNil)
+				So(e, ShouldResemble, legacy)
+			})
+		})
+	})
+
+	Convey("Given a codec of the NewInstance schema with a registry", t, func() {
+		stub := &registryStub{}
+		codec := schema.NewRegistryCodec(schema.NewInstanceSchema, stub, "dimensions-extracted-value")
+		type ctxKey struct{}
+		messageCtx := context.WithValue(ctx, ctxKey{}, "message")
+
+		Convey("When an event is marshalled and unmarshalled with a context", func() {
+			b, err := codec.MarshalContext(messageCtx, written)
+			So(err, ShouldBeNil)
+			So(stub.ctx, ShouldEqual, messageCtx)
+			stub.ctx = nil
+			var e event.NewInstance
+			err = codec.UnmarshalContext(messageCtx, b, &e)
+
+			Convey("Then the registry is called with the context", func() {
+				So(err, ShouldBeNil)
+				So(e, ShouldResemble, written)
+				So(stub.ctx, ShouldEqual, messageCtx)
+			})
+		})
+
+		Convey("When a framed message is unmarshalled while the registry is unavailable", func() {
+			stub.err = errors.New("registry is down")
+			legacy := event.NewInstance{InstanceID: "instance1"}
+			b, _ := schema.NewInstanceSchema.Marshal(legacy)
+			var e event.NewInstance
+			err := codec.Unmarshal(b, &e)
+
+			Convey("Then the registry error is returned, as the frame cannot be confirmed", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "registry is down")
+			})
+		})
+	})
+
+	Convey("Given a codec of the NewInstance schema with a registry that blocks on unknown schemas, and a cached writer schema", t, func() {
+		registry := &blockingRegistry{blocked: make(chan struct{}), released: make(chan struct{})}
+		codec := schema.NewRegistryCodec(schema.NewInstanceSchema, registry, "dimensions-extracted-value")
+		b, err := codec.Marshal(written)
+		So(err, ShouldBeNil)
+		So(codec.Unmarshal(b, &event.NewInstance{}), ShouldBeNil)
+
+		Convey("When a message is unmarshalled while the writer schema of another message is being fetched", func() {
+			unknown := append([]byte{0, 0, 0, 0, 2}, b[5:]...)
+			fetched := make(chan error)
+			go func() {
+				fetched <- codec.Unmarshal(unknown, &event.NewInstance{})
+			}()
+			<-registry.blocked
+
+			done := make(chan error)
+			var e event.NewInstance
+			go func() {
+				done <- codec.Unmarshal(b, &e)
+			}()
+
+			Convey("Then it is not blocked by the registry call", func() {
+				select {
+				case err := <-done:
+					So(err, ShouldBeNil)
+					So(e, ShouldResemble, written)
+				case <-time.After(5 * time.Second):
+					t.Error("unmarshalling a message with a cached writer schema was blocked by the registry call")
+				}
+				close(registry.released)
+				<-fetched
+			})
+		})
+	})
+
+	Convey("Given a codec whose registry cannot be written", t, func() {
+		codec := schema.NewRegistryCodec(schema.NewInstanceSchema, schema.NewFileRegistry(filepath.Join(t.TempDir(), "missing", "registry.json")), "dimensions-extracted-value")
+
+		Convey("When an event is marshalled", func() {
+			_, err := codec.Marshal(written)
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
@@ -1,47 +1,75 @@
 package schema
 
 import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+
+	"github.com/ONSdigital/dp-dimension-importer/event"
 	"github.com/ONSdigital/dp-kafka/v2/avro"
 )
 
-var newInstance = ` {
-	"type": "record",
-	"name": "dimensions-extracted",
-	"namespace": "",
-	"fields": [
-		{
-			"name": "file_url",
-			"type": "string"
-		},
-		{
-			"name": "instance_id",
-			"type": "string"
-		}
-	]
-}`
+//go:generate go run ../cmd/schemagen -dir avro
 
-// NewInstanceSchema avro schema for a newInstance event
-var NewInstanceSchema = &avro.Schema{
-	Definition: newInstance,
-}
+// definitions contains the definition of every version of the schemas, in files named '<schema name>.v<version>.avsc'.
+// New versions are generated from the event structs by cmd/schemagen.
+//
+//go:embed avro/*.avsc
+var definitions embed.FS
+
+// NewInstanceSchema versioned avro schema for a newInstance event
+var NewInstanceSchema = mustLoad("dimensions-extracted", event.NewInstance{})
+
+// InstanceCompletedSchema versioned avro schema for a instanceCompleted event
+var InstanceCompletedSchema = mustLoad("dimensions-inserted", event.InstanceCompleted{})
+
+// ImportReportSchema versioned avro schema for an importReport event
+var ImportReportSchema = mustLoad("dimensions-import-report", event.ImportReport{})
+
+// ImportFailedSchema versioned avro schema for an importFailed event
+var ImportFailedSchema = mustLoad("dimensions-import-failed", event.ImportFailed{})
 
-var instanceCompleted = `{
-	"type": "record",
-	"name": "dimensions-inserted",
-	"namespace": "",
-	"fields": [
-		{
-			"name": "file_url",
-			"type": "string"
-		},
-		{
-			"name": "instance_id",
-			"type": "string"
+// InstanceDeletedSchema versioned avro schema for an instanceDeleted event
+var InstanceDeletedSchema = mustLoad("instance-deleted", event.InstanceDeleted{})
+
+// DimensionsDeletedSchema versioned avro schema for a dimensionsDeleted event
+var DimensionsDeletedSchema = mustLoad("dimensions-deleted", event.DimensionsDeleted{})
+
+// All contains every versioned schema of the service
+var All = []*Versioned{NewInstanceSchema, InstanceCompletedSchema, ImportReportSchema, ImportFailedSchema, InstanceDeletedSchema, DimensionsDeletedSchema}
+
+// mustLoad returns the versioned schema of the provided event with every version of its definition, panicking if there are none
+func mustLoad(name string, e interface{}) *Versioned {
+	paths, err := fs.Glob(definitions, fmt.Sprintf("avro/%s.v*.avsc", name))
+	if err != nil || len(paths) == 0 {
+		panic(fmt.Sprintf("no definition of schema %s", name))
+	}
+
+	versions := make(map[int]*avro.Schema, len(paths))
+	for _, path := range paths {
+		var version int
+		if _, err := fmt.Sscanf(path, "avro/"+name+".v%d.avsc", &version); err != nil {
+			panic(fmt.Sprintf("invalid schema definition file name %s", path))
+		}
+		b, err := definitions.ReadFile(path)
+		if err != nil {
+			panic(err)
 		}
-	]
-}`
+		versions[version] = &avro.Schema{Definition: string(b)}
+	}
 
-// InstanceCompletedSchema avro schema for a instanceCompleted event
-var InstanceCompletedSchema = &avro.Schema{
-	Definition: instanceCompleted,
+	v := &Versioned{Name: name, Event: e, Versions: make([]*avro.Schema, 0, len(versions))}
+	numbers := make([]int, 0, len(versions))
+	for version := range versions {
+		numbers = append(numbers, version)
+	}
+	sort.Ints(numbers)
+	for i, version := range numbers {
+		if version != i+1 {
+			panic(fmt.Sprintf("missing version %d of schema %s", i+1, name))
+		}
+		v.Versions = append(v.Versions, versions[version])
+	}
+	return v
 }
@@ -0,0 +1,97 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/ONSdigital/dp-kafka/v2/avro"
+)
+
+// Versioned is an avro schema with several versions, oldest first, where each version only adds fields with a default value to the previous one.
+// Events are marshalled with the latest version, and unmarshalled with the latest version that can decode them,
+// so that payloads written with any version can be read, and fields missing from older payloads keep their default (zero) value.
+type Versioned struct {
+	Name     string
+	Versions []*avro.Schema
+	Event    interface{} // event struct whose avro tags define the fields of the latest version, if any (see Generate)
+}
+
+// Latest returns the latest version of the schema
+func (v *Versioned) Latest() *avro.Schema {
+	return v.Versions[len(v.Versions)-1]
+}
+
+// Version returns the provided version of the schema, starting at 1, or nil if it does not exist
+func (v *Versioned) Version(version int) *avro.Schema {
+	if version < 1 || version > len(v.Versions) {
+		return nil
+	}
+	return v.Versions[version-1]
+}
+
+// Marshal avro encodes the provided event with the latest version of the schema
+func (v *Versioned) Marshal(s interface{}) ([]byte, error) {
+	return v.Latest().Marshal(s)
+}
+
+// Unmarshal decodes the provided avro message into the value pointed to by s, which must be a pointer to a struct,
+// trying every version of the schema from the latest to the oldest. Only the fields defined by the version that decodes the message are set,
+// any other fields are left with their zero value.
+// Payloads written with an older version cannot be decoded by a later one, as they lack the data of its additional fields,
+// and payloads written with a later version than any known one are decoded by the latest version, ignoring the additional fields.
+func (v *Versioned) Unmarshal(message []byte, s interface{}) error {
+	target := reflect.ValueOf(s)
+	if target.Kind() != reflect.Ptr || target.Elem().Kind() != reflect.Struct {
+		return avro.ErrUnsupportedType(target.Kind())
+	}
+	target.Elem().Set(reflect.Zero(target.Elem().Type()))
+
+	var errs []error
+	for i := len(v.Versions) - 1; i >= 0; i-- {
+		err := unmarshalVersion(v.Versions[i], message, target.Elem())
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("version %d: %w", i+1, err))
+	}
+	return fmt.Errorf("failed to unmarshal %s message with any schema version: %w", v.Name, errors.Join(errs...))
+}
+
+// unmarshalVersion decodes the provided avro message with a single version of a schema, only setting the fields of the target struct
+// whose avro tag is defined by that version. The target is not modified if the message cannot be decoded.
+func unmarshalVersion(schema *avro.Schema, message []byte, target reflect.Value) error {
+	var record struct {
+		Fields []struct {
+			Name string `json:"name"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(schema.Definition), &record); err != nil {
+		return fmt.Errorf("invalid schema definition: %w", err)
+	}
+	defined := make(map[string]struct{}, len(record.Fields))
+	for _, f := range record.Fields {
+		defined[f.Name] = struct{}{}
+	}
+
+	// decode into a struct with only the fields of the target that are defined by the schema
+	typ := target.Type()
+	fields := []reflect.StructField{}
+	indexes := []int{}
+	for i := 0; i < typ.NumField(); i++ {
+		if _, found := defined[typ.Field(i).Tag.Get("avro")]; found {
+			fields = append(fields, typ.Field(i))
+			indexes = append(indexes, i)
+		}
+	}
+	decoded := reflect.New(reflect.StructOf(fields))
+	if err := schema.Unmarshal(message, decoded.Interface()); err != nil {
+		return err
+	}
+
+	for j, i := range indexes {
+		target.Field(i).Set(decoded.Elem().Field(j))
+	}
+	return nil
+}
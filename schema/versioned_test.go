@@ -0,0 +1,203 @@
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dp-dimension-importer/event"
+	"github.com/ONSdigital/dp-dimension-importer/schema"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// newInstanceV1 is a NewInstance event as known by the writers of the first version of its schema
+type newInstanceV1 struct {
+	FileURL    string `avro:"file_url"`
+	InstanceID string `avro:"instance_id"`
+}
+
+// instanceCompletedV2 is an InstanceCompleted event as known by the readers of the second version of its schema
+type instanceCompletedV2 struct {
+	FileURL      string `avro:"file_url"`
+	InstanceID   string `avro:"instance_id"`
+	DatasetID    string `avro:"dataset_id"`
+	Edition      string `avro:"edition"`
+	Version      int32  `avro:"version"`
+	InstanceType string `avro:"instance_type"`
+}
+
+// instanceCompletedV3 is an InstanceCompleted event as known by the writers of the third version of its schema
+type instanceCompletedV3 struct {
+	FileURL        string `avro:"file_url"`
+	InstanceID     string `avro:"instance_id"`
+	DatasetID      string `avro:"dataset_id"`
+	Edition        string `avro:"edition"`
+	Version        int32  `avro:"version"`
+	InstanceType   string `avro:"instance_type"`
+	TraceID        string `avro:"trace_id"`
+	DimensionCount int64  `avro:"dimension_count"`
+	OptionCount    int64  `avro:"option_count"`
+}
+
+func TestNewInstanceSchema(t *testing.T) {
+	Convey("Given a NewInstance payload written with the first version of the schema", t, func() {
+		b, err := schema.NewInstanceSchema.Version(1).Marshal(newInstanceV1{FileURL: "/a/b.csv", InstanceID: "instance1"})
+		So(err, ShouldBeNil)
+
+		Convey("When it is unmarshalled by the versioned schema", func() {
+			var e event.NewInstance
+			err := schema.NewInstanceSchema.Unmarshal(b, &e)
+
+			Convey("Then the fields it has are read, and the optional fields have their default value", func() {
+				So(err, ShouldBeNil)
+				So(e, ShouldResemble, event.NewInstance{FileURL: "/a/b.csv", InstanceID: "instance1"})
+			})
+		})
+	})
+
+	Convey("Given a NewInstance payload written with the latest version of the schema", t, func() {
+		written := event.NewInstance{
+			FileURL:    "/a/b.csv",
+			InstanceID: "instance1",
+			DatasetID:  "cpih01",
+			Edition:    "2021",
+			Version:    2,
+			TraceID:    "trace1",
+			Force:      true,
+
+			Incremental: true,
+		}
+		b, err := schema.NewInstanceSchema.Marshal(written)
+		So(err, ShouldBeNil)
+
+		Convey("When it is unmarshalled by the versioned schema", func() {
+			var e event.NewInstance
+			err := schema.NewInstanceSchema.Unmarshal(b, &e)
+
+			Convey("Then all the fields are read", func() {
+				So(err, ShouldBeNil)
+				So(e, ShouldResemble, written)
+			})
+		})
+
+		Convey("When it is unmarshalled by a reader that only knows the first version of the schema", func() {
+			var e newInstanceV1
+			err := schema.NewInstanceSchema.Version(1).Unmarshal(b, &e)
+
+			Convey("Then the fields known by the reader are read", func() {
+				So(err, ShouldBeNil)
+				So(e, ShouldResemble, newInstanceV1{FileURL: "/a/b.csv", InstanceID: "instance1"})
+			})
+		})
+	})
+
+	Convey("Given a payload that is not valid for any version of the schema", t, func() {
+		b := []byte{0xff}
+
+		Convey("When it is unmarshalled by the versioned schema", func() {
+			var e event.NewInstance
+			err := schema.NewInstanceSchema.Unmarshal(b, &e)
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldStartWith, "failed to unmarshal dimensions-extracted message with any schema version")
+			})
+		})
+	})
+
+	Convey("When a value that is not a pointer to a struct is unmarshalled", t, func() {
+		err := schema.NewInstanceSchema.Unmarshal([]byte{}, event.NewInstance{})
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestInstanceCompletedSchema(t *testing.T) {
+	written := event.InstanceCompleted{
+		FileURL:        "/a/b.csv",
+		InstanceID:     "instance1",
+		DatasetID:      "cpih01",
+		Edition:        "2021",
+		Version:        2,
+		InstanceType:   "v4",
+		TraceID:        "trace1",
+		DimensionCount: 3,
+		OptionCount:    120,
+
+		DurationMS:      1500,
+		CodeLists:       []string{"geography-list", "time-list"},
+		OptionsHash:     "86cb1cc5dabb977cd31365b1cd6f21561026e508e2fbf9e7677bff3cae5256fd",
+		ImporterVersion: "v1.2.3",
+
+		Rebuild: true,
+	}
+
+	Convey("Given an InstanceCompleted payload written with the latest version of the schema", t, func() {
+		b, err := schema.InstanceCompletedSchema.Marshal(written)
+		So(err, ShouldBeNil)
+
+		Convey("When it is unmarshalled by the versioned schema", func() {
+			var e event.InstanceCompleted
+			err := schema.InstanceCompletedSchema.Unmarshal(b, &e)
+
+			Convey("Then all the fields are read", func() {
+				So(err, ShouldBeNil)
+				So(e, ShouldResemble, written)
+			})
+		})
+
+		Convey("When it is unmarshalled by readers that only know older versions of the schema", func() {
+			var v3 instanceCompletedV3
+			errV3 := schema.InstanceCompletedSchema.Version(3).Unmarshal(b, &v3)
+			var v2 instanceCompletedV2
+			errV2 := schema.InstanceCompletedSchema.Version(2).Unmarshal(b, &v2)
+			var v1 newInstanceV1
+			errV1 := schema.InstanceCompletedSchema.Version(1).Unmarshal(b, &v1)
+
+			Convey("Then the fields known by each reader are read", func() {
+				So(errV3, ShouldBeNil)
+				So(v3, ShouldResemble, instanceCompletedV3{
+					FileURL: "/a/b.csv", InstanceID: "instance1", DatasetID: "cpih01", Edition: "2021", Version: 2, InstanceType: "v4",
+					TraceID: "trace1", DimensionCount: 3, OptionCount: 120,
+				})
+				So(errV2, ShouldBeNil)
+				So(v2, ShouldResemble, instanceCompletedV2{
+					FileURL: "/a/b.csv", InstanceID: "instance1", DatasetID: "cpih01", Edition: "2021", Version: 2, InstanceType: "v4",
+				})
+				So(errV1, ShouldBeNil)
+				So(v1, ShouldResemble, newInstanceV1{FileURL: "/a/b.csv", InstanceID: "instance1"})
+			})
+		})
+	})
+
+	Convey("Given InstanceCompleted payloads written with every older version of the schema", t, func() {
+		b1, err := schema.InstanceCompletedSchema.Version(1).Marshal(newInstanceV1{FileURL: "/a/b.csv", InstanceID: "instance1"})
+		So(err, ShouldBeNil)
+		b2, err := schema.InstanceCompletedSchema.Version(2).Marshal(instanceCompletedV2{
+			FileURL: "/a/b.csv", InstanceID: "instance1", DatasetID: "cpih01", Edition: "2021", Version: 2, InstanceType: "v4",
+		})
+		So(err, ShouldBeNil)
+
+		Convey("When they are unmarshalled by the versioned schema", func() {
+			var e1, e2 event.InstanceCompleted
+			err1 := schema.InstanceCompletedSchema.Unmarshal(b1, &e1)
+			err2 := schema.InstanceCompletedSchema.Unmarshal(b2, &e2)
+
+			Convey("Then the fields of each version are read, and the later fields have their default value", func() {
+				So(err1, ShouldBeNil)
+				So(e1, ShouldResemble, event.InstanceCompleted{FileURL: "/a/b.csv", InstanceID: "instance1"})
+				So(err2, ShouldBeNil)
+				So(e2, ShouldResemble, event.InstanceCompleted{
+					FileURL: "/a/b.csv", InstanceID: "instance1", DatasetID: "cpih01", Edition: "2021", Version: 2, InstanceType: "v4",
+				})
+			})
+		})
+	})
+
+	Convey("When a version that does not exist is requested", t, func() {
+		Convey("Then nil is returned", func() {
+			So(schema.InstanceCompletedSchema.Version(0), ShouldBeNil)
+			So(schema.InstanceCompletedSchema.Version(6), ShouldBeNil)
+		})
+	})
+}
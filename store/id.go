@@ -0,0 +1,24 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrInvalidID is returned when an ID that would be written into a graph query has characters other than letters, digits, '_' and '-'
+var ErrInvalidID = errors.New("invalid id")
+
+// validID matches the IDs that can be safely written into a gremlin query
+var validID = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ValidateID returns ErrInvalidID if any of the provided instance, dimension or code list IDs is empty,
+// or has characters other than letters, digits, '_' and '-', which could change the meaning of the query it is written into
+func ValidateID(ids ...string) error {
+	for _, id := range ids {
+		if !validID.MatchString(id) {
+			return fmt.Errorf("%w: %q", ErrInvalidID, id)
+		}
+	}
+	return nil
+}
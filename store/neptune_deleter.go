@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ONSdigital/dp-graph/v2/graph"
+	"github.com/ONSdigital/dp-graph/v2/neptune"
+	gremgo "github.com/ONSdigital/gremgo-neptune"
+)
+
+// Gremlin query used to delete the nodes of an instance, following the node layout of the dp-graph neptune implementation:
+// the observation nodes, which also drops their relationships to the dimension nodes, the code relationships to the instance node,
+// the dimension nodes and the instance node.
+const deleteInstance = `g.V().hasLabel('_%[1]s_observation').drop().iterate();` +
+	`g.V('_%[1]s_Instance').inE('inDataset').drop().iterate();` +
+	`g.V('_%[1]s_Instance').in('HAS_DIMENSION').drop().iterate();` +
+	`g.V('_%[1]s_Instance').drop()`
+
+// Type check to ensure that NeptuneDeleter implements the InstanceDeleter interface
+var _ InstanceDeleter = (*NeptuneDeleter)(nil)
+
+// NeptuneExecutor is the subset of the neptune connection pool used by NeptuneDeleter
+type NeptuneExecutor interface {
+	Execute(query string, bindings, rebindings map[string]string) ([]gremgo.Response, error)
+}
+
+// NeptuneDeleter deletes the nodes created by an import from a neptune graph database
+type NeptuneDeleter struct {
+	Pool NeptuneExecutor
+}
+
+// NewNeptuneDeleter returns a NeptuneDeleter that uses the connection pool of the provided graph database,
+// or ErrNotNeptune if it is not backed by neptune
+func NewNeptuneDeleter(db *graph.DB) (*NeptuneDeleter, error) {
+	neptuneDB, ok := db.Driver.(*neptune.NeptuneDB)
+	if !ok {
+		return nil, ErrNotNeptune
+	}
+	return &NeptuneDeleter{Pool: neptuneDB.Pool}, nil
+}
+
+// DeleteInstance deletes the observation nodes, the dimension nodes and the instance node of an instance, along with all their relationships,
+// including the code relationships and the relationships between the observations and the dimension options.
+// The hierarchy nodes of the instance are not deleted, as they are owned by the hierarchy builder, nor are the code and code list nodes, which are shared between instances.
+func (n *NeptuneDeleter) DeleteInstance(ctx context.Context, instanceID string) error {
+	if err := ValidateID(instanceID); err != nil {
+		return err
+	}
+	if _, err := n.Pool.Execute(fmt.Sprintf(deleteInstance, instanceID), nil, nil); err != nil {
+		return fmt.Errorf("failed to delete instance nodes: %w", err)
+	}
+	return nil
+}
+
+// DeleteInstanceConstraint does nothing, as constraints are not a neptune construct
+// and CreateInstanceConstraint does not create anything in the neptune implementation
+func (n *NeptuneDeleter) DeleteInstanceConstraint(ctx context.Context, instanceID string) error {
+	return nil
+}
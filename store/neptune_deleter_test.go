@@ -0,0 +1,71 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ONSdigital/dp-dimension-importer/store"
+	"github.com/ONSdigital/dp-dimension-importer/store/storertest"
+	gremgo "github.com/ONSdigital/gremgo-neptune"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNeptuneDeleter_DeleteInstance(t *testing.T) {
+	Convey("Given a neptune pool that executes queries successfully", t, func() {
+		pool := &storertest.NeptuneExecutorMock{
+			ExecuteFunc: func(query string, bindings map[string]string, rebindings map[string]string) ([]gremgo.Response, error) {
+				return []gremgo.Response{}, nil
+			},
+		}
+		deleter := &store.NeptuneDeleter{Pool: pool}
+
+		Convey("When DeleteInstance is called", func() {
+			err := deleter.DeleteInstance(ctx, "instance1")
+
+			Convey("Then the observation nodes, the code relationships, the dimension nodes and the instance node are dropped", func() {
+				So(err, ShouldBeNil)
+				So(pool.ExecuteCalls(), ShouldHaveLength, 1)
+				So(pool.ExecuteCalls()[0].Query, ShouldEqual,
+					`g.V().hasLabel('_instance1_observation').drop().iterate();`+
+						`g.V('_instance1_Instance').inE('inDataset').drop().iterate();`+
+						`g.V('_instance1_Instance').in('HAS_DIMENSION').drop().iterate();`+
+						`g.V('_instance1_Instance').drop()`)
+			})
+		})
+
+		Convey("When DeleteInstance is called with an instance ID that is not safe to write into a query", func() {
+			err := deleter.DeleteInstance(ctx, "instance1').drop();g.V('")
+
+			Convey("Then ErrInvalidID is returned and no query is executed", func() {
+				So(errors.Is(err, store.ErrInvalidID), ShouldBeTrue)
+				So(pool.ExecuteCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When DeleteInstanceConstraint is called", func() {
+			err := deleter.DeleteInstanceConstraint(ctx, "instance1")
+
+			Convey("Then no query is executed", func() {
+				So(err, ShouldBeNil)
+				So(pool.ExecuteCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+
+	Convey("Given a neptune pool that fails to execute queries", t, func() {
+		pool := &storertest.NeptuneExecutorMock{
+			ExecuteFunc: func(query string, bindings map[string]string, rebindings map[string]string) ([]gremgo.Response, error) {
+				return nil, errPool
+			},
+		}
+		deleter := &store.NeptuneDeleter{Pool: pool}
+
+		Convey("When DeleteInstance is called", func() {
+			err := deleter.DeleteInstance(ctx, "instance1")
+
+			Convey("Then the pool error is returned", func() {
+				So(errors.Is(err, errPool), ShouldBeTrue)
+			})
+		})
+	})
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ONSdigital/dp-graph/v2/graph"
+	"github.com/ONSdigital/dp-graph/v2/neptune"
+	"github.com/ONSdigital/graphson"
+)
+
+// Gremlin queries used to read back the nodes created by an import, following the node layout of the dp-graph neptune implementation.
+// The IDs written into them must be checked with ValidateID first.
+const (
+	getDimensionNodes    = `g.V('_%s_Instance').in('HAS_DIMENSION').hasLabel('_%s_%s')`
+	getInstanceCodes     = `g.V('_%s_Instance').in('inDataset').hasLabel('_code').where(out('usedBy').hasLabel('_code_list').has('listID','%s')).values('value')`
+	getCodeListInstances = `g.V().hasLabel('_code_list').has('listID','%s').in('usedBy').hasLabel('_code').out('inDataset').dedup().id()`
+)
+
+// instanceNodeIDPrefix and instanceNodeIDSuffix surround the instance ID in the ID of an instance node
+const (
+	instanceNodeIDPrefix = "_"
+	instanceNodeIDSuffix = "_Instance"
+)
+
+// ErrNotNeptune is returned when a GraphReader is requested for a graph database that is not backed by neptune
+var ErrNotNeptune = errors.New("graph database driver is not neptune")
+
+// Type check to ensure that NeptuneReader implements the GraphReader interface
+var _ GraphReader = (*NeptuneReader)(nil)
+
+// NeptunePool is the subset of the neptune connection pool used by NeptuneReader
+type NeptunePool interface {
+	Get(query string, bindings, rebindings map[string]string) ([]graphson.Vertex, error)
+	GetStringList(query string, bindings, rebindings map[string]string) ([]string, error)
+}
+
+// NeptuneReader reads back the nodes created by an import from a neptune graph database
+type NeptuneReader struct {
+	Pool NeptunePool
+}
+
+// NewNeptuneReader returns a NeptuneReader that uses the connection pool of the provided graph database,
+// or ErrNotNeptune if it is not backed by neptune
+func NewNeptuneReader(db *graph.DB) (*NeptuneReader, error) {
+	neptuneDB, ok := db.Driver.(*neptune.NeptuneDB)
+	if !ok {
+		return nil, ErrNotNeptune
+	}
+	return &NeptuneReader{Pool: neptuneDB.Pool}, nil
+}
+
+// GetDimensionNodeIDs returns the ID of the node of each option of the provided dimension that is related to the instance node, by option
+func (n *NeptuneReader) GetDimensionNodeIDs(ctx context.Context, instanceID, dimensionID string) (map[string]string, error) {
+	if err := ValidateID(instanceID, dimensionID); err != nil {
+		return nil, err
+	}
+	vertices, err := n.Pool.Get(fmt.Sprintf(getDimensionNodes, instanceID, instanceID, dimensionID), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dimension nodes: %w", err)
+	}
+
+	nodeIDs := make(map[string]string, len(vertices))
+	for _, v := range vertices {
+		option, err := v.GetProperty("value")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the value of dimension node %s: %w", v.GetID(), err)
+		}
+		nodeIDs[option] = v.GetID()
+	}
+	return nodeIDs, nil
+}
+
+// GetInstanceCodes returns the codes of the provided code list that have a relationship to the instance node
+func (n *NeptuneReader) GetInstanceCodes(ctx context.Context, instanceID, codeListID string) ([]string, error) {
+	if err := ValidateID(instanceID, codeListID); err != nil {
+		return nil, err
+	}
+	codes, err := n.Pool.GetStringList(fmt.Sprintf(getInstanceCodes, instanceID, codeListID), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance codes: %w", err)
+	}
+	return codes, nil
+}
+
+// GetCodeListInstances returns the IDs of the instances whose nodes have a relationship to any code of the provided code list
+func (n *NeptuneReader) GetCodeListInstances(ctx context.Context, codeListID string) ([]string, error) {
+	if err := ValidateID(codeListID); err != nil {
+		return nil, err
+	}
+	nodeIDs, err := n.Pool.GetStringList(fmt.Sprintf(getCodeListInstances, codeListID), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get code list instances: %w", err)
+	}
+
+	instanceIDs := make([]string, 0, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		if !strings.HasPrefix(nodeID, instanceNodeIDPrefix) || !strings.HasSuffix(nodeID, instanceNodeIDSuffix) {
+			return nil, fmt.Errorf("unexpected instance node id: %s", nodeID)
+		}
+		instanceIDs = append(instanceIDs, strings.TrimSuffix(strings.TrimPrefix(nodeID, instanceNodeIDPrefix), instanceNodeIDSuffix))
+	}
+	return instanceIDs, nil
+}
+
+// InstanceConstraintExists always returns true, as constraints are not a neptune construct
+// and CreateInstanceConstraint does not create anything in the neptune implementation
+func (n *NeptuneReader) InstanceConstraintExists(ctx context.Context, instanceID string) (bool, error) {
+	return true, nil
+}
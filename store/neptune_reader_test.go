@@ -0,0 +1,163 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ONSdigital/dp-dimension-importer/store"
+	"github.com/ONSdigital/dp-dimension-importer/store/storertest"
+	"github.com/ONSdigital/graphson"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var (
+	ctx     = context.Background()
+	errPool = errors.New("pool error")
+)
+
+// dimensionVertex returns a dimension node vertex with the provided ID and option value
+func dimensionVertex(id, option string) graphson.Vertex {
+	return graphson.Vertex{
+		Type: "g:Vertex",
+		Value: graphson.VertexValue{
+			ID:    id,
+			Label: "_instance1_geography",
+			Properties: map[string][]graphson.VertexProperty{
+				"value": {{Type: "g:VertexProperty", Value: graphson.VertexPropertyValue{Label: "value", Value: option}}},
+			},
+		},
+	}
+}
+
+func TestNeptuneReader_GetDimensionNodeIDs(t *testing.T) {
+	Convey("Given a neptune pool that returns two dimension nodes", t, func() {
+		pool := &storertest.NeptunePoolMock{
+			GetFunc: func(query string, bindings map[string]string, rebindings map[string]string) ([]graphson.Vertex, error) {
+				return []graphson.Vertex{
+					dimensionVertex("_instance1_geography_K02000001", "K02000001"),
+					dimensionVertex("_instance1_geography_W92000004", "W92000004"),
+				}, nil
+			},
+		}
+		reader := &store.NeptuneReader{Pool: pool}
+
+		Convey("When GetDimensionNodeIDs is called", func() {
+			nodeIDs, err := reader.GetDimensionNodeIDs(ctx, "instance1", "geography")
+
+			Convey("Then the node ID of each option is returned", func() {
+				So(err, ShouldBeNil)
+				So(nodeIDs, ShouldResemble, map[string]string{
+					"K02000001": "_instance1_geography_K02000001",
+					"W92000004": "_instance1_geography_W92000004",
+				})
+			})
+
+			Convey("Then the dimension nodes related to the instance node are queried", func() {
+				So(pool.GetCalls(), ShouldHaveLength, 1)
+				So(pool.GetCalls()[0].Query, ShouldEqual, `g.V('_instance1_Instance').in('HAS_DIMENSION').hasLabel('_instance1_geography')`)
+			})
+		})
+	})
+
+	Convey("Given a neptune pool that returns an error", t, func() {
+		pool := &storertest.NeptunePoolMock{
+			GetFunc: func(query string, bindings map[string]string, rebindings map[string]string) ([]graphson.Vertex, error) {
+				return nil, errPool
+			},
+		}
+		reader := &store.NeptuneReader{Pool: pool}
+
+		Convey("When GetDimensionNodeIDs is called", func() {
+			_, err := reader.GetDimensionNodeIDs(ctx, "instance1", "geography")
+
+			Convey("Then the error is wrapped and returned", func() {
+				So(errors.Is(err, errPool), ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestNeptuneReader_GetInstanceCodes(t *testing.T) {
+	Convey("Given a neptune pool that returns two codes", t, func() {
+		pool := &storertest.NeptunePoolMock{
+			GetStringListFunc: func(query string, bindings map[string]string, rebindings map[string]string) ([]string, error) {
+				return []string{"K02000001", "W92000004"}, nil
+			},
+		}
+		reader := &store.NeptuneReader{Pool: pool}
+
+		Convey("When GetInstanceCodes is called", func() {
+			codes, err := reader.GetInstanceCodes(ctx, "instance1", "geography-codes")
+
+			Convey("Then the codes of the code list related to the instance node are returned", func() {
+				So(err, ShouldBeNil)
+				So(codes, ShouldResemble, []string{"K02000001", "W92000004"})
+				So(pool.GetStringListCalls()[0].Query, ShouldEqual, `g.V('_instance1_Instance').in('inDataset').hasLabel('_code')`+
+					`.where(out('usedBy').hasLabel('_code_list').has('listID','geography-codes')).values('value')`)
+			})
+		})
+	})
+}
+
+func TestNeptuneReader_GetCodeListInstances(t *testing.T) {
+	Convey("Given a neptune pool that returns two instance node IDs", t, func() {
+		pool := &storertest.NeptunePoolMock{
+			GetStringListFunc: func(query string, bindings map[string]string, rebindings map[string]string) ([]string, error) {
+				return []string{"_instance1_Instance", "_instance2_Instance"}, nil
+			},
+		}
+		reader := &store.NeptuneReader{Pool: pool}
+
+		Convey("When GetCodeListInstances is called", func() {
+			instanceIDs, err := reader.GetCodeListInstances(ctx, "geography-codes")
+
+			Convey("Then the IDs of the instances related to the codes of the code list are returned", func() {
+				So(err, ShouldBeNil)
+				So(instanceIDs, ShouldResemble, []string{"instance1", "instance2"})
+				So(pool.GetStringListCalls()[0].Query, ShouldEqual, `g.V().hasLabel('_code_list').has('listID','geography-codes')`+
+					`.in('usedBy').hasLabel('_code').out('inDataset').dedup().id()`)
+			})
+		})
+	})
+
+	Convey("Given a neptune pool", t, func() {
+		pool := &storertest.NeptunePoolMock{}
+		reader := &store.NeptuneReader{Pool: pool}
+
+		Convey("When GetCodeListInstances is called with a code list id containing quotes", func() {
+			_, err := reader.GetCodeListInstances(ctx, "x').drop().iterate();g.V().has('listID','")
+
+			Convey("Then ErrInvalidID is returned and no query is executed", func() {
+				So(errors.Is(err, store.ErrInvalidID), ShouldBeTrue)
+				So(pool.GetStringListCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When GetInstanceCodes is called with an instance id containing quotes", func() {
+			_, err := reader.GetInstanceCodes(ctx, "instance1')", "geography-codes")
+
+			Convey("Then ErrInvalidID is returned and no query is executed", func() {
+				So(errors.Is(err, store.ErrInvalidID), ShouldBeTrue)
+				So(pool.GetStringListCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a neptune pool that returns a node ID that is not an instance node ID", t, func() {
+		pool := &storertest.NeptunePoolMock{
+			GetStringListFunc: func(query string, bindings map[string]string, rebindings map[string]string) ([]string, error) {
+				return []string{"_instance1_geography_K02000001"}, nil
+			},
+		}
+		reader := &store.NeptuneReader{Pool: pool}
+
+		Convey("When GetCodeListInstances is called", func() {
+			_, err := reader.GetCodeListInstances(ctx, "geography-codes")
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
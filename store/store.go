@@ -9,6 +9,11 @@ import (
 )
 
 //go:generate moq -out storertest/storer.go -pkg storertest . Storer
+//go:generate moq -out storertest/version_details_storer.go -pkg storertest . VersionDetailsStorer
+//go:generate moq -out storertest/graph_reader.go -pkg storertest . GraphReader
+//go:generate moq -out storertest/neptune_pool.go -pkg storertest . NeptunePool
+//go:generate moq -out storertest/instance_deleter.go -pkg storertest . InstanceDeleter
+//go:generate moq -out storertest/neptune_executor.go -pkg storertest . NeptuneExecutor
 
 // Storer is an interface representing the required methods to interact with the DB for instances and dimensions
 type Storer interface {
@@ -23,3 +28,25 @@ type Storer interface {
 	Close(ctx context.Context) error
 	ErrorChan() chan error
 }
+
+// VersionDetailsStorer is an optional interface, implemented by stores that can attach the dataset, edition and version details to an instance node
+type VersionDetailsStorer interface {
+	AddVersionDetailsToInstance(ctx context.Context, instanceID, datasetID, edition string, version int) error
+}
+
+// GraphReader is an optional interface, implemented by stores that can read back the nodes created by an import,
+// so that they can be reconciled with the dimension options in dataset API
+type GraphReader interface {
+	GetDimensionNodeIDs(ctx context.Context, instanceID, dimensionID string) (nodeIDs map[string]string, err error)
+	GetInstanceCodes(ctx context.Context, instanceID, codeListID string) (codes []string, err error)
+	GetCodeListInstances(ctx context.Context, codeListID string) (instanceIDs []string, err error)
+	InstanceConstraintExists(ctx context.Context, instanceID string) (bool, error)
+}
+
+// InstanceDeleter is an optional interface, implemented by stores that can delete the nodes created by the import of an instance,
+// so that an existing instance can be imported again, or its graph data removed once it has been deleted.
+// DeleteInstance deletes the instance node, its dimension nodes and their code relationships.
+type InstanceDeleter interface {
+	DeleteInstance(ctx context.Context, instanceID string) error
+	DeleteInstanceConstraint(ctx context.Context, instanceID string) error
+}
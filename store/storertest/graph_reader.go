@@ -0,0 +1,244 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package storertest
+
+import (
+	"context"
+	"github.com/ONSdigital/dp-dimension-importer/store"
+	"sync"
+)
+
+// Ensure, that GraphReaderMock does implement store.GraphReader.
+// If this is not the case, regenerate this file with moq.
+var _ store.GraphReader = &GraphReaderMock{}
+
+// GraphReaderMock is a mock implementation of store.GraphReader.
+//
+//	func TestSomethingThatUsesGraphReader(t *testing.T) {
+//
+//		// make and configure a mocked store.GraphReader
+//		mockedGraphReader := &GraphReaderMock{
+//			GetCodeListInstancesFunc: func(ctx context.Context, codeListID string) ([]string, error) {
+//				panic("mock out the GetCodeListInstances method")
+//			},
+//			GetDimensionNodeIDsFunc: func(ctx context.Context, instanceID string, dimensionID string) (map[string]string, error) {
+//				panic("mock out the GetDimensionNodeIDs method")
+//			},
+//			GetInstanceCodesFunc: func(ctx context.Context, instanceID string, codeListID string) ([]string, error) {
+//				panic("mock out the GetInstanceCodes method")
+//			},
+//			InstanceConstraintExistsFunc: func(ctx context.Context, instanceID string) (bool, error) {
+//				panic("mock out the InstanceConstraintExists method")
+//			},
+//		}
+//
+//		// use mockedGraphReader in code that requires store.GraphReader
+//		// and then make assertions.
+//
+//	}
+type GraphReaderMock struct {
+	// GetCodeListInstancesFunc mocks the GetCodeListInstances method.
+	GetCodeListInstancesFunc func(ctx context.Context, codeListID string) ([]string, error)
+
+	// GetDimensionNodeIDsFunc mocks the GetDimensionNodeIDs method.
+	GetDimensionNodeIDsFunc func(ctx context.Context, instanceID string, dimensionID string) (map[string]string, error)
+
+	// GetInstanceCodesFunc mocks the GetInstanceCodes method.
+	GetInstanceCodesFunc func(ctx context.Context, instanceID string, codeListID string) ([]string, error)
+
+	// InstanceConstraintExistsFunc mocks the InstanceConstraintExists method.
+	InstanceConstraintExistsFunc func(ctx context.Context, instanceID string) (bool, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// GetCodeListInstances holds details about calls to the GetCodeListInstances method.
+		GetCodeListInstances []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// CodeListID is the codeListID argument value.
+			CodeListID string
+		}
+		// GetDimensionNodeIDs holds details about calls to the GetDimensionNodeIDs method.
+		GetDimensionNodeIDs []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// DimensionID is the dimensionID argument value.
+			DimensionID string
+		}
+		// GetInstanceCodes holds details about calls to the GetInstanceCodes method.
+		GetInstanceCodes []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// CodeListID is the codeListID argument value.
+			CodeListID string
+		}
+		// InstanceConstraintExists holds details about calls to the InstanceConstraintExists method.
+		InstanceConstraintExists []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+		}
+	}
+	lockGetCodeListInstances     sync.RWMutex
+	lockGetDimensionNodeIDs      sync.RWMutex
+	lockGetInstanceCodes         sync.RWMutex
+	lockInstanceConstraintExists sync.RWMutex
+}
+
+// GetCodeListInstances calls GetCodeListInstancesFunc.
+func (mock *GraphReaderMock) GetCodeListInstances(ctx context.Context, codeListID string) ([]string, error) {
+	if mock.GetCodeListInstancesFunc == nil {
+		panic("GraphReaderMock.GetCodeListInstancesFunc: method is nil but GraphReader.GetCodeListInstances was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		CodeListID string
+	}{
+		Ctx:        ctx,
+		CodeListID: codeListID,
+	}
+	mock.lockGetCodeListInstances.Lock()
+	mock.calls.GetCodeListInstances = append(mock.calls.GetCodeListInstances, callInfo)
+	mock.lockGetCodeListInstances.Unlock()
+	return mock.GetCodeListInstancesFunc(ctx, codeListID)
+}
+
+// GetCodeListInstancesCalls gets all the calls that were made to GetCodeListInstances.
+// Check the length with:
+//
+//	len(mockedGraphReader.GetCodeListInstancesCalls())
+func (mock *GraphReaderMock) GetCodeListInstancesCalls() []struct {
+	Ctx        context.Context
+	CodeListID string
+} {
+	var calls []struct {
+		Ctx        context.Context
+		CodeListID string
+	}
+	mock.lockGetCodeListInstances.RLock()
+	calls = mock.calls.GetCodeListInstances
+	mock.lockGetCodeListInstances.RUnlock()
+	return calls
+}
+
+// GetDimensionNodeIDs calls GetDimensionNodeIDsFunc.
+func (mock *GraphReaderMock) GetDimensionNodeIDs(ctx context.Context, instanceID string, dimensionID string) (map[string]string, error) {
+	if mock.GetDimensionNodeIDsFunc == nil {
+		panic("GraphReaderMock.GetDimensionNodeIDsFunc: method is nil but GraphReader.GetDimensionNodeIDs was just called")
+	}
+	callInfo := struct {
+		Ctx         context.Context
+		InstanceID  string
+		DimensionID string
+	}{
+		Ctx:         ctx,
+		InstanceID:  instanceID,
+		DimensionID: dimensionID,
+	}
+	mock.lockGetDimensionNodeIDs.Lock()
+	mock.calls.GetDimensionNodeIDs = append(mock.calls.GetDimensionNodeIDs, callInfo)
+	mock.lockGetDimensionNodeIDs.Unlock()
+	return mock.GetDimensionNodeIDsFunc(ctx, instanceID, dimensionID)
+}
+
+// GetDimensionNodeIDsCalls gets all the calls that were made to GetDimensionNodeIDs.
+// Check the length with:
+//
+//	len(mockedGraphReader.GetDimensionNodeIDsCalls())
+func (mock *GraphReaderMock) GetDimensionNodeIDsCalls() []struct {
+	Ctx         context.Context
+	InstanceID  string
+	DimensionID string
+} {
+	var calls []struct {
+		Ctx         context.Context
+		InstanceID  string
+		DimensionID string
+	}
+	mock.lockGetDimensionNodeIDs.RLock()
+	calls = mock.calls.GetDimensionNodeIDs
+	mock.lockGetDimensionNodeIDs.RUnlock()
+	return calls
+}
+
+// GetInstanceCodes calls GetInstanceCodesFunc.
+func (mock *GraphReaderMock) GetInstanceCodes(ctx context.Context, instanceID string, codeListID string) ([]string, error) {
+	if mock.GetInstanceCodesFunc == nil {
+		panic("GraphReaderMock.GetInstanceCodesFunc: method is nil but GraphReader.GetInstanceCodes was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		InstanceID string
+		CodeListID string
+	}{
+		Ctx:        ctx,
+		InstanceID: instanceID,
+		CodeListID: codeListID,
+	}
+	mock.lockGetInstanceCodes.Lock()
+	mock.calls.GetInstanceCodes = append(mock.calls.GetInstanceCodes, callInfo)
+	mock.lockGetInstanceCodes.Unlock()
+	return mock.GetInstanceCodesFunc(ctx, instanceID, codeListID)
+}
+
+// GetInstanceCodesCalls gets all the calls that were made to GetInstanceCodes.
+// Check the length with:
+//
+//	len(mockedGraphReader.GetInstanceCodesCalls())
+func (mock *GraphReaderMock) GetInstanceCodesCalls() []struct {
+	Ctx        context.Context
+	InstanceID string
+	CodeListID string
+} {
+	var calls []struct {
+		Ctx        context.Context
+		InstanceID string
+		CodeListID string
+	}
+	mock.lockGetInstanceCodes.RLock()
+	calls = mock.calls.GetInstanceCodes
+	mock.lockGetInstanceCodes.RUnlock()
+	return calls
+}
+
+// InstanceConstraintExists calls InstanceConstraintExistsFunc.
+func (mock *GraphReaderMock) InstanceConstraintExists(ctx context.Context, instanceID string) (bool, error) {
+	if mock.InstanceConstraintExistsFunc == nil {
+		panic("GraphReaderMock.InstanceConstraintExistsFunc: method is nil but GraphReader.InstanceConstraintExists was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		InstanceID string
+	}{
+		Ctx:        ctx,
+		InstanceID: instanceID,
+	}
+	mock.lockInstanceConstraintExists.Lock()
+	mock.calls.InstanceConstraintExists = append(mock.calls.InstanceConstraintExists, callInfo)
+	mock.lockInstanceConstraintExists.Unlock()
+	return mock.InstanceConstraintExistsFunc(ctx, instanceID)
+}
+
+// InstanceConstraintExistsCalls gets all the calls that were made to InstanceConstraintExists.
+// Check the length with:
+//
+//	len(mockedGraphReader.InstanceConstraintExistsCalls())
+func (mock *GraphReaderMock) InstanceConstraintExistsCalls() []struct {
+	Ctx        context.Context
+	InstanceID string
+} {
+	var calls []struct {
+		Ctx        context.Context
+		InstanceID string
+	}
+	mock.lockInstanceConstraintExists.RLock()
+	calls = mock.calls.InstanceConstraintExists
+	mock.lockInstanceConstraintExists.RUnlock()
+	return calls
+}
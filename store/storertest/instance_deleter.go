@@ -0,0 +1,132 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package storertest
+
+import (
+	"context"
+	"github.com/ONSdigital/dp-dimension-importer/store"
+	"sync"
+)
+
+// Ensure, that InstanceDeleterMock does implement store.InstanceDeleter.
+// If this is not the case, regenerate this file with moq.
+var _ store.InstanceDeleter = &InstanceDeleterMock{}
+
+// InstanceDeleterMock is a mock implementation of store.InstanceDeleter.
+//
+//	func TestSomethingThatUsesInstanceDeleter(t *testing.T) {
+//
+//		// make and configure a mocked store.InstanceDeleter
+//		mockedInstanceDeleter := &InstanceDeleterMock{
+//			DeleteInstanceFunc: func(ctx context.Context, instanceID string) error {
+//				panic("mock out the DeleteInstance method")
+//			},
+//			DeleteInstanceConstraintFunc: func(ctx context.Context, instanceID string) error {
+//				panic("mock out the DeleteInstanceConstraint method")
+//			},
+//		}
+//
+//		// use mockedInstanceDeleter in code that requires store.InstanceDeleter
+//		// and then make assertions.
+//
+//	}
+type InstanceDeleterMock struct {
+	// DeleteInstanceFunc mocks the DeleteInstance method.
+	DeleteInstanceFunc func(ctx context.Context, instanceID string) error
+
+	// DeleteInstanceConstraintFunc mocks the DeleteInstanceConstraint method.
+	DeleteInstanceConstraintFunc func(ctx context.Context, instanceID string) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// DeleteInstance holds details about calls to the DeleteInstance method.
+		DeleteInstance []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+		}
+		// DeleteInstanceConstraint holds details about calls to the DeleteInstanceConstraint method.
+		DeleteInstanceConstraint []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+		}
+	}
+	lockDeleteInstance           sync.RWMutex
+	lockDeleteInstanceConstraint sync.RWMutex
+}
+
+// DeleteInstance calls DeleteInstanceFunc.
+func (mock *InstanceDeleterMock) DeleteInstance(ctx context.Context, instanceID string) error {
+	if mock.DeleteInstanceFunc == nil {
+		panic("InstanceDeleterMock.DeleteInstanceFunc: method is nil but InstanceDeleter.DeleteInstance was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		InstanceID string
+	}{
+		Ctx:        ctx,
+		InstanceID: instanceID,
+	}
+	mock.lockDeleteInstance.Lock()
+	mock.calls.DeleteInstance = append(mock.calls.DeleteInstance, callInfo)
+	mock.lockDeleteInstance.Unlock()
+	return mock.DeleteInstanceFunc(ctx, instanceID)
+}
+
+// DeleteInstanceCalls gets all the calls that were made to DeleteInstance.
+// Check the length with:
+//
+//	len(mockedInstanceDeleter.DeleteInstanceCalls())
+func (mock *InstanceDeleterMock) DeleteInstanceCalls() []struct {
+	Ctx        context.Context
+	InstanceID string
+} {
+	var calls []struct {
+		Ctx        context.Context
+		InstanceID string
+	}
+	mock.lockDeleteInstance.RLock()
+	calls = mock.calls.DeleteInstance
+	mock.lockDeleteInstance.RUnlock()
+	return calls
+}
+
+// DeleteInstanceConstraint calls DeleteInstanceConstraintFunc.
+func (mock *InstanceDeleterMock) DeleteInstanceConstraint(ctx context.Context, instanceID string) error {
+	if mock.DeleteInstanceConstraintFunc == nil {
+		panic("InstanceDeleterMock.DeleteInstanceConstraintFunc: method is nil but InstanceDeleter.DeleteInstanceConstraint was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		InstanceID string
+	}{
+		Ctx:        ctx,
+		InstanceID: instanceID,
+	}
+	mock.lockDeleteInstanceConstraint.Lock()
+	mock.calls.DeleteInstanceConstraint = append(mock.calls.DeleteInstanceConstraint, callInfo)
+	mock.lockDeleteInstanceConstraint.Unlock()
+	return mock.DeleteInstanceConstraintFunc(ctx, instanceID)
+}
+
+// DeleteInstanceConstraintCalls gets all the calls that were made to DeleteInstanceConstraint.
+// Check the length with:
+//
+//	len(mockedInstanceDeleter.DeleteInstanceConstraintCalls())
+func (mock *InstanceDeleterMock) DeleteInstanceConstraintCalls() []struct {
+	Ctx        context.Context
+	InstanceID string
+} {
+	var calls []struct {
+		Ctx        context.Context
+		InstanceID string
+	}
+	mock.lockDeleteInstanceConstraint.RLock()
+	calls = mock.calls.DeleteInstanceConstraint
+	mock.lockDeleteInstanceConstraint.RUnlock()
+	return calls
+}
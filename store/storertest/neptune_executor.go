@@ -0,0 +1,88 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package storertest
+
+import (
+	"github.com/ONSdigital/dp-dimension-importer/store"
+	gremgo "github.com/ONSdigital/gremgo-neptune"
+	"sync"
+)
+
+// Ensure, that NeptuneExecutorMock does implement store.NeptuneExecutor.
+// If this is not the case, regenerate this file with moq.
+var _ store.NeptuneExecutor = &NeptuneExecutorMock{}
+
+// NeptuneExecutorMock is a mock implementation of store.NeptuneExecutor.
+//
+//	func TestSomethingThatUsesNeptuneExecutor(t *testing.T) {
+//
+//		// make and configure a mocked store.NeptuneExecutor
+//		mockedNeptuneExecutor := &NeptuneExecutorMock{
+//			ExecuteFunc: func(query string, bindings map[string]string, rebindings map[string]string) ([]gremgo.Response, error) {
+//				panic("mock out the Execute method")
+//			},
+//		}
+//
+//		// use mockedNeptuneExecutor in code that requires store.NeptuneExecutor
+//		// and then make assertions.
+//
+//	}
+type NeptuneExecutorMock struct {
+	// ExecuteFunc mocks the Execute method.
+	ExecuteFunc func(query string, bindings map[string]string, rebindings map[string]string) ([]gremgo.Response, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Execute holds details about calls to the Execute method.
+		Execute []struct {
+			// Query is the query argument value.
+			Query string
+			// Bindings is the bindings argument value.
+			Bindings map[string]string
+			// Rebindings is the rebindings argument value.
+			Rebindings map[string]string
+		}
+	}
+	lockExecute sync.RWMutex
+}
+
+// Execute calls ExecuteFunc.
+func (mock *NeptuneExecutorMock) Execute(query string, bindings map[string]string, rebindings map[string]string) ([]gremgo.Response, error) {
+	if mock.ExecuteFunc == nil {
+		panic("NeptuneExecutorMock.ExecuteFunc: method is nil but NeptuneExecutor.Execute was just called")
+	}
+	callInfo := struct {
+		Query      string
+		Bindings   map[string]string
+		Rebindings map[string]string
+	}{
+		Query:      query,
+		Bindings:   bindings,
+		Rebindings: rebindings,
+	}
+	mock.lockExecute.Lock()
+	mock.calls.Execute = append(mock.calls.Execute, callInfo)
+	mock.lockExecute.Unlock()
+	return mock.ExecuteFunc(query, bindings, rebindings)
+}
+
+// ExecuteCalls gets all the calls that were made to Execute.
+// Check the length with:
+//
+//	len(mockedNeptuneExecutor.ExecuteCalls())
+func (mock *NeptuneExecutorMock) ExecuteCalls() []struct {
+	Query      string
+	Bindings   map[string]string
+	Rebindings map[string]string
+} {
+	var calls []struct {
+		Query      string
+		Bindings   map[string]string
+		Rebindings map[string]string
+	}
+	mock.lockExecute.RLock()
+	calls = mock.calls.Execute
+	mock.lockExecute.RUnlock()
+	return calls
+}
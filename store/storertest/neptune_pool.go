@@ -0,0 +1,144 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package storertest
+
+import (
+	"github.com/ONSdigital/dp-dimension-importer/store"
+	"github.com/ONSdigital/graphson"
+	"sync"
+)
+
+// Ensure, that NeptunePoolMock does implement store.NeptunePool.
+// If this is not the case, regenerate this file with moq.
+var _ store.NeptunePool = &NeptunePoolMock{}
+
+// NeptunePoolMock is a mock implementation of store.NeptunePool.
+//
+//	func TestSomethingThatUsesNeptunePool(t *testing.T) {
+//
+//		// make and configure a mocked store.NeptunePool
+//		mockedNeptunePool := &NeptunePoolMock{
+//			GetFunc: func(query string, bindings map[string]string, rebindings map[string]string) ([]graphson.Vertex, error) {
+//				panic("mock out the Get method")
+//			},
+//			GetStringListFunc: func(query string, bindings map[string]string, rebindings map[string]string) ([]string, error) {
+//				panic("mock out the GetStringList method")
+//			},
+//		}
+//
+//		// use mockedNeptunePool in code that requires store.NeptunePool
+//		// and then make assertions.
+//
+//	}
+type NeptunePoolMock struct {
+	// GetFunc mocks the Get method.
+	GetFunc func(query string, bindings map[string]string, rebindings map[string]string) ([]graphson.Vertex, error)
+
+	// GetStringListFunc mocks the GetStringList method.
+	GetStringListFunc func(query string, bindings map[string]string, rebindings map[string]string) ([]string, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Get holds details about calls to the Get method.
+		Get []struct {
+			// Query is the query argument value.
+			Query string
+			// Bindings is the bindings argument value.
+			Bindings map[string]string
+			// Rebindings is the rebindings argument value.
+			Rebindings map[string]string
+		}
+		// GetStringList holds details about calls to the GetStringList method.
+		GetStringList []struct {
+			// Query is the query argument value.
+			Query string
+			// Bindings is the bindings argument value.
+			Bindings map[string]string
+			// Rebindings is the rebindings argument value.
+			Rebindings map[string]string
+		}
+	}
+	lockGet           sync.RWMutex
+	lockGetStringList sync.RWMutex
+}
+
+// Get calls GetFunc.
+func (mock *NeptunePoolMock) Get(query string, bindings map[string]string, rebindings map[string]string) ([]graphson.Vertex, error) {
+	if mock.GetFunc == nil {
+		panic("NeptunePoolMock.GetFunc: method is nil but NeptunePool.Get was just called")
+	}
+	callInfo := struct {
+		Query      string
+		Bindings   map[string]string
+		Rebindings map[string]string
+	}{
+		Query:      query,
+		Bindings:   bindings,
+		Rebindings: rebindings,
+	}
+	mock.lockGet.Lock()
+	mock.calls.Get = append(mock.calls.Get, callInfo)
+	mock.lockGet.Unlock()
+	return mock.GetFunc(query, bindings, rebindings)
+}
+
+// GetCalls gets all the calls that were made to Get.
+// Check the length with:
+//
+//	len(mockedNeptunePool.GetCalls())
+func (mock *NeptunePoolMock) GetCalls() []struct {
+	Query      string
+	Bindings   map[string]string
+	Rebindings map[string]string
+} {
+	var calls []struct {
+		Query      string
+		Bindings   map[string]string
+		Rebindings map[string]string
+	}
+	mock.lockGet.RLock()
+	calls = mock.calls.Get
+	mock.lockGet.RUnlock()
+	return calls
+}
+
+// GetStringList calls GetStringListFunc.
+func (mock *NeptunePoolMock) GetStringList(query string, bindings map[string]string, rebindings map[string]string) ([]string, error) {
+	if mock.GetStringListFunc == nil {
+		panic("NeptunePoolMock.GetStringListFunc: method is nil but NeptunePool.GetStringList was just called")
+	}
+	callInfo := struct {
+		Query      string
+		Bindings   map[string]string
+		Rebindings map[string]string
+	}{
+		Query:      query,
+		Bindings:   bindings,
+		Rebindings: rebindings,
+	}
+	mock.lockGetStringList.Lock()
+	mock.calls.GetStringList = append(mock.calls.GetStringList, callInfo)
+	mock.lockGetStringList.Unlock()
+	return mock.GetStringListFunc(query, bindings, rebindings)
+}
+
+// GetStringListCalls gets all the calls that were made to GetStringList.
+// Check the length with:
+//
+//	len(mockedNeptunePool.GetStringListCalls())
+func (mock *NeptunePoolMock) GetStringListCalls() []struct {
+	Query      string
+	Bindings   map[string]string
+	Rebindings map[string]string
+} {
+	var calls []struct {
+		Query      string
+		Bindings   map[string]string
+		Rebindings map[string]string
+	}
+	mock.lockGetStringList.RLock()
+	calls = mock.calls.GetStringList
+	mock.lockGetStringList.RUnlock()
+	return calls
+}
@@ -0,0 +1,100 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package storertest
+
+import (
+	"context"
+	"github.com/ONSdigital/dp-dimension-importer/store"
+	"sync"
+)
+
+// Ensure, that VersionDetailsStorerMock does implement store.VersionDetailsStorer.
+// If this is not the case, regenerate this file with moq.
+var _ store.VersionDetailsStorer = &VersionDetailsStorerMock{}
+
+// VersionDetailsStorerMock is a mock implementation of store.VersionDetailsStorer.
+//
+//	func TestSomethingThatUsesVersionDetailsStorer(t *testing.T) {
+//
+//		// make and configure a mocked store.VersionDetailsStorer
+//		mockedVersionDetailsStorer := &VersionDetailsStorerMock{
+//			AddVersionDetailsToInstanceFunc: func(ctx context.Context, instanceID string, datasetID string, edition string, version int) error {
+//				panic("mock out the AddVersionDetailsToInstance method")
+//			},
+//		}
+//
+//		// use mockedVersionDetailsStorer in code that requires store.VersionDetailsStorer
+//		// and then make assertions.
+//
+//	}
+type VersionDetailsStorerMock struct {
+	// AddVersionDetailsToInstanceFunc mocks the AddVersionDetailsToInstance method.
+	AddVersionDetailsToInstanceFunc func(ctx context.Context, instanceID string, datasetID string, edition string, version int) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// AddVersionDetailsToInstance holds details about calls to the AddVersionDetailsToInstance method.
+		AddVersionDetailsToInstance []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// DatasetID is the datasetID argument value.
+			DatasetID string
+			// Edition is the edition argument value.
+			Edition string
+			// Version is the version argument value.
+			Version int
+		}
+	}
+	lockAddVersionDetailsToInstance sync.RWMutex
+}
+
+// AddVersionDetailsToInstance calls AddVersionDetailsToInstanceFunc.
+func (mock *VersionDetailsStorerMock) AddVersionDetailsToInstance(ctx context.Context, instanceID string, datasetID string, edition string, version int) error {
+	if mock.AddVersionDetailsToInstanceFunc == nil {
+		panic("VersionDetailsStorerMock.AddVersionDetailsToInstanceFunc: method is nil but VersionDetailsStorer.AddVersionDetailsToInstance was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		InstanceID string
+		DatasetID  string
+		Edition    string
+		Version    int
+	}{
+		Ctx:        ctx,
+		InstanceID: instanceID,
+		DatasetID:  datasetID,
+		Edition:    edition,
+		Version:    version,
+	}
+	mock.lockAddVersionDetailsToInstance.Lock()
+	mock.calls.AddVersionDetailsToInstance = append(mock.calls.AddVersionDetailsToInstance, callInfo)
+	mock.lockAddVersionDetailsToInstance.Unlock()
+	return mock.AddVersionDetailsToInstanceFunc(ctx, instanceID, datasetID, edition, version)
+}
+
+// AddVersionDetailsToInstanceCalls gets all the calls that were made to AddVersionDetailsToInstance.
+// Check the length with:
+//
+//	len(mockedVersionDetailsStorer.AddVersionDetailsToInstanceCalls())
+func (mock *VersionDetailsStorerMock) AddVersionDetailsToInstanceCalls() []struct {
+	Ctx        context.Context
+	InstanceID string
+	DatasetID  string
+	Edition    string
+	Version    int
+} {
+	var calls []struct {
+		Ctx        context.Context
+		InstanceID string
+		DatasetID  string
+		Edition    string
+		Version    int
+	}
+	mock.lockAddVersionDetailsToInstance.RLock()
+	calls = mock.calls.AddVersionDetailsToInstance
+	mock.lockAddVersionDetailsToInstance.RUnlock()
+	return calls
+}